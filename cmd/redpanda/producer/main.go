@@ -1,8 +1,21 @@
 package main
 
-import "go-playground/internal/justforfun/redpanda/producer"
+import (
+	"flag"
+	"go-playground/internal/justforfun/redpanda/producer"
+	_ "net/http/pprof"
+	"strings"
+)
+
+var (
+	seedBrokers = flag.String("brokers", "localhost:19092", "comma delimited list of seed brokers")
+	topic       = flag.String("topic", "foobar", "topic to consume for metric incrementing")
+	produce     = flag.Bool("produce", true, "if true, rather than consume, produce to the topic once per second (value \"foobar\")")
+)
 
 func main() {
+	flag.Parse()
+
 	producer.PlaygroundRedPandaProducer()
-	producer.PlaygroundRedPandaProducerHook()
+	producer.PlaygroundRedPandaProducerHook(strings.Split(*seedBrokers, ","), *topic, *produce)
 }
@@ -1,11 +1,223 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"go-playground/internal/justforfun/vehiclesim"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
 func main() {
-	vehiclesim.PlotEngineTorqueCurve()
-	vehiclesim.VehicleSimulation()
+	command := ""
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
 
+	switch command {
+	case "fleet":
+		runFleet(os.Args[2:])
+	case "perf":
+		runPerformance(os.Args[2:])
+	case "rerun":
+		runRerun(os.Args[2:])
+	case "batch":
+		runBatch(os.Args[2:])
+	case "plot":
+		runPlot(os.Args[2:])
+	case "replay":
+		runReplay(os.Args[2:])
+	case "schema":
+		runSchema(os.Args[2:])
+	default:
+		vehiclesim.PlotEngineTorqueCurve()
+		vehiclesim.VehicleSimulation()
+	}
+}
+
+// runFleet parses the flags of "vehiclesim fleet" and runs the fleet simulation
+func runFleet(args []string) {
+	fleetFlags := flag.NewFlagSet("fleet", flag.ExitOnError)
+	vehicles := fleetFlags.Int("vehicles", 10, "number of simulated vehicles")
+	workers := fleetFlags.Int("workers", 0, "goroutines stepping the vehicles (0 = number of CPUs)")
+	timeScale := fleetFlags.Float64("timescale", 1, "simulated seconds per real second (0 = as fast as possible)")
+	duration := fleetFlags.Duration("duration", 0, "simulated time to run (0 = until Ctrl+C)")
+	seed := fleetFlags.Int64("seed", time.Now().UnixNano(), "base seed, vehicle i uses seed+i")
+	_ = fleetFlags.Parse(args)
+
+	vehiclesim.FleetSimulation(vehiclesim.FleetConfig{
+		Vehicles:  *vehicles,
+		Workers:   *workers,
+		TimeScale: *timeScale,
+		Duration:  *duration,
+		Seed:      *seed,
+	})
+}
+
+// runPerformance parses the flags of "vehiclesim perf [scenario...]" and runs the performance scenarios
+func runPerformance(args []string) {
+	perfFlags := flag.NewFlagSet("perf", flag.ExitOnError)
+	tireSpec := perfFlags.String("tire", vehiclesim.DefaultVehicleSpec().TireSpec, "tire spec of the car, e.g. \"245/40R19 98Y\"")
+	finalDrive := perfFlags.Float64("final-drive", vehiclesim.DefaultVehicleSpec().DifferentialRatio, "final drive ratio")
+	seed := perfFlags.Int64("seed", 1, "seed of the engine fluctuations")
+	perfFlags.Usage = func() {
+		fmt.Fprintf(perfFlags.Output(), "Usage: vehiclesim perf [flags] [%s ...]\n", strings.Join(vehiclesim.ScenarioNames, " | "))
+		perfFlags.PrintDefaults()
+	}
+	_ = perfFlags.Parse(args)
+
+	scenarios := perfFlags.Args()
+	if len(scenarios) == 0 {
+		scenarios = vehiclesim.ScenarioNames
+	}
+
+	spec := vehiclesim.DefaultVehicleSpec()
+	spec.TireSpec = *tireSpec
+	spec.DifferentialRatio = *finalDrive
+
+	if err := vehiclesim.PerformanceSimulation(scenarios, spec, *seed); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// runBatch parses the flags of "vehiclesim batch" and runs the parameter sweep
+func runBatch(args []string) {
+	batchFlags := flag.NewFlagSet("batch", flag.ExitOnError)
+	sweepFile := batchFlags.String("sweep", "", "JSON sweep definition (empty = final drive 3.5 to 4.5 on the default car)")
+	workers := batchFlags.Int("workers", 0, "goroutines running the cases (0 = number of CPUs)")
+	outputDir := batchFlags.String("out", "datalake", "directory of the CSV and JSON summary")
+	_ = batchFlags.Parse(args)
+
+	sweep := vehiclesim.DefaultSweep()
+	if *sweepFile != "" {
+		var err error
+		if sweep, err = vehiclesim.LoadSweep(*sweepFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	vehiclesim.BatchSimulation(sweep, *workers, *outputDir)
+}
+
+// runRerun parses the flags of "vehiclesim rerun <recording>" and replays the recorded driver inputs
+func runRerun(args []string) {
+	rerunFlags := flag.NewFlagSet("rerun", flag.ExitOnError)
+	after := rerunFlags.Duration("after", 10*time.Second, "simulated time to keep running after the last input")
+	rerunFlags.Usage = func() {
+		fmt.Fprintln(rerunFlags.Output(), "Usage: vehiclesim rerun [flags] <recording.jsonl>")
+		rerunFlags.PrintDefaults()
+	}
+	_ = rerunFlags.Parse(args)
+
+	if rerunFlags.NArg() != 1 {
+		rerunFlags.Usage()
+		os.Exit(2)
+	}
+
+	if err := vehiclesim.RerunRecording(rerunFlags.Arg(0), after.Seconds()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// runReplay parses the flags of "vehiclesim replay <telemetry>" and sends the recorded telemetry to the sinks
+func runReplay(args []string) {
+	replayFlags := flag.NewFlagSet("replay", flag.ExitOnError)
+	timeScale := replayFlags.Float64("timescale", 1, "recorded seconds per real second (0 = as fast as possible)")
+	now := replayFlags.Bool("now", false, "shift the timestamps so that the run starts now, needs a -timescale above 0")
+	channels := replayFlags.String("channels", "", "comma separated channels sent to InfluxDB and Sparkplug B (empty = all), Kafka, CAN and the telemetry file get every channel")
+	replayFlags.Usage = func() {
+		fmt.Fprintln(replayFlags.Output(), "Usage: vehiclesim replay [flags] <telemetry.jsonl | telemetry.csv>")
+		replayFlags.PrintDefaults()
+	}
+	_ = replayFlags.Parse(args)
+
+	if replayFlags.NArg() != 1 {
+		replayFlags.Usage()
+		os.Exit(2)
+	}
+	if *timeScale < 0 || (*now && *timeScale == 0) {
+		fmt.Fprintln(os.Stderr, "-timescale has to be above 0 with -now and cannot be negative")
+		os.Exit(2)
+	}
+
+	config := vehiclesim.ReplayConfig{TimeScale: *timeScale, Now: *now}
+	if *channels != "" {
+		config.Channels = strings.Split(*channels, ",")
+	}
+	if err := vehiclesim.ReplayTelemetry(replayFlags.Arg(0), config); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// runPlot parses the flags of "vehiclesim plot torque" and "vehiclesim plot run <recording>" and renders the images
+func runPlot(args []string) {
+	plotFlags := flag.NewFlagSet("plot", flag.ExitOnError)
+	formats := plotFlags.String("format", strings.Join(vehiclesim.PlotFormats, ","), "comma separated image formats (svg, png)")
+	outputDir := plotFlags.String("out", "datalake", "directory of the images")
+	channels := plotFlags.String("channels", strings.Join(vehiclesim.DefaultRunChannels, ","), "comma separated channels of \"plot run\"")
+	after := plotFlags.Duration("after", 10*time.Second, "simulated time to keep running after the last input of \"plot run\"")
+	plotFlags.Usage = func() {
+		fmt.Fprintln(plotFlags.Output(), "Usage: vehiclesim plot [flags] torque | run <recording.jsonl>")
+		plotFlags.PrintDefaults()
+	}
+	if len(args) == 0 {
+		plotFlags.Usage()
+		os.Exit(2)
+	}
+	target := args[0]
+	_ = plotFlags.Parse(args[1:])
+
+	var err error
+	switch {
+	case target == "torque" && plotFlags.NArg() == 0:
+		var files []string
+		files, err = vehiclesim.SaveTorqueCurvePlots(*outputDir, strings.Split(*formats, ","))
+		for _, file := range files {
+			fmt.Printf("Torque curve plotted in %s\n", file)
+		}
+	case target == "run" && plotFlags.NArg() == 1:
+		recordingPath := plotFlags.Arg(0)
+		name := strings.TrimSuffix(filepath.Base(recordingPath), filepath.Ext(recordingPath))
+		var outputs []string
+		for _, format := range strings.Split(*formats, ",") {
+			outputs = append(outputs, filepath.Join(*outputDir, name+"."+format))
+		}
+		err = vehiclesim.PlotRecording(recordingPath, after.Seconds(), strings.Split(*channels, ","), outputs)
+	default:
+		plotFlags.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// runSchema prints the telemetry schema of "vehiclesim schema" as JSON Schema or Avro
+func runSchema(args []string) {
+	schemaFlags := flag.NewFlagSet("schema", flag.ExitOnError)
+	format := schemaFlags.String("format", "json", "json (JSON Schema of the records) or avro")
+	_ = schemaFlags.Parse(args)
+
+	switch *format {
+	case "json":
+		schema, err := vehiclesim.TelemetryJSONSchema()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println(string(schema))
+	case "avro":
+		fmt.Println(vehiclesim.TelemetryAvroSchema())
+	default:
+		schemaFlags.Usage()
+		os.Exit(2)
+	}
 }
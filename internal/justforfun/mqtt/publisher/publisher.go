@@ -3,6 +3,7 @@ package publisher
 import (
 	"fmt"
 	"math/rand"
+	"net/url"
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
@@ -22,6 +23,71 @@ var connectLostHandler mqtt.ConnectionLostHandler = func(client mqtt.Client, err
 	fmt.Printf("Connection lost: %v", err)
 }
 
+// Publisher is a client connected to an MQTT broker that waits for each publish up to a timeout
+type Publisher struct {
+	client  mqtt.Client
+	servers []*url.URL
+	timeout time.Duration
+}
+
+// NewClientOptions returns the options of a client of the broker, printing the connection changes
+// Callers can change them before Connect, e.g. to set a will
+func NewClientOptions(broker string, clientID string) *mqtt.ClientOptions {
+	opts := mqtt.NewClientOptions()
+	opts.AddBroker(broker)
+	opts.SetClientID(clientID)
+	opts.OnConnect = connectHandler
+	opts.OnConnectionLost = connectLostHandler
+	return opts
+}
+
+// NewPublisher returns a client of the broker of the options that waits up to timeout for the
+// connection and for each publish. The client is set before Connect, the OnConnect handler can use it.
+func NewPublisher(opts *mqtt.ClientOptions, timeout time.Duration) *Publisher {
+	return &Publisher{client: mqtt.NewClient(opts), servers: opts.Servers, timeout: timeout}
+}
+
+// Connect connects to the broker
+func (p *Publisher) Connect() error {
+	token := p.client.Connect()
+	if !token.WaitTimeout(p.timeout) {
+		return fmt.Errorf("error connecting to %v: timeout", p.servers)
+	}
+	if token.Error() != nil {
+		return fmt.Errorf("error connecting to %v: %v", p.servers, token.Error())
+	}
+	return nil
+}
+
+// Publish sends the payload to the topic and waits until the broker takes it
+func (p *Publisher) Publish(topic string, qos byte, retained bool, payload interface{}) error {
+	token := p.client.Publish(topic, qos, retained, payload)
+	if !token.WaitTimeout(p.timeout) {
+		return fmt.Errorf("error publishing %s: timeout", topic)
+	}
+	if token.Error() != nil {
+		return fmt.Errorf("error publishing %s: %v", topic, token.Error())
+	}
+	return nil
+}
+
+// Subscribe calls handler with every message of the topic
+func (p *Publisher) Subscribe(topic string, qos byte, handler mqtt.MessageHandler) error {
+	token := p.client.Subscribe(topic, qos, handler)
+	if !token.WaitTimeout(p.timeout) {
+		return fmt.Errorf("error subscribing to %s: timeout", topic)
+	}
+	if token.Error() != nil {
+		return fmt.Errorf("error subscribing to %s: %v", topic, token.Error())
+	}
+	return nil
+}
+
+// Disconnect waits up to quiesce milliseconds for the pending work and closes the connection
+func (p *Publisher) Disconnect(quiesce uint) {
+	p.client.Disconnect(quiesce)
+}
+
 func generateRandomMessage(messages []string) string {
 	return messages[rand.Intn(len(messages))]
 }
@@ -41,15 +107,9 @@ func RickAndRoll(randomMessage bool) {
 		"4 - Never gonna tell a lie and hurt you",
 	}
 
-	opts := mqtt.NewClientOptions()
-	opts.AddBroker(broker)
-	opts.SetClientID(clientID)
-	opts.OnConnect = connectHandler
-	opts.OnConnectionLost = connectLostHandler
-
-	client := mqtt.NewClient(opts)
-	if token := client.Connect(); token.Wait() && token.Error() != nil {
-		panic(token.Error())
+	client := NewPublisher(NewClientOptions(broker, clientID), 30*time.Second)
+	if err := client.Connect(); err != nil {
+		panic(err)
 	}
 
 	for {
@@ -64,9 +124,11 @@ func RickAndRoll(randomMessage bool) {
 			}
 		}
 
-		token := client.Publish(topic, 0, false, message)
-		token.Wait()
-		fmt.Printf("Published message: %s\n", message)
+		if err := client.Publish(topic, 0, false, message); err != nil {
+			fmt.Println(err)
+		} else {
+			fmt.Printf("Published message: %s\n", message)
+		}
 		time.Sleep(100 * time.Millisecond)
 
 	}
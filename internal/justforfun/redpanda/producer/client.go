@@ -0,0 +1,42 @@
+package producer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Config is the cluster and batching of a producer client, zero values keep the client defaults
+type Config struct {
+	Brokers []string
+	Topic   string // Topic of the records produced without one
+
+	Linger          time.Duration // Wait for more records before sending a batch
+	MaxBatchBytes   int32
+	DeliveryTimeout time.Duration // Give up on a record not acknowledged in time (at least 1s)
+}
+
+// NewClient creates a producer client for config, opts are added to the options of the config.
+// The connection to the brokers is opened on the first request.
+func NewClient(config Config, opts ...kgo.Opt) (*kgo.Client, error) {
+	clientOpts := []kgo.Opt{kgo.SeedBrokers(config.Brokers...)}
+	if config.Topic != "" {
+		clientOpts = append(clientOpts, kgo.DefaultProduceTopic(config.Topic))
+	}
+	if config.Linger > 0 {
+		clientOpts = append(clientOpts, kgo.ProducerLinger(config.Linger))
+	}
+	if config.MaxBatchBytes > 0 {
+		clientOpts = append(clientOpts, kgo.ProducerBatchMaxBytes(config.MaxBatchBytes))
+	}
+	if config.DeliveryTimeout > 0 {
+		clientOpts = append(clientOpts, kgo.RecordDeliveryTimeout(config.DeliveryTimeout))
+	}
+
+	client, err := kgo.NewClient(append(clientOpts, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating Kafka client: %v", err)
+	}
+	return client, nil
+}
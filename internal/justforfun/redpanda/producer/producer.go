@@ -45,7 +45,7 @@ func PlaygroundRedPandaProducer() {
 
 	seeds := []string{"localhost:19092"}
 
-	client, err := kgo.NewClient(kgo.SeedBrokers(seeds...))
+	client, err := NewClient(Config{Brokers: seeds})
 	if err != nil {
 		panic(err)
 	}
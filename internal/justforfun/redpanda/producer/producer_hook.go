@@ -2,24 +2,15 @@ package producer
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"go-playground/pkg/thelogger"
 	"net"
-	_ "net/http/pprof"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/twmb/franz-go/pkg/kgo"
 )
 
-var (
-	seedBrokers = flag.String("brokers", "localhost:19092", "comma delimited list of seed brokers")
-	topic       = flag.String("topic", "foobar", "topic to consume for metric incrementing")
-	produce     = flag.Bool("produce", true, "if true, rather than consume, produce to the topic once per second (value \"foobar\")")
-)
-
 // BrokerHooks implements BrokerConnectHook and other hooks of franz-go module
 type BrokerHooks struct {
 	logger *thelogger.TheLogger
@@ -69,7 +60,9 @@ func (h *BrokerHooks) OnBrokerWrite(meta kgo.BrokerMetadata, key int16, bytesWri
 	}
 }
 
-func PlaygroundRedPandaProducerHook() {
+// PlaygroundRedPandaProducerHook produces "foobar" to topic every 5 seconds, or consumes the topic when produce
+// is false, logging the broker connections
+func PlaygroundRedPandaProducerHook(brokers []string, topic string, produce bool) {
 	logger := thelogger.NewTheLogger()
 	logger.Info("Starting RedPanda example with hooks and async producer")
 
@@ -78,26 +71,24 @@ func PlaygroundRedPandaProducerHook() {
 	}
 
 	opts := []kgo.Opt{
-		kgo.SeedBrokers(strings.Split(*seedBrokers, ",")...),
 		kgo.WithHooks(hooks),
-		kgo.DefaultProduceTopic(*topic),
 		kgo.AllowAutoTopicCreation(),
 		// Client logger (kgo.BasicLogger) is commented out; hooks use their own custom logger.
 		//kgo.WithLogger(kgo.BasicLogger(os.Stderr, kgo.LogLevelInfo, func() string {
 		//	return time.Now().Format("[2006-01-02 15:04:05.999] ")
 		//})),
 	}
-	if !*produce {
-		opts = append(opts, kgo.ConsumeTopics(*topic))
+	if !produce {
+		opts = append(opts, kgo.ConsumeTopics(topic))
 	}
 
-	cl, err := kgo.NewClient(opts...)
+	cl, err := NewClient(Config{Brokers: brokers, Topic: topic}, opts...)
 	if err != nil {
 		panic(fmt.Sprintf("unable to create client: %v", err))
 	}
 	defer cl.Close()
 
-	if *produce {
+	if produce {
 		for range time.Tick(5 * time.Second) {
 			if err := cl.ProduceSync(context.Background(), kgo.StringRecord("foobar")).FirstErr(); err != nil {
 				panic(fmt.Sprintf("unable to produce: %v", err))
@@ -0,0 +1,319 @@
+package vehiclesim
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"go-playground/internal/justforfun/vehiclesim/obd"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultStreamRate = 10.0 // Snapshots per second of the telemetry stream
+	maxStreamRate     = 50.0
+	maxStepsPerCall   = 10000
+)
+
+// ControlRequest moves the driver controls, omitted fields are left as they are
+type ControlRequest struct {
+	Throttle *float64 `json:"throttle"` // 0.0 to 1.0
+	Brake    *float64 `json:"brake"`    // 0.0 to 1.0
+	Clutch   *float64 `json:"clutch"`   // 0.0 = pressed, 1.0 = released
+	Gear     *int     `json:"gear"`     // 0 = neutral
+	Steering *float64 `json:"steering"` // Steering wheel degrees, positive turns left
+}
+
+// commands validates the request against a gearbox of maxGear gears and converts it to driver commands
+func (r ControlRequest) commands(maxGear int) ([]Command, error) {
+	var commands []Command
+	pedal := func(input string, value *float64) error {
+		if value == nil {
+			return nil
+		}
+		if *value < 0 || *value > 1 {
+			return fmt.Errorf("%s must be between 0 and 1, got %g", input, *value)
+		}
+		commands = append(commands, Command{Input: input, Value: *value})
+		return nil
+	}
+
+	if err := pedal(InputThrottle, r.Throttle); err != nil {
+		return nil, err
+	}
+	if err := pedal(InputBrake, r.Brake); err != nil {
+		return nil, err
+	}
+	if err := pedal(InputClutch, r.Clutch); err != nil {
+		return nil, err
+	}
+	if r.Gear != nil {
+		if *r.Gear < 0 || *r.Gear > maxGear {
+			return nil, fmt.Errorf("gear must be between 0 and %d, got %d", maxGear, *r.Gear)
+		}
+		commands = append(commands, Command{Input: InputGear, Value: float64(*r.Gear)})
+	}
+	if r.Steering != nil {
+		commands = append(commands, Command{Input: InputSteering, Value: *r.Steering})
+	}
+
+	if len(commands) == 0 {
+		return nil, fmt.Errorf("no control given, expected throttle, brake, clutch, gear or steering")
+	}
+	return commands, nil
+}
+
+// ControlServer exposes a live simulation over HTTP
+//
+//	GET  /api/status            pace, simulated time, driving mode and refused controls
+//	GET  /api/telemetry         snapshot of the last step
+//	GET  /api/stream?rate=10    WebSocket streaming a snapshot per message, up to 50 per second
+//	POST /api/controls          ControlRequest, takes the car from the autopilot
+//	POST /api/autopilot         hands the car back to the autopilot
+//	POST /api/pause             stops stepping
+//	POST /api/resume            steps again on the wall clock
+//	POST /api/step?n=1          steps a paused simulation, returns the last snapshot
+//	POST /api/timescale         {"time_scale": 2} simulated seconds per real second
+//	GET  /api/faults            trouble codes stored in the ECU
+//	POST /api/faults            {"codes": ["P0301"]} injects trouble codes
+//	DELETE /api/faults          clears the trouble codes, like OBD-II mode 04
+type ControlServer struct {
+	live     *LiveSimulation
+	controls *ControlledDriver
+	faults   *obd.Faults
+	maxGear  int // Highest gear of the simulated vehicle
+	upgrader websocket.Upgrader
+}
+
+// NewControlServer serves the given simulation, controls must be the driver the simulation steps with
+// or the one wrapped by it. faults are the trouble codes reported over OBD-II.
+func NewControlServer(live *LiveSimulation, controls *ControlledDriver, faults *obd.Faults) *ControlServer {
+	return &ControlServer{
+		live:     live,
+		controls: controls,
+		faults:   faults,
+		maxGear:  live.vehicle.Gearbox.GetMaxGear(),
+		upgrader: websocket.Upgrader{
+			// Dashboards are served from other origins
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// Handler returns the routes of the API
+func (c *ControlServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/status", c.handleStatus)
+	mux.HandleFunc("GET /api/telemetry", c.handleTelemetry)
+	mux.HandleFunc("GET /api/stream", c.handleStream)
+	mux.HandleFunc("POST /api/controls", c.handleControls)
+	mux.HandleFunc("POST /api/autopilot", c.handleAutopilot)
+	mux.HandleFunc("POST /api/pause", c.handlePause)
+	mux.HandleFunc("POST /api/resume", c.handleResume)
+	mux.HandleFunc("POST /api/step", c.handleStep)
+	mux.HandleFunc("POST /api/timescale", c.handleTimeScale)
+	mux.HandleFunc("GET /api/faults", c.handleFaults)
+	mux.HandleFunc("POST /api/faults", c.handleInjectFaults)
+	mux.HandleFunc("DELETE /api/faults", c.handleClearFaults)
+	return mux
+}
+
+// controlStatus is the body of /api/status
+type controlStatus struct {
+	LiveStatus
+	Manual   bool   `json:"manual"`
+	Rejected string `json:"rejected,omitempty"` // Why the vehicle refused the last controls
+}
+
+func (c *ControlServer) status() controlStatus {
+	status := controlStatus{LiveStatus: c.live.Status(), Manual: c.controls.IsManual()}
+	if err := c.controls.Rejected(); err != nil {
+		status.Rejected = err.Error()
+	}
+	return status
+}
+
+func (c *ControlServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, c.status())
+}
+
+func (c *ControlServer) handleTelemetry(w http.ResponseWriter, r *http.Request) {
+	snapshot, ok := c.live.Latest()
+	if !ok {
+		http.Error(w, "no step simulated yet", http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+func (c *ControlServer) handleControls(w http.ResponseWriter, r *http.Request) {
+	var request ControlRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("invalid controls: %v", err), http.StatusBadRequest)
+		return
+	}
+	commands, err := request.commands(c.maxGear)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.controls.Command(commands...)
+	writeJSON(w, http.StatusAccepted, c.status())
+}
+
+func (c *ControlServer) handleAutopilot(w http.ResponseWriter, r *http.Request) {
+	c.controls.SetManual(false)
+	writeJSON(w, http.StatusOK, c.status())
+}
+
+func (c *ControlServer) handlePause(w http.ResponseWriter, r *http.Request) {
+	c.live.Pause()
+	writeJSON(w, http.StatusOK, c.status())
+}
+
+func (c *ControlServer) handleResume(w http.ResponseWriter, r *http.Request) {
+	c.live.Resume()
+	writeJSON(w, http.StatusOK, c.status())
+}
+
+func (c *ControlServer) handleStep(w http.ResponseWriter, r *http.Request) {
+	n := 1
+	if value := r.URL.Query().Get("n"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > maxStepsPerCall {
+			http.Error(w, fmt.Sprintf("n must be between 1 and %d", maxStepsPerCall), http.StatusBadRequest)
+			return
+		}
+		n = parsed
+	}
+
+	snapshot, err := c.live.Step(n)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+func (c *ControlServer) handleTimeScale(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		TimeScale float64 `json:"time_scale"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("invalid time scale: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := c.live.SetTimeScale(request.TimeScale); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, c.status())
+}
+
+// faultList is the body of /api/faults
+type faultList struct {
+	Codes []string `json:"codes"`
+}
+
+func (c *ControlServer) handleFaults(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, faultList{Codes: c.faults.Strings()})
+}
+
+func (c *ControlServer) handleInjectFaults(w http.ResponseWriter, r *http.Request) {
+	var request faultList
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("invalid faults: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(request.Codes) == 0 {
+		http.Error(w, "no trouble code given", http.StatusBadRequest)
+		return
+	}
+	codes := make([]obd.DTC, len(request.Codes))
+	for i, text := range request.Codes {
+		code, err := obd.ParseDTC(text)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		codes[i] = code
+	}
+
+	c.faults.Inject(codes...)
+	writeJSON(w, http.StatusOK, faultList{Codes: c.faults.Strings()})
+}
+
+func (c *ControlServer) handleClearFaults(w http.ResponseWriter, r *http.Request) {
+	c.faults.Clear()
+	writeJSON(w, http.StatusOK, faultList{Codes: c.faults.Strings()})
+}
+
+// handleStream sends the latest snapshot at the requested rate, skipping the ticks without a new step
+func (c *ControlServer) handleStream(w http.ResponseWriter, r *http.Request) {
+	rate := defaultStreamRate
+	if value := r.URL.Query().Get("rate"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 || parsed > maxStreamRate {
+			http.Error(w, fmt.Sprintf("rate must be above 0 and up to %.0f", maxStreamRate), http.StatusBadRequest)
+			return
+		}
+		rate = parsed
+	}
+
+	conn, err := c.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader already answered the client
+		return
+	}
+	defer conn.Close()
+
+	// Reading is needed to process the close and ping messages of the client
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+	defer ticker.Stop()
+
+	var lastSent time.Time
+	for {
+		select {
+		case <-closed:
+			return
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+
+		snapshot, ok := c.live.Latest()
+		if !ok || snapshot.Time.Equal(lastSent) {
+			continue
+		}
+		if err := conn.WriteJSON(snapshot); err != nil {
+			return
+		}
+		lastSent = snapshot.Time
+	}
+}
+
+// writeJSON answers with the value encoded as JSON
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error encoding response: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
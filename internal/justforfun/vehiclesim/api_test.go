@@ -0,0 +1,147 @@
+package vehiclesim
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"go-playground/internal/justforfun/vehiclesim/obd"
+	"go-playground/internal/justforfun/vehiclesim/route"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestControlServer takes the car from the autopilot while paused, steps it by hand and streams it once resumed
+func TestControlServer(t *testing.T) {
+	vehicle, err := NewVehicle("vehicle-001", DefaultVehicleSpec(), 7, route.Default(), defaultStart, 0, time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("NewVehicle: %v", err)
+	}
+	controls := NewControlledDriver(NewScriptedDriver(NormalProfile()))
+	live := NewLiveSimulation(vehicle, controls, 0.1, nil)
+	server := httptest.NewServer(NewControlServer(live, controls, obd.NewFaults()).Handler())
+	defer server.Close()
+
+	post := func(path, body string) *http.Response {
+		t.Helper()
+		response, err := http.Post(server.URL+path, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("POST %s: %v", path, err)
+		}
+		t.Cleanup(func() { response.Body.Close() })
+		return response
+	}
+
+	if response, err := http.Get(server.URL + "/api/telemetry"); err != nil || response.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("telemetry before the first step: %v %v", response, err)
+	}
+	if response := post("/api/step", ""); response.StatusCode != http.StatusConflict {
+		t.Errorf("step while running: got status %d, want %d", response.StatusCode, http.StatusConflict)
+	}
+
+	post("/api/pause", "")
+	if response := post("/api/controls", `{"throttle": 1.5}`); response.StatusCode != http.StatusBadRequest {
+		t.Errorf("throttle out of range: got status %d, want %d", response.StatusCode, http.StatusBadRequest)
+	}
+	if response := post("/api/controls", `{"throttle": 0.3, "gear": 0}`); response.StatusCode != http.StatusAccepted {
+		t.Fatalf("controls: got status %d, want %d", response.StatusCode, http.StatusAccepted)
+	}
+
+	response := post("/api/step?n=20", "")
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("step: got status %d", response.StatusCode)
+	}
+	var snapshot Snapshot
+	if err := json.NewDecoder(response.Body).Decode(&snapshot); err != nil {
+		t.Fatalf("decoding step snapshot: %v", err)
+	}
+	if snapshot.Engine.AcceleratorPosition != 0.3 || snapshot.Gearbox.CurrentGear != 0 {
+		t.Errorf("manual controls not applied: %s%s", snapshot.Engine.String(), snapshot.Gearbox.String())
+	}
+
+	var status controlStatus
+	response, err = http.Get(server.URL + "/api/status")
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	defer response.Body.Close()
+	if err := json.NewDecoder(response.Body).Decode(&status); err != nil {
+		t.Fatalf("decoding status: %v", err)
+	}
+	if !status.Paused || !status.Manual || status.Steps != 20 || status.ElapsedS < 1.99 {
+		t.Errorf("unexpected status after 20 manual steps: %+v", status)
+	}
+
+	post("/api/autopilot", "")
+	if controls.IsManual() {
+		t.Error("autopilot not handed back the car")
+	}
+	if response := post("/api/timescale", `{"time_scale": 50}`); response.StatusCode != http.StatusOK {
+		t.Fatalf("time scale: got status %d", response.StatusCode)
+	}
+	post("/api/resume", "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		live.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/stream?rate=20", nil)
+	if err != nil {
+		t.Fatalf("dialing the stream: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	var streamed []Snapshot
+	for len(streamed) < 2 {
+		var message Snapshot
+		if err := conn.ReadJSON(&message); err != nil {
+			t.Fatalf("reading the stream: %v", err)
+		}
+		streamed = append(streamed, message)
+	}
+	if !streamed[1].Time.After(streamed[0].Time) || !streamed[0].Time.After(snapshot.Time) {
+		t.Errorf("stream not moving forward: %v, %v after %v", streamed[0].Time, streamed[1].Time, snapshot.Time)
+	}
+}
+
+// TestControlServerRejected checks the gears come from the gearbox and a command the vehicle refuses
+// shows up in the status instead of being printed
+func TestControlServerRejected(t *testing.T) {
+	vehicle, err := NewVehicle("vehicle-001", DefaultVehicleSpec(), 7, route.Default(), defaultStart, 0, time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("NewVehicle: %v", err)
+	}
+	controls := NewControlledDriver(NewScriptedDriver(NormalProfile()))
+	live := NewLiveSimulation(vehicle, controls, 0.1, nil)
+	server := NewControlServer(live, controls, obd.NewFaults())
+
+	maxGear := vehicle.Gearbox.GetMaxGear()
+	if _, err := (ControlRequest{Gear: &maxGear}).commands(server.maxGear); err != nil {
+		t.Errorf("gear %d: %v", maxGear, err)
+	}
+	beyond := maxGear + 1
+	if _, err := (ControlRequest{Gear: &beyond}).commands(server.maxGear); err == nil {
+		t.Errorf("gear %d of a %d gear gearbox: expected an error", beyond, maxGear)
+	}
+
+	controls.Command(Command{Input: InputGear, Value: float64(beyond)})
+	controls.Drive(vehicle, 0.1)
+	if status := server.status(); !strings.Contains(status.Rejected, "no gear 8") {
+		t.Errorf("status after an impossible gear: rejected %q, want the reason", status.Rejected)
+	}
+	controls.Command(Command{Input: InputGear, Value: 2})
+	controls.Drive(vehicle, 0.1)
+	if status := server.status(); status.Rejected != "" {
+		t.Errorf("status after a valid gear: rejected %q, want none", status.Rejected)
+	}
+}
@@ -0,0 +1,383 @@
+package vehiclesim
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"go-playground/internal/justforfun/vehiclesim/gps"
+	"go-playground/internal/justforfun/vehiclesim/route"
+	"go-playground/internal/justforfun/vehiclesim/units"
+	"go-playground/pkg/datetimeutils"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	batchStepSize = 0.1   // Simulated seconds per step of every batch run
+	batchDuration = 120.0 // Simulated seconds of a run when the sweep does not set it
+	launchSpeedMS = 0.5   // Ground speed that starts the 0-100 km/h clock
+)
+
+// SweepRange is an inclusive range of values
+type SweepRange struct {
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+	Step float64 `json:"step"`
+}
+
+// values expands the range, a zero step or max gives only Min
+func (r SweepRange) values() []float64 {
+	if r.Step <= 0 || r.Max <= r.Min {
+		return []float64{r.Min}
+	}
+	var values []float64
+	// Half a step of tolerance so the float error does not drop Max
+	for i := 0; r.Min+float64(i)*r.Step <= r.Max+r.Step/2; i++ {
+		values = append(values, math.Round((r.Min+float64(i)*r.Step)*1000)/1000)
+	}
+	return values
+}
+
+// SweepDefinition is the parameter grid of a batch run, every combination is simulated Runs times
+type SweepDefinition struct {
+	Name         string     `json:"name"`
+	Profile      string     `json:"profile"`    // Driver profile: normal, calm or sporty
+	DurationS    float64    `json:"duration_s"` // Simulated seconds of each run (0 = 120)
+	Runs         int        `json:"runs"`       // Seeds per combination (0 = 1)
+	BaseSeed     int64      `json:"base_seed"`  // Run i of a combination uses BaseSeed+i
+	FinalDrive   SweepRange `json:"final_drive"`
+	TireSpecs    []string   `json:"tire_specs"`
+	UpshiftRPM   []float64  `json:"upshift_rpm"`   // Empty keeps the profile value
+	DownshiftRPM []float64  `json:"downshift_rpm"` // Empty keeps the profile value
+}
+
+// DefaultSweep compares final drives between 3.5 and 4.5 on the default car
+func DefaultSweep() SweepDefinition {
+	return SweepDefinition{
+		Name:       "final-drive",
+		Profile:    "normal",
+		DurationS:  batchDuration,
+		Runs:       1,
+		BaseSeed:   1,
+		FinalDrive: SweepRange{Min: 3.5, Max: 4.5, Step: 0.1},
+		TireSpecs:  []string{DefaultVehicleSpec().TireSpec},
+	}
+}
+
+// LoadSweep reads a sweep definition from a JSON file
+func LoadSweep(path string) (SweepDefinition, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return SweepDefinition{}, fmt.Errorf("error opening sweep file: %v", err)
+	}
+	defer file.Close()
+
+	var sweep SweepDefinition
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&sweep); err != nil {
+		return SweepDefinition{}, fmt.Errorf("error parsing sweep file %s: %v", path, err)
+	}
+	return sweep, nil
+}
+
+// BatchCase is one combination of the sweep with its seed
+type BatchCase struct {
+	Index        int     `json:"index"`
+	FinalDrive   float64 `json:"final_drive"`
+	TireSpec     string  `json:"tire_spec"`
+	UpshiftRPM   float64 `json:"upshift_rpm"`
+	DownshiftRPM float64 `json:"downshift_rpm"`
+	Seed         int64   `json:"seed"`
+}
+
+// BatchResult summarizes one headless run
+type BatchResult struct {
+	BatchCase
+	ZeroTo100S  float64 `json:"zero_to_100_s"` // 0 when 100 km/h was not reached
+	TopSpeedKMH float64 `json:"top_speed_kmh"`
+	FuelUsedL   float64 `json:"fuel_used_l"`
+	Shifts      int     `json:"shifts"`
+	DistanceM   float64 `json:"distance_m"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// String implements the String interface for human-readable formatting
+func (r BatchResult) String() string {
+	return fmt.Sprintf("Batch [Case: %d, FinalDrive: %.2f, Tire: %s, Upshift: %.0f, 0-100: %.1f s, TopSpeed: %.1f KMH, Fuel: %.2f L, Shifts: %d]\n",
+		r.Index,
+		r.FinalDrive,
+		r.TireSpec,
+		r.UpshiftRPM,
+		r.ZeroTo100S,
+		r.TopSpeedKMH,
+		r.FuelUsedL,
+		r.Shifts)
+}
+
+// profileByName returns the driver profile with the given name
+func profileByName(name string) (DriverProfile, error) {
+	for _, profile := range fleetProfiles {
+		if p := profile(); p.Name == name {
+			return p, nil
+		}
+	}
+	return DriverProfile{}, fmt.Errorf("unknown driver profile %q", name)
+}
+
+// cases expands the sweep into every combination of its parameters
+func (s SweepDefinition) cases(profile DriverProfile) []BatchCase {
+	tireSpecs := s.TireSpecs
+	if len(tireSpecs) == 0 {
+		tireSpecs = []string{DefaultVehicleSpec().TireSpec}
+	}
+	upshifts := s.UpshiftRPM
+	if len(upshifts) == 0 {
+		upshifts = []float64{profile.UpshiftRPM}
+	}
+	downshifts := s.DownshiftRPM
+	if len(downshifts) == 0 {
+		downshifts = []float64{profile.DownshiftRPM}
+	}
+	finalDrives := s.FinalDrive.values()
+	if s.FinalDrive.Min <= 0 {
+		finalDrives = []float64{DefaultVehicleSpec().DifferentialRatio}
+	}
+	runs := max(s.Runs, 1)
+
+	var cases []BatchCase
+	for _, finalDrive := range finalDrives {
+		for _, tireSpec := range tireSpecs {
+			for _, upshift := range upshifts {
+				for _, downshift := range downshifts {
+					for run := 0; run < runs; run++ {
+						cases = append(cases, BatchCase{
+							Index:        len(cases),
+							FinalDrive:   finalDrive,
+							TireSpec:     tireSpec,
+							UpshiftRPM:   upshift,
+							DownshiftRPM: downshift,
+							Seed:         s.BaseSeed + int64(run),
+						})
+					}
+				}
+			}
+		}
+	}
+	return cases
+}
+
+// RunBatch simulates every case of the sweep headless on a pool of workers
+// Results are returned in case order, a case that fails to build keeps its error in the result
+func RunBatch(sweep SweepDefinition, workers int) ([]BatchResult, error) {
+	if sweep.Profile == "" {
+		sweep.Profile = NormalProfile().Name
+	}
+	profile, err := profileByName(sweep.Profile)
+	if err != nil {
+		return nil, err
+	}
+	if sweep.DurationS <= 0 {
+		sweep.DurationS = batchDuration
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	theRoute, err := loadRoute()
+	if err != nil {
+		return nil, fmt.Errorf("error loading route: %v", err)
+	}
+	start, heading := loadStartPosition()
+
+	cases := sweep.cases(profile)
+	results := make([]BatchResult, len(cases))
+
+	var wg sync.WaitGroup
+	jobs := make(chan BatchCase)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batchCase := range jobs {
+				results[batchCase.Index] = runBatchCase(batchCase, profile, sweep.DurationS, theRoute, start, heading)
+			}
+		}()
+	}
+	for _, batchCase := range cases {
+		jobs <- batchCase
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, nil
+}
+
+// runBatchCase drives one vehicle from a standing start for durationS simulated seconds
+func runBatchCase(batchCase BatchCase, profile DriverProfile, durationS float64, theRoute *route.Route, start gps.Coordinate, heading float64) BatchResult {
+	result := BatchResult{BatchCase: batchCase}
+
+	spec := DefaultVehicleSpec()
+	spec.DifferentialRatio = batchCase.FinalDrive
+	spec.TireSpec = batchCase.TireSpec
+
+	vehicle, err := NewVehicle(fmt.Sprintf("batch-%03d", batchCase.Index), spec, batchCase.Seed, theRoute, start, heading, time.Time{})
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	profile.UpshiftRPM = batchCase.UpshiftRPM
+	profile.DownshiftRPM = batchCase.DownshiftRPM
+	driver := NewScriptedDriver(profile)
+
+	launchTime := -1.0
+	for step := 0; step < int(math.Round(durationS/batchStepSize)); step++ {
+		driver.Drive(vehicle, batchStepSize)
+		snapshot := vehicle.Step(batchStepSize)
+
+		speedMS := snapshot.Body.SpeedMS
+		if launchTime < 0 && speedMS > launchSpeedMS {
+			launchTime = vehicle.Elapsed()
+		}
+		speedKMH := units.MetersPerSecond.Of(speedMS).KilometersPerHour()
+		if result.ZeroTo100S == 0 && launchTime >= 0 && speedKMH >= 100 {
+			result.ZeroTo100S = vehicle.Elapsed() - launchTime
+		}
+		result.TopSpeedKMH = math.Max(result.TopSpeedKMH, speedKMH)
+		result.Shifts += len(snapshot.Shifts)
+		result.DistanceM = snapshot.Wheels.DistanceM
+	}
+	result.FuelUsedL = vehicle.Engine.GetFuelUsed()
+
+	return result
+}
+
+// batchCSVHeader are the columns of WriteBatchCSV
+var batchCSVHeader = []string{"index", "final_drive", "tire_spec", "upshift_rpm", "downshift_rpm", "seed",
+	"zero_to_100_s", "top_speed_kmh", "fuel_used_l", "shifts", "distance_m", "error"}
+
+// WriteBatchCSV writes one row per result, 0-100 is empty when 100 km/h was not reached
+func WriteBatchCSV(w io.Writer, results []BatchResult) error {
+	formatFloat := func(value float64, decimals int) string {
+		return strconv.FormatFloat(value, 'f', decimals, 64)
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(batchCSVHeader); err != nil {
+		return fmt.Errorf("error writing CSV header: %v", err)
+	}
+	for _, r := range results {
+		zeroTo100 := ""
+		if r.ZeroTo100S > 0 {
+			zeroTo100 = formatFloat(r.ZeroTo100S, 1)
+		}
+		row := []string{
+			strconv.Itoa(r.Index),
+			formatFloat(r.FinalDrive, 3),
+			r.TireSpec,
+			formatFloat(r.UpshiftRPM, 0),
+			formatFloat(r.DownshiftRPM, 0),
+			strconv.FormatInt(r.Seed, 10),
+			zeroTo100,
+			formatFloat(r.TopSpeedKMH, 1),
+			formatFloat(r.FuelUsedL, 3),
+			strconv.Itoa(r.Shifts),
+			formatFloat(r.DistanceM, 0),
+			r.Error,
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("error writing CSV row: %v", err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// batchReport is the JSON document of a batch run
+type batchReport struct {
+	Sweep   SweepDefinition `json:"sweep"`
+	Results []BatchResult   `json:"results"`
+}
+
+// WriteBatchJSON writes the sweep and its results as an indented JSON document
+func WriteBatchJSON(w io.Writer, sweep SweepDefinition, results []BatchResult) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(batchReport{Sweep: sweep, Results: results}); err != nil {
+		return fmt.Errorf("error encoding batch JSON: %v", err)
+	}
+	return nil
+}
+
+// SaveBatch writes the results to <dir>/<name>.csv and <dir>/<name>.json
+func SaveBatch(dir string, name string, sweep SweepDefinition, results []BatchResult) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("error creating output directory: %v", err)
+	}
+
+	exporters := map[string]func(io.Writer) error{
+		".csv":  func(w io.Writer) error { return WriteBatchCSV(w, results) },
+		".json": func(w io.Writer) error { return WriteBatchJSON(w, sweep, results) },
+	}
+
+	for extension, export := range exporters {
+		file, err := os.Create(filepath.Join(dir, name+extension))
+		if err != nil {
+			return fmt.Errorf("error creating %s file: %v", extension, err)
+		}
+
+		err = export(file)
+		closeErr := file.Close()
+		if err != nil {
+			return err
+		}
+		if closeErr != nil {
+			return closeErr
+		}
+	}
+	return nil
+}
+
+// BatchSimulation runs the sweep and saves the summary in outputDir
+func BatchSimulation(sweep SweepDefinition, workers int, outputDir string) {
+	if sweep.Name == "" {
+		sweep.Name = "batch"
+	}
+	fmt.Printf("Starting batch %s\n", sweep.Name)
+	started := time.Now()
+
+	results, err := RunBatch(sweep, workers)
+	if err != nil {
+		panic(fmt.Sprintf("Error running batch: %v", err))
+	}
+
+	// Fastest cases first on screen, the files keep the case order
+	ranking := append([]BatchResult(nil), results...)
+	sort.SliceStable(ranking, func(i, j int) bool {
+		if (ranking[i].ZeroTo100S > 0) != (ranking[j].ZeroTo100S > 0) {
+			return ranking[i].ZeroTo100S > 0
+		}
+		return ranking[i].ZeroTo100S < ranking[j].ZeroTo100S
+	})
+	for _, result := range ranking {
+		fmt.Print(result.String())
+	}
+
+	timeStamp, err := datetimeutils.CreateFileTimeStamp(datetimeutils.Now())
+	if err != nil {
+		panic(fmt.Sprintf("Error creating batch timestamp: %v", err))
+	}
+	name := fmt.Sprintf("%s-%s", sweep.Name, timeStamp)
+	if err := SaveBatch(outputDir, name, sweep, results); err != nil {
+		panic(fmt.Sprintf("Error saving batch: %v", err))
+	}
+	fmt.Printf("%d runs in %s, summary saved in %s/%s.csv and .json\n", len(results), time.Since(started).Round(time.Millisecond), outputDir, name)
+}
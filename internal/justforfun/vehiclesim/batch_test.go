@@ -0,0 +1,68 @@
+package vehiclesim
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+)
+
+// TestRunBatch checks the sweep expands into every combination, the results come back in case order
+// and the fixed seeds give the same summary whatever the number of workers
+func TestRunBatch(t *testing.T) {
+	sweep := SweepDefinition{
+		Name:       "test",
+		DurationS:  60,
+		Runs:       2,
+		BaseSeed:   7,
+		FinalDrive: SweepRange{Min: 3.5, Max: 4.5, Step: 0.5},
+		TireSpecs:  []string{"245/40R19", "225/45R17"},
+	}
+
+	results, err := RunBatch(sweep, 4)
+	if err != nil {
+		t.Fatalf("RunBatch: %v", err)
+	}
+	if len(results) != 3*2*2 {
+		t.Fatalf("got %d results, want 12", len(results))
+	}
+	for i, result := range results {
+		if result.Index != i || result.Error != "" {
+			t.Errorf("result %d: index %d, error %q", i, result.Index, result.Error)
+		}
+		if result.TopSpeedKMH <= 0 || result.FuelUsedL <= 0 || result.Shifts == 0 {
+			t.Errorf("case %d did not drive: %s", i, result.String())
+		}
+	}
+
+	again, err := RunBatch(sweep, 1)
+	if err != nil {
+		t.Fatalf("RunBatch: %v", err)
+	}
+	for i := range results {
+		if again[i] != results[i] {
+			t.Errorf("case %d: got %s, want %s", i, again[i].String(), results[i].String())
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := WriteBatchCSV(&buffer, results); err != nil {
+		t.Fatalf("WriteBatchCSV: %v", err)
+	}
+	rows, err := csv.NewReader(&buffer).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV: %v", err)
+	}
+	if len(rows) != len(results)+1 || len(rows[0]) != len(batchCSVHeader) {
+		t.Fatalf("got %d rows of %d columns, want %d of %d", len(rows), len(rows[0]), len(results)+1, len(batchCSVHeader))
+	}
+}
+
+func TestSweepRangeValues(t *testing.T) {
+	got := SweepRange{Min: 3.5, Max: 4.5, Step: 0.1}.values()
+	if len(got) != 11 || got[0] != 3.5 || got[10] != 4.5 {
+		t.Errorf("got %v, want 11 values from 3.5 to 4.5", got)
+	}
+	if got := (SweepRange{Min: 3.84}).values(); len(got) != 1 || got[0] != 3.84 {
+		t.Errorf("got %v, want [3.84]", got)
+	}
+}
@@ -0,0 +1,114 @@
+package body
+
+import "math"
+
+const (
+	Gravity    = 9.81  // m/s²
+	AirDensity = 1.225 // kg/m³ at sea level
+)
+
+// Body is the longitudinal model of the vehicle body (chassis)
+// It integrates the ground speed from the tractive force delivered by the tires
+type Body struct {
+	massKg             float64
+	dragCoefficient    float64 // Cd
+	frontalAreaM2      float64
+	rollingResistance  float64 // Crr
+	drivenAxleFraction float64 // Fraction of the weight on the driven axle (rear)
+	tireRollingFactor  float64 // Rolling resistance of the tires relative to new tires at nominal pressure
+
+	grade          float64 // Road grade in radians (positive = uphill)
+	speedMS        float64 // Ground speed in m/s
+	accelerationMS float64 // Longitudinal acceleration in m/s²
+}
+
+// NewBody creates a body with typical values for a rear driven sedan
+func NewBody() *Body {
+	return &Body{
+		massKg:             1550,
+		dragCoefficient:    0.30,
+		frontalAreaM2:      2.2,
+		rollingResistance:  0.012,
+		drivenAxleFraction: 0.52,
+		tireRollingFactor:  1.0,
+	}
+}
+
+// SetGrade sets the road grade in radians (positive = uphill)
+func (b *Body) SetGrade(grade float64) {
+	b.grade = grade
+}
+
+// SetTireRollingFactor scales the rolling resistance with the pressure, temperature and wear of the tires
+func (b *Body) SetTireRollingFactor(factor float64) {
+	if factor > 0 {
+		b.tireRollingFactor = factor
+	}
+}
+
+// GetSpeed returns the ground speed in m/s
+func (b *Body) GetSpeed() float64 {
+	return b.speedMS
+}
+
+// GetMass returns the vehicle mass in kg
+func (b *Body) GetMass() float64 {
+	return b.massKg
+}
+
+// SetMass sets the vehicle mass in kg, including driver and load
+func (b *Body) SetMass(massKg float64) {
+	if massKg > 0 {
+		b.massKg = massKg
+	}
+}
+
+// DrivenWheelLoad returns the normal load in Newtons on each wheel of the driven axle
+func (b *Body) DrivenWheelLoad() float64 {
+	return b.massKg * Gravity * math.Cos(b.grade) * b.drivenAxleFraction / 2
+}
+
+// aerodynamicDrag returns the air resistance in Newtons
+func (b *Body) aerodynamicDrag() float64 {
+	return 0.5 * AirDensity * b.dragCoefficient * b.frontalAreaM2 * b.speedMS * b.speedMS
+}
+
+// rollingResistanceForce returns the rolling resistance in Newtons, only while moving
+func (b *Body) rollingResistanceForce() float64 {
+	if b.speedMS <= 0 {
+		return 0
+	}
+	return b.rollingResistance * b.tireRollingFactor * b.massKg * Gravity * math.Cos(b.grade)
+}
+
+// gradeForce returns the gravity component along the road in Newtons
+func (b *Body) gradeForce() float64 {
+	return b.massKg * Gravity * math.Sin(b.grade)
+}
+
+// Update integrates the ground speed
+// Parameters:
+//
+//	tractiveForce: longitudinal force in Newtons delivered by the tires (negative = braking)
+//	deltaTime: time elapsed in seconds
+func (b *Body) Update(tractiveForce float64, deltaTime float64) {
+	netForce := tractiveForce - b.aerodynamicDrag() - b.rollingResistanceForce() - b.gradeForce()
+	b.accelerationMS = netForce / b.massKg
+
+	b.speedMS += b.accelerationMS * deltaTime
+
+	// The car does not roll backwards, the driver holds it with the brake
+	if b.speedMS < 0 {
+		b.speedMS = 0
+		b.accelerationMS = 0
+	}
+}
+
+// GetData returns the body telemetry
+func (b *Body) GetData() Telemetry {
+	return Telemetry{
+		SpeedMS:        b.speedMS,
+		AccelerationMS: b.accelerationMS,
+		Grade:          b.grade,
+	}
+}
@@ -0,0 +1,46 @@
+package body
+
+import (
+	"math"
+	"testing"
+)
+
+// TestEnergyBalance pushes the body up and down hills and checks the work of the tractive force
+// ends up as kinetic and potential energy plus the drag and rolling losses
+func TestEnergyBalance(t *testing.T) {
+	for _, grade := range []float64{-0.05, 0, 0.05} {
+		b := NewBody()
+		b.SetGrade(grade)
+
+		const deltaTime, force = 0.01, 4000.0
+		var work, losses, climb float64
+		for i := 0; i < 3000; i++ {
+			speed := b.GetSpeed()
+			b.Update(force, deltaTime)
+			// The trapezoidal speed over the step matches the explicit integration of Update
+			average := (speed + b.GetSpeed()) / 2
+			work += force * average * deltaTime
+			losses += (b.aerodynamicDrag() + b.rollingResistanceForce()) * average * deltaTime
+			climb += average * deltaTime * math.Sin(grade)
+		}
+
+		kinetic := 0.5 * b.GetMass() * b.GetSpeed() * b.GetSpeed()
+		potential := b.GetMass() * Gravity * climb
+		if balance := work - losses - kinetic - potential; math.Abs(balance) > 0.001*work {
+			t.Errorf("grade %.2f: %.0f J of work, %.0f J lost, %.0f J kinetic and %.0f J potential, %.0f J unaccounted",
+				grade, work, losses, kinetic, potential, balance)
+		}
+	}
+}
+
+// TestBodyHoldsStill checks the body does not roll backwards when the force cannot hold it on a hill
+func TestBodyHoldsStill(t *testing.T) {
+	b := NewBody()
+	b.SetGrade(0.1)
+	for i := 0; i < 100; i++ {
+		b.Update(0, 0.1)
+		if b.GetSpeed() < 0 {
+			t.Fatalf("step %d: speed %.3f m/s, want the body held at 0", i, b.GetSpeed())
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package body
+
+// Checkpoint is the state of the body needed to resume a simulation
+// The mass and aerodynamics come from the vehicle spec
+type Checkpoint struct {
+	Grade          float64
+	SpeedMS        float64
+	AccelerationMS float64
+}
+
+// Checkpoint returns the current state of the body
+func (b *Body) Checkpoint() Checkpoint {
+	return Checkpoint{
+		Grade:          b.grade,
+		SpeedMS:        b.speedMS,
+		AccelerationMS: b.accelerationMS,
+	}
+}
+
+// Restore sets the body back to a saved state
+func (b *Body) Restore(c Checkpoint) {
+	b.grade = c.Grade
+	b.speedMS = c.SpeedMS
+	b.accelerationMS = c.AccelerationMS
+}
@@ -0,0 +1,25 @@
+package body
+
+import (
+	"fmt"
+	"go-playground/internal/justforfun/vehiclesim/units"
+	"math"
+)
+
+type Telemetry struct {
+	SpeedMS        float64 // Ground speed in m/s
+	AccelerationMS float64 // Longitudinal acceleration in m/s²
+	Grade          float64 // Road grade in radians
+}
+
+// String implements the String interface for human-readable formatting
+func (d Telemetry) String() string {
+	return fmt.Sprintf("Body [GroundSpeed: %.2f KMH, Acceleration: %.2f m/s², Grade: %.1f %%]\n",
+		units.MetersPerSecond.Of(d.SpeedMS).KilometersPerHour(),
+		d.AccelerationMS,
+		d.getGradePercent())
+}
+
+func (d Telemetry) getGradePercent() float64 {
+	return 100 * math.Tan(d.Grade)
+}
@@ -0,0 +1,121 @@
+package can
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultDBC(t *testing.T) {
+	db, err := LoadDBC("")
+	if err != nil {
+		t.Fatalf("LoadDBC: %v", err)
+	}
+	if len(db.Messages) != 5 {
+		t.Fatalf("got %d messages, want 5", len(db.Messages))
+	}
+	for _, message := range db.Messages {
+		for _, signal := range message.Signals {
+			if signal.Channel == "" {
+				t.Errorf("signal %s.%s has no SimChannel", message.Name, signal.Name)
+			}
+		}
+	}
+
+	engine, ok := db.Message("ENGINE_DATA")
+	if !ok || engine.ID != 0x100 || engine.Extended || engine.CycleTime != 100*time.Millisecond || len(engine.Signals) != 6 {
+		t.Errorf("ENGINE_DATA = %+v", engine)
+	}
+	tires, ok := db.Message("TIRE_STATUS")
+	if !ok || tires.ID != 0x18FEF433 || !tires.Extended || tires.CycleTime != time.Second {
+		t.Errorf("TIRE_STATUS = %+v", tires)
+	}
+	if fuel, ok := db.Message("ENGINE_FUEL"); !ok || fuel.Length != 4 {
+		t.Errorf("ENGINE_FUEL = %+v", fuel)
+	}
+}
+
+func TestSignalEncoding(t *testing.T) {
+	db, err := LoadDBC("")
+	if err != nil {
+		t.Fatalf("LoadDBC: %v", err)
+	}
+	engine, _ := db.Message("ENGINE_DATA")
+	wheelSpeeds, _ := db.Message("WHEEL_SPEEDS")
+	tires, _ := db.Message("TIRE_STATUS")
+
+	tests := []struct {
+		message *Message
+		values  map[string]float64
+		want    string
+	}{
+		// Intel: 1000 rpm is 4000 = 0x0FA0 from bit 0, -40 degC is the raw 0
+		{engine, map[string]float64{"EngineSpeed": 1000, "CoolantTemp": -40}, "100#A00F000000000000"},
+		// Signed and limited to the range of the signal: -0.5 is 0xCE, 2 is clamped to 1.27
+		{wheelSpeeds, map[string]float64{"SlipRL": -0.5, "SlipRR": 2}, "120#000000000000CE7F"},
+		// Motorola: the most significant byte first, from the start bit
+		{tires, map[string]float64{"TireTempRL": 60, "TirePressureRL": 240}, "18FEF433#6400096000000000"},
+	}
+	for _, tt := range tests {
+		frame := tt.message.Encode(tt.values)
+		if frame.String() != tt.want {
+			t.Errorf("%s.Encode(%v) = %s, want %s", tt.message.Name, tt.values, frame, tt.want)
+		}
+	}
+
+	values := map[string]float64{
+		"TireTempRL": 85, "TireTempRR": -12, "TirePressureRL": 251.3,
+		"TirePressureRR": 238.7, "TreadDepthRL": 7.45, "TreadDepthRR": 1.6,
+	}
+	decoded, err := tires.Decode(tires.Encode(values).Data)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	for name, want := range values {
+		if math.Abs(decoded[name]-want) > 1e-9 {
+			t.Errorf("%s decoded as %v, want %v", name, decoded[name], want)
+		}
+	}
+	if _, err := engine.Decode([]byte{1, 2, 3}); err == nil {
+		t.Error("Decode of a short frame: expected an error")
+	}
+}
+
+func TestParseDBCErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		dbc  string
+	}{
+		{"multiplexed", "BO_ 1 MUX: 8 ECU\n SG_ Mode M : 0|8@1+ (1,0) [0|0] \"\" Vector__XXX\n"},
+		{"out of the frame", "BO_ 1 SHORT: 2 ECU\n SG_ Speed : 8|16@1+ (1,0) [0|0] \"\" Vector__XXX\n"},
+		{"CAN FD", "BO_ 1 LONG: 64 ECU\n"},
+		{"unknown signal attribute", "BO_ 1 A: 8 ECU\n SG_ B : 0|8@1+ (1,0) [0|0] \"\" Vector__XXX\nBA_ \"SimChannel\" SG_ 1 C \"rpm\";\n"},
+		{"no message", "VERSION \"\"\n"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseDBC(strings.NewReader(tt.dbc)); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestLogWriter(t *testing.T) {
+	var output bytes.Buffer
+	log := NewLogWriter(&output, "vcan0")
+	if err := log.Write(time.Unix(1700000000, 100000000), Frame{ID: 0x100, Data: []byte{0xE0, 0x1A}}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := log.Write(time.Unix(1700000001, 0), Frame{ID: 0x18FEF433, Extended: true, Data: []byte{}}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	want := "(1700000000.100000) vcan0 100#E01A\n(1700000001.000000) vcan0 18FEF433#\n"
+	if output.String() != want {
+		t.Errorf("log = %q, want %q", output.String(), want)
+	}
+}
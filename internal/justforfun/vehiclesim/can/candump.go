@@ -0,0 +1,51 @@
+package can
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// LogWriter writes frames in the log format of candump -l, read back by canplayer:
+//
+//	(1700000000.100000) vcan0 100#E01A00000000DC82
+type LogWriter struct {
+	mu     sync.Mutex
+	output io.Writer
+	writer *bufio.Writer
+	iface  string
+}
+
+// NewLogWriter logs the frames as received on the named interface, e.g. "vcan0"
+func NewLogWriter(w io.Writer, iface string) *LogWriter {
+	return &LogWriter{output: w, writer: bufio.NewWriter(w), iface: iface}
+}
+
+// Write logs one frame with its timestamp
+func (l *LogWriter) Write(timestamp time.Time, frame Frame) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err := fmt.Fprintf(l.writer, "(%d.%06d) %s %s\n",
+		timestamp.Unix(), timestamp.Nanosecond()/1000, l.iface, frame)
+	return err
+}
+
+// Flush writes the buffered frames
+func (l *LogWriter) Flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.writer.Flush()
+}
+
+// Close writes the buffered frames and closes the output when it is a file or any other io.Closer
+func (l *LogWriter) Close() error {
+	if err := l.Flush(); err != nil {
+		return err
+	}
+	if closer, ok := l.output.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
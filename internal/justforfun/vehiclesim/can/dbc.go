@@ -0,0 +1,268 @@
+package can
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDBC describes the frames of the simulated vehicle, used when no DBC file is given
+//
+//go:embed default.dbc
+var DefaultDBC string
+
+const (
+	extendedIDFlag = 0x80000000 // Set on the message ID of a DBC for 29 bit identifiers
+	maxFrameLength = 8          // Data bytes of a classic CAN frame
+
+	// independentSignals is the pseudo message of the signals not sent in any frame
+	independentSignals = "VECTOR__INDEPENDENT_SIG_MSG"
+)
+
+var (
+	messagePattern = regexp.MustCompile(`^BO_\s+(\d+)\s+(\w+)\s*:\s*(\d+)\s+(\w+)`)
+	signalPattern  = regexp.MustCompile(`^SG_\s+(\w+)\s*(M|m\d+)?\s*:\s*(\d+)\|(\d+)@([01])([+-])\s*` +
+		`\(\s*([^,\s]+)\s*,\s*([^)\s]+)\s*\)\s*\[\s*([^|\s]+)\s*\|\s*([^\]\s]+)\s*\]\s*"([^"]*)"`)
+	messageAttributePattern = regexp.MustCompile(`^BA_\s+"(\w+)"\s+BO_\s+(\d+)\s+([^;]+);`)
+	signalAttributePattern  = regexp.MustCompile(`^BA_\s+"(\w+)"\s+SG_\s+(\d+)\s+(\w+)\s+([^;]+);`)
+)
+
+// Database holds the messages of a DBC file
+type Database struct {
+	Messages []*Message
+}
+
+// Message is one CAN frame of the database
+type Message struct {
+	ID        uint32
+	Extended  bool // 29 bit identifier
+	Name      string
+	Length    int // Data bytes
+	Sender    string
+	CycleTime time.Duration // GenMsgCycleTime attribute, 0 when the message is not periodic
+	Signals   []*Signal
+}
+
+// Signal is one value packed in a message
+type Signal struct {
+	Name      string
+	StartBit  int
+	Length    int  // Bits
+	BigEndian bool // Motorola byte order (@0), the start bit is the most significant bit
+	Signed    bool
+	Factor    float64
+	Offset    float64
+	Min       float64
+	Max       float64 // Equal to Min when the DBC sets no range
+	Unit      string
+	Channel   string // SimChannel attribute: the simulation value of the signal, empty to match by name
+}
+
+// LoadDBC reads a DBC file, or the bundled DefaultDBC when path is empty
+func LoadDBC(path string) (*Database, error) {
+	if path == "" {
+		return ParseDBC(strings.NewReader(DefaultDBC))
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening DBC file: %v", err)
+	}
+	defer file.Close()
+
+	db, err := ParseDBC(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading DBC file %s: %v", path, err)
+	}
+	return db, nil
+}
+
+// ParseDBC reads the messages, signals and their GenMsgCycleTime and SimChannel attributes.
+// Other sections (nodes, comments, value tables, ...) are skipped. Multiplexed signals and
+// CAN FD messages longer than 8 bytes are not supported.
+func ParseDBC(r io.Reader) (*Database, error) {
+	db := &Database{}
+	var message *Message
+	inComment := false
+	skipSignals := false
+
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		// Comments are quoted and may span several lines
+		quotes := strings.Count(line, `"`) - strings.Count(line, `\"`)
+		if inComment {
+			inComment = quotes%2 == 0
+			continue
+		}
+		if strings.HasPrefix(line, "CM_") {
+			inComment = quotes%2 == 1
+			continue
+		}
+
+		var err error
+		switch {
+		case strings.HasPrefix(line, "BO_ "):
+			message, err = parseMessage(line)
+			skipSignals = err == nil && message.Name == independentSignals
+			if err == nil && !skipSignals {
+				db.Messages = append(db.Messages, message)
+			}
+		case strings.HasPrefix(line, "SG_ "):
+			if skipSignals {
+				break
+			}
+			if message == nil {
+				err = fmt.Errorf("signal outside of a message")
+				break
+			}
+			var signal *Signal
+			if signal, err = parseSignal(line, message.Length); err == nil {
+				message.Signals = append(message.Signals, signal)
+			}
+		case strings.HasPrefix(line, "BA_ "):
+			err = db.parseAttribute(line)
+		case line != "":
+			message = nil
+			skipSignals = false
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(db.Messages) == 0 {
+		return nil, fmt.Errorf("no message defined")
+	}
+	return db, nil
+}
+
+func parseMessage(line string) (*Message, error) {
+	match := messagePattern.FindStringSubmatch(line)
+	if match == nil {
+		return nil, fmt.Errorf("invalid message %q", line)
+	}
+	id, err := strconv.ParseUint(match[1], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message ID %s: %v", match[1], err)
+	}
+	length, _ := strconv.Atoi(match[3])
+	if length > maxFrameLength && match[2] != independentSignals {
+		return nil, fmt.Errorf("message %s has %d bytes, CAN FD frames are not supported", match[2], length)
+	}
+
+	message := &Message{ID: uint32(id), Name: match[2], Length: length, Sender: match[4]}
+	if message.ID&extendedIDFlag != 0 {
+		message.ID &^= extendedIDFlag
+		message.Extended = true
+	}
+	return message, nil
+}
+
+func parseSignal(line string, messageLength int) (*Signal, error) {
+	match := signalPattern.FindStringSubmatch(line)
+	if match == nil {
+		return nil, fmt.Errorf("invalid signal %q", line)
+	}
+	if match[2] != "" {
+		return nil, fmt.Errorf("signal %s is multiplexed, multiplexing is not supported", match[1])
+	}
+
+	signal := &Signal{
+		Name:      match[1],
+		BigEndian: match[5] == "0",
+		Signed:    match[6] == "-",
+		Unit:      match[11],
+	}
+	signal.StartBit, _ = strconv.Atoi(match[3])
+	signal.Length, _ = strconv.Atoi(match[4])
+	for i, field := range []*float64{&signal.Factor, &signal.Offset, &signal.Min, &signal.Max} {
+		value, err := strconv.ParseFloat(match[7+i], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number in signal %s: %v", signal.Name, err)
+		}
+		*field = value
+	}
+
+	if signal.Length < 1 || signal.Length > 64 {
+		return nil, fmt.Errorf("signal %s has %d bits, expected 1 to 64", signal.Name, signal.Length)
+	}
+	if signal.Factor == 0 {
+		return nil, fmt.Errorf("signal %s has a zero factor", signal.Name)
+	}
+	for _, bit := range signal.bits() {
+		if bit < 0 || bit >= messageLength*8 {
+			return nil, fmt.Errorf("signal %s does not fit in %d bytes", signal.Name, messageLength)
+		}
+	}
+	return signal, nil
+}
+
+// parseAttribute reads the GenMsgCycleTime of messages and the SimChannel of signals,
+// attributes of messages not in the database are skipped
+func (db *Database) parseAttribute(line string) error {
+	if match := messageAttributePattern.FindStringSubmatch(line); match != nil && match[1] == "GenMsgCycleTime" {
+		message, err := db.messageByRawID(match[2])
+		if err != nil || message == nil {
+			return err
+		}
+		ms, err := strconv.ParseFloat(strings.TrimSpace(match[3]), 64)
+		if err != nil || ms < 0 {
+			return fmt.Errorf("invalid cycle time of %s: %q", message.Name, match[3])
+		}
+		message.CycleTime = time.Duration(ms * float64(time.Millisecond))
+	}
+
+	if match := signalAttributePattern.FindStringSubmatch(line); match != nil && match[1] == "SimChannel" {
+		message, err := db.messageByRawID(match[2])
+		if err != nil || message == nil {
+			return err
+		}
+		for _, signal := range message.Signals {
+			if signal.Name == match[3] {
+				signal.Channel = strings.Trim(strings.TrimSpace(match[4]), `"`)
+				return nil
+			}
+		}
+		return fmt.Errorf("message %s has no signal %s", message.Name, match[3])
+	}
+	return nil
+}
+
+// messageByRawID finds a message by its ID as written in the DBC, with the extended flag,
+// nil when there is none
+func (db *Database) messageByRawID(text string) (*Message, error) {
+	id, err := strconv.ParseUint(text, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message ID %s: %v", text, err)
+	}
+	for _, message := range db.Messages {
+		raw := message.ID
+		if message.Extended {
+			raw |= extendedIDFlag
+		}
+		if uint64(raw) == id {
+			return message, nil
+		}
+	}
+	return nil, nil
+}
+
+// Message finds a message by name
+func (db *Database) Message(name string) (*Message, bool) {
+	for _, message := range db.Messages {
+		if message.Name == name {
+			return message, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,123 @@
+package can
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Frame is a classic CAN data frame
+type Frame struct {
+	ID       uint32
+	Extended bool // 29 bit identifier
+	Data     []byte
+}
+
+// String formats the frame like candump and cansend: 123#DEADBEEF, with 8 hex digits for extended IDs
+func (f Frame) String() string {
+	id := fmt.Sprintf("%03X", f.ID)
+	if f.Extended {
+		id = fmt.Sprintf("%08X", f.ID)
+	}
+	return fmt.Sprintf("%s#%s", id, strings.ToUpper(fmt.Sprintf("%x", f.Data)))
+}
+
+// bits returns the positions in the frame of the signal bits, from the least significant one.
+// Positions count from bit 0 of byte 0 to bit 7 of the last byte.
+func (s *Signal) bits() []int {
+	bits := make([]int, s.Length)
+	if !s.BigEndian {
+		for i := range bits {
+			bits[i] = s.StartBit + i
+		}
+		return bits
+	}
+
+	// Motorola: the start bit is the most significant one, the following bits go down
+	// the byte and continue from bit 7 of the next byte
+	position := s.StartBit
+	for i := s.Length - 1; i >= 0; i-- {
+		bits[i] = position
+		if position%8 == 0 {
+			position += 15
+		} else {
+			position--
+		}
+	}
+	return bits
+}
+
+// rawRange returns the lowest and highest raw values of the signal
+func (s *Signal) rawRange() (float64, float64) {
+	if s.Signed {
+		return -math.Pow(2, float64(s.Length-1)), math.Pow(2, float64(s.Length-1)) - 1
+	}
+	return 0, math.Pow(2, float64(s.Length)) - 1
+}
+
+// Encode packs a physical value in the frame data, limited to the range of the signal
+func (s *Signal) Encode(data []byte, value float64) {
+	if s.Max > s.Min {
+		value = math.Max(s.Min, math.Min(s.Max, value))
+	}
+	low, high := s.rawRange()
+	raw := math.Max(low, math.Min(high, math.Round((value-s.Offset)/s.Factor)))
+
+	var bits uint64
+	if raw < 0 {
+		bits = uint64(int64(raw))
+	} else {
+		bits = uint64(raw)
+	}
+	for i, position := range s.bits() {
+		mask := byte(1) << (position % 8)
+		if bits>>i&1 == 1 {
+			data[position/8] |= mask
+		} else {
+			data[position/8] &^= mask
+		}
+	}
+}
+
+// Decode returns the physical value of the signal in the frame data
+func (s *Signal) Decode(data []byte) float64 {
+	var bits uint64
+	for i, position := range s.bits() {
+		if data[position/8]>>(position%8)&1 == 1 {
+			bits |= 1 << i
+		}
+	}
+	if s.Signed && s.Length < 64 && bits>>(s.Length-1)&1 == 1 {
+		bits |= ^uint64(0) << s.Length // Sign extension
+	}
+
+	raw := float64(bits)
+	if s.Signed {
+		raw = float64(int64(bits))
+	}
+	return raw*s.Factor + s.Offset
+}
+
+// Encode builds the frame of the message from the physical values of its signals,
+// signals without a value are sent as zeros
+func (m *Message) Encode(values map[string]float64) Frame {
+	data := make([]byte, m.Length)
+	for _, signal := range m.Signals {
+		if value, ok := values[signal.Name]; ok {
+			signal.Encode(data, value)
+		}
+	}
+	return Frame{ID: m.ID, Extended: m.Extended, Data: data}
+}
+
+// Decode returns the physical values of the signals in the frame data
+func (m *Message) Decode(data []byte) (map[string]float64, error) {
+	if len(data) < m.Length {
+		return nil, fmt.Errorf("frame of %s has %d bytes, expected %d", m.Name, len(data), m.Length)
+	}
+	values := make(map[string]float64, len(m.Signals))
+	for _, signal := range m.Signals {
+		values[signal.Name] = signal.Decode(data)
+	}
+	return values, nil
+}
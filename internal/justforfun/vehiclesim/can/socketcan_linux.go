@@ -0,0 +1,58 @@
+package can
+
+import (
+	"encoding/binary"
+	"fmt"
+	"golang.org/x/sys/unix"
+	"net"
+)
+
+// canFrameSize is the size of struct can_frame: ID, length, 3 reserved bytes and 8 data bytes
+const canFrameSize = 16
+
+// SocketCAN sends frames on a Linux CAN interface, e.g. "can0" or the virtual "vcan0"
+type SocketCAN struct {
+	fd int
+}
+
+// OpenSocketCAN binds a raw CAN socket to the interface
+func OpenSocketCAN(iface string) (*SocketCAN, error) {
+	netInterface, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, fmt.Errorf("error finding CAN interface %s: %v", iface, err)
+	}
+	fd, err := unix.Socket(unix.AF_CAN, unix.SOCK_RAW, unix.CAN_RAW)
+	if err != nil {
+		return nil, fmt.Errorf("error opening CAN socket: %v", err)
+	}
+	if err := unix.Bind(fd, &unix.SockaddrCAN{Ifindex: netInterface.Index}); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("error binding CAN socket to %s: %v", iface, err)
+	}
+	return &SocketCAN{fd: fd}, nil
+}
+
+// Send writes one frame to the bus
+func (s *SocketCAN) Send(frame Frame) error {
+	if len(frame.Data) > maxFrameLength {
+		return fmt.Errorf("frame %X has %d bytes, at most %d fit", frame.ID, len(frame.Data), maxFrameLength)
+	}
+	id := frame.ID
+	if frame.Extended {
+		id |= unix.CAN_EFF_FLAG
+	}
+
+	var buffer [canFrameSize]byte
+	binary.NativeEndian.PutUint32(buffer[0:4], id)
+	buffer[4] = byte(len(frame.Data))
+	copy(buffer[8:], frame.Data)
+	if _, err := unix.Write(s.fd, buffer[:]); err != nil {
+		return fmt.Errorf("error sending CAN frame %s: %v", frame, err)
+	}
+	return nil
+}
+
+// Close releases the socket
+func (s *SocketCAN) Close() error {
+	return unix.Close(s.fd)
+}
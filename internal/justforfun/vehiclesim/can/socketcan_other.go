@@ -0,0 +1,23 @@
+//go:build !linux
+
+package can
+
+import "fmt"
+
+// SocketCAN sends frames on a Linux CAN interface, not available on this system
+type SocketCAN struct{}
+
+// OpenSocketCAN fails, SocketCAN only exists on Linux
+func OpenSocketCAN(iface string) (*SocketCAN, error) {
+	return nil, fmt.Errorf("error opening CAN interface %s: SocketCAN is only available on Linux", iface)
+}
+
+// Send is never reached, OpenSocketCAN fails
+func (s *SocketCAN) Send(frame Frame) error {
+	return fmt.Errorf("SocketCAN is only available on Linux")
+}
+
+// Close does nothing
+func (s *SocketCAN) Close() error {
+	return nil
+}
@@ -0,0 +1,118 @@
+package vehiclesim
+
+import (
+	"errors"
+	"fmt"
+	"go-playground/internal/justforfun/vehiclesim/can"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// canCycleTolerance lets a periodic frame go out on the step closest to its cycle time
+const canCycleTolerance = time.Millisecond
+
+// canMessage is a message of the DBC with the channel of each of its signals
+type canMessage struct {
+	message  *can.Message
+	channels map[string]Channel // By signal name, signals without a channel are sent as zeros
+}
+
+// CANSink encodes the snapshots into the frames of a DBC, logs them in candump format
+// and sends them on a SocketCAN interface when given one
+type CANSink struct {
+	messages []canMessage
+	log      *can.LogWriter
+	bus      *can.SocketCAN
+
+	mu       sync.Mutex
+	nextSend map[string][]time.Time // Per vehicle, when each message is due again
+}
+
+// NewCANSink maps the signals of the database to the channels of the snapshot, by their
+// SimChannel attribute or else by name. It returns the signals left without a channel.
+// log and bus may be nil.
+func NewCANSink(db *can.Database, log *can.LogWriter, bus *can.SocketCAN) (*CANSink, []string, error) {
+	sink := &CANSink{log: log, bus: bus, nextSend: make(map[string][]time.Time)}
+
+	var unmapped []string
+	for _, message := range db.Messages {
+		mapped := canMessage{message: message, channels: make(map[string]Channel)}
+		for _, signal := range message.Signals {
+			if signal.Channel != "" {
+				channel, err := ChannelByName(signal.Channel)
+				if err != nil {
+					return nil, nil, fmt.Errorf("error mapping signal %s.%s: %v", message.Name, signal.Name, err)
+				}
+				mapped.channels[signal.Name] = channel
+			} else if channel, err := ChannelByName(strings.ToLower(signal.Name)); err == nil {
+				mapped.channels[signal.Name] = channel
+			} else {
+				unmapped = append(unmapped, message.Name+"."+signal.Name)
+			}
+		}
+		sink.messages = append(sink.messages, mapped)
+	}
+	sort.Strings(unmapped)
+	return sink, unmapped, nil
+}
+
+// Frames encodes the snapshot into one frame per message of the DBC
+func (s *CANSink) Frames(snapshot Snapshot) []can.Frame {
+	frames := make([]can.Frame, len(s.messages))
+	for i, mapped := range s.messages {
+		frames[i] = mapped.encode(snapshot)
+	}
+	return frames
+}
+
+func (m canMessage) encode(snapshot Snapshot) can.Frame {
+	values := make(map[string]float64, len(m.channels))
+	for signal, channel := range m.channels {
+		values[signal] = channel.Value(snapshot)
+	}
+	return m.message.Encode(values)
+}
+
+// Write logs and sends the frames due at the time of the snapshot: periodic messages
+// (GenMsgCycleTime) once per cycle, the others on every step
+func (s *CANSink) Write(snapshot Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	nextSend, ok := s.nextSend[snapshot.VehicleID]
+	if !ok {
+		nextSend = make([]time.Time, len(s.messages))
+		s.nextSend[snapshot.VehicleID] = nextSend
+	}
+
+	var errs []error
+	for i, mapped := range s.messages {
+		if snapshot.Time.Before(nextSend[i].Add(-canCycleTolerance)) {
+			continue
+		}
+		nextSend[i] = snapshot.Time.Add(mapped.message.CycleTime)
+
+		frame := mapped.encode(snapshot)
+		if s.log != nil {
+			errs = append(errs, s.log.Write(snapshot.Time, frame))
+		}
+		if s.bus != nil {
+			errs = append(errs, s.bus.Send(frame))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes the log and releases the interface
+func (s *CANSink) Close() error {
+	var errs []error
+	if s.log != nil {
+		errs = append(errs, s.log.Close())
+	}
+	if s.bus != nil {
+		errs = append(errs, s.bus.Close())
+	}
+	return errors.Join(errs...)
+}
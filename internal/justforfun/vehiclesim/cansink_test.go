@@ -0,0 +1,81 @@
+package vehiclesim
+
+import (
+	"bufio"
+	"bytes"
+	"go-playground/internal/justforfun/vehiclesim/can"
+	"go-playground/internal/justforfun/vehiclesim/route"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCANSink logs the frames of the bundled DBC at their cycle times and decodes them back to the snapshot
+func TestCANSink(t *testing.T) {
+	db, err := can.LoadDBC("")
+	if err != nil {
+		t.Fatalf("LoadDBC: %v", err)
+	}
+	var output bytes.Buffer
+	sink, unmapped, err := NewCANSink(db, can.NewLogWriter(&output, "vcan0"), nil)
+	if err != nil {
+		t.Fatalf("NewCANSink: %v", err)
+	}
+	if len(unmapped) > 0 {
+		t.Errorf("signals without a channel: %v", unmapped)
+	}
+
+	vehicle, err := NewVehicle("vehicle-001", DefaultVehicleSpec(), 7, route.Default(), defaultStart, 0, time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("NewVehicle: %v", err)
+	}
+	driver := NewScriptedDriver(NormalProfile())
+	var snapshot Snapshot
+	for i := 0; i < 300; i++ { // 30 s
+		driver.Drive(vehicle, 0.1)
+		snapshot = vehicle.Step(0.1)
+		if err := sink.Write(snapshot); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	counts := map[string]int{}
+	scanner := bufio.NewScanner(&output)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || fields[1] != "vcan0" {
+			t.Fatalf("invalid log line %q", scanner.Text())
+		}
+		counts[strings.Split(fields[2], "#")[0]]++
+	}
+	if counts["100"] != 300 || counts["120"] != 300 {
+		t.Errorf("got %d ENGINE_DATA and %d WHEEL_SPEEDS frames, want 300 at 100 ms", counts["100"], counts["120"])
+	}
+	if counts["18FEF433"] != 30 {
+		t.Errorf("got %d TIRE_STATUS frames, want 30 at 1 s", counts["18FEF433"])
+	}
+
+	for _, frame := range sink.Frames(snapshot) {
+		message := db.Messages[0]
+		for _, m := range db.Messages {
+			if m.ID == frame.ID {
+				message = m
+			}
+		}
+		values, err := message.Decode(frame.Data)
+		if err != nil {
+			t.Fatalf("Decode %s: %v", message.Name, err)
+		}
+		for _, signal := range message.Signals {
+			channel, _ := ChannelByName(signal.Channel)
+			want := math.Max(signal.Min, math.Min(signal.Max, channel.Value(snapshot)))
+			if math.Abs(values[signal.Name]-want) > signal.Factor/2+1e-9 {
+				t.Errorf("%s.%s = %v, want %v", message.Name, signal.Name, values[signal.Name], want)
+			}
+		}
+	}
+}
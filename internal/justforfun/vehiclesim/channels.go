@@ -0,0 +1,45 @@
+package vehiclesim
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Channel is one numeric value of the snapshot, named like its field of TelemetrySchema
+type Channel struct {
+	Name  string
+	Unit  string
+	Value func(s Snapshot) float64
+}
+
+// Channels lists the numeric channels of a snapshot: every field of TelemetrySchema but the strings,
+// booleans are 0 or 1
+var Channels = schemaChannels()
+
+func schemaChannels() []Channel {
+	var channels []Channel
+	for _, field := range TelemetrySchema {
+		if field.Type == StringField {
+			continue
+		}
+		channels = append(channels, Channel{Name: field.Channel, Unit: field.Unit, Value: field.Float})
+	}
+	return channels
+}
+
+// ChannelByName finds a channel of Channels
+func ChannelByName(name string) (Channel, error) {
+	for _, channel := range Channels {
+		if channel.Name == name {
+			return channel, nil
+		}
+	}
+
+	names := make([]string, len(Channels))
+	for i, channel := range Channels {
+		names[i] = channel.Name
+	}
+	sort.Strings(names)
+	return Channel{}, fmt.Errorf("unknown channel %q, expected one of %s", name, strings.Join(names, ", "))
+}
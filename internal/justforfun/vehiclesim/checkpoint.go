@@ -0,0 +1,137 @@
+package vehiclesim
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-playground/internal/justforfun/vehiclesim/body"
+	"go-playground/internal/justforfun/vehiclesim/cruise"
+	"go-playground/internal/justforfun/vehiclesim/differential"
+	"go-playground/internal/justforfun/vehiclesim/engine"
+	"go-playground/internal/justforfun/vehiclesim/gearbox"
+	"go-playground/internal/justforfun/vehiclesim/gps"
+	"go-playground/internal/justforfun/vehiclesim/route"
+	"go-playground/internal/justforfun/vehiclesim/steering"
+	"go-playground/internal/justforfun/vehiclesim/tcs"
+	"go-playground/internal/justforfun/vehiclesim/wheels"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// checkpointVersion is bumped whenever the checkpoint layout changes
+const checkpointVersion = 5
+
+// Checkpoint is the full state of a vehicle at the end of a step
+// The driver is not part of it, a resumed vehicle can be driven by any Driver
+type Checkpoint struct {
+	Version   int
+	VehicleID string
+	Spec      VehicleSpec
+	Route     *route.Route
+	StartTime time.Time
+	Elapsed   float64 // Simulated seconds since StartTime
+
+	BrakePedal       float64
+	ManualSteering   bool
+	Gear             int  // Gear of the last step, the next one compares it to spot a shift
+	TireRatingWarned bool // The speed rating warning has been logged, a resumed run does not log it again
+
+	Engine          engine.Checkpoint
+	Gearbox         gearbox.Checkpoint
+	Differential    differential.Checkpoint
+	Wheels          wheels.Checkpoint
+	Body            body.Checkpoint
+	Steering        steering.Checkpoint
+	TractionControl tcs.Checkpoint
+	CruiseControl   cruise.Checkpoint
+	Tracker         gps.Checkpoint
+	ShiftMonitor    gearbox.MonitorCheckpoint
+}
+
+// Checkpoint returns the state of every component of the vehicle, call it between steps
+func (v *Vehicle) Checkpoint() Checkpoint {
+	return Checkpoint{
+		Version:          checkpointVersion,
+		VehicleID:        v.ID,
+		Spec:             v.Spec,
+		Route:            v.Route,
+		StartTime:        v.startTime,
+		Elapsed:          v.elapsed,
+		BrakePedal:       v.brakePedal,
+		ManualSteering:   v.manualSteering,
+		Gear:             v.gear,
+		TireRatingWarned: v.tireRatingWarned,
+		Engine:           v.Engine.Checkpoint(),
+		Gearbox:          v.Gearbox.Checkpoint(),
+		Differential:     v.Differential.Checkpoint(),
+		Wheels:           v.Wheels.Checkpoint(),
+		Body:             v.Body.Checkpoint(),
+		Steering:         v.Steering.Checkpoint(),
+		TractionControl:  v.TractionControl.Checkpoint(),
+		CruiseControl:    v.CruiseControl.Checkpoint(),
+		Tracker:          v.Tracker.Checkpoint(),
+		ShiftMonitor:     v.ShiftMonitor.Checkpoint(),
+	}
+}
+
+// RestoreVehicle builds the vehicle of a checkpoint, its next step continues the saved run
+func RestoreVehicle(c Checkpoint) (*Vehicle, error) {
+	if c.Version != checkpointVersion {
+		return nil, fmt.Errorf("checkpoint version %d is not supported, expected %d", c.Version, checkpointVersion)
+	}
+	if c.Route == nil || len(c.Route.Points) < 2 {
+		return nil, fmt.Errorf("checkpoint of %s has no route", c.VehicleID)
+	}
+
+	v, err := NewVehicle(c.VehicleID, c.Spec, c.Engine.Seed, c.Route, defaultStart, 0, c.StartTime)
+	if err != nil {
+		return nil, err
+	}
+	v.elapsed = c.Elapsed
+	v.brakePedal = c.BrakePedal
+	v.manualSteering = c.ManualSteering
+	v.gear = c.Gear
+	v.tireRatingWarned = c.TireRatingWarned
+
+	v.Engine.Restore(c.Engine)
+	v.Gearbox.Restore(c.Gearbox)
+	v.Differential.Restore(c.Differential)
+	v.Wheels.Restore(c.Wheels)
+	v.Body.Restore(c.Body)
+	v.Steering.Restore(c.Steering)
+	v.TractionControl.Restore(c.TractionControl)
+	v.CruiseControl.Restore(c.CruiseControl)
+	v.Tracker.Restore(c.Tracker)
+	v.ShiftMonitor.Restore(c.ShiftMonitor)
+	return v, nil
+}
+
+// SaveCheckpoint writes the checkpoint as JSON, creating the directory if needed
+func SaveCheckpoint(path string, c Checkpoint) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating checkpoint directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding checkpoint: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("error writing checkpoint: %v", err)
+	}
+	return nil
+}
+
+// LoadCheckpoint reads a checkpoint written by SaveCheckpoint
+func LoadCheckpoint(path string) (Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("error reading checkpoint: %v", err)
+	}
+
+	var c Checkpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Checkpoint{}, fmt.Errorf("error parsing checkpoint %s: %v", path, err)
+	}
+	return c, nil
+}
@@ -0,0 +1,56 @@
+package vehiclesim
+
+import (
+	"go-playground/internal/justforfun/vehiclesim/route"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestCheckpointResume saves a vehicle mid-run to a file and checks the restored copy
+// continues exactly like the original, random engine events included
+func TestCheckpointResume(t *testing.T) {
+	original, err := NewVehicle("vehicle-001", DefaultVehicleSpec(), 42, route.Default(), defaultStart, 0, time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("NewVehicle: %v", err)
+	}
+	driver := NewScriptedDriver(SportyProfile())
+	for i := 0; i < 300; i++ {
+		driver.Drive(original, 0.1)
+		original.Step(0.1)
+	}
+
+	original.tireRatingWarned = true
+
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	if err := SaveCheckpoint(path, original.Checkpoint()); err != nil {
+		t.Fatalf("SaveCheckpoint: %v", err)
+	}
+	checkpoint, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint: %v", err)
+	}
+	resumed, err := RestoreVehicle(checkpoint)
+	if err != nil {
+		t.Fatalf("RestoreVehicle: %v", err)
+	}
+
+	if !resumed.tireRatingWarned {
+		t.Error("the resumed vehicle warns about the tire speed rating again")
+	}
+
+	// Both continue with the pedals where the driver left them
+	for i := 0; i < 300; i++ {
+		want := original.Step(0.1)
+		got := resumed.Step(0.1)
+		if got.Engine != want.Engine || got.Wheels != want.Wheels || got.GPS != want.GPS || !got.Time.Equal(want.Time) {
+			t.Fatalf("step %d after resume:\ngot  %s%s%s\nwant %s%s%s", i, got.Engine.String(), got.Wheels.String(), got.GPS.String(),
+				want.Engine.String(), want.Wheels.String(), want.GPS.String())
+		}
+	}
+
+	checkpoint.Version = 0
+	if _, err := RestoreVehicle(checkpoint); err == nil {
+		t.Error("expected an error restoring an unknown checkpoint version")
+	}
+}
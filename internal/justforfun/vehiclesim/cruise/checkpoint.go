@@ -0,0 +1,45 @@
+package cruise
+
+// Checkpoint is the state of the cruise control needed to resume a simulation
+type Checkpoint struct {
+	State       State
+	SetSpeedKMH float64
+	SpeedKMH    float64
+	ErrorKMH    float64
+	Integral    float64
+	Throttle    float64
+	BrakeTorque float64
+	Initialized bool
+}
+
+// Checkpoint returns the current state of the cruise control
+func (c *CruiseControl) Checkpoint() Checkpoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Checkpoint{
+		State:       c.state,
+		SetSpeedKMH: c.setSpeedKMH,
+		SpeedKMH:    c.speedKMH,
+		ErrorKMH:    c.errorKMH,
+		Integral:    c.integral,
+		Throttle:    c.throttle,
+		BrakeTorque: c.brakeTorque,
+		Initialized: c.initialized,
+	}
+}
+
+// Restore sets the cruise control back to a saved state
+func (c *CruiseControl) Restore(checkpoint Checkpoint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.state = checkpoint.State
+	c.setSpeedKMH = checkpoint.SetSpeedKMH
+	c.speedKMH = checkpoint.SpeedKMH
+	c.errorKMH = checkpoint.ErrorKMH
+	c.integral = checkpoint.Integral
+	c.throttle = checkpoint.Throttle
+	c.brakeTorque = checkpoint.BrakeTorque
+	c.initialized = checkpoint.Initialized
+}
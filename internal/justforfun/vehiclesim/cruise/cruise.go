@@ -0,0 +1,226 @@
+package cruise
+
+import (
+	"math"
+	"sync"
+)
+
+// State is the cruise control operating mode
+type State int
+
+const (
+	// Off means no set speed is memorized
+	Off State = iota
+	// Standby keeps the set speed memorized but does not control the car (after a cancel)
+	Standby
+	// Engaged controls throttle and brakes to hold the set speed
+	Engaged
+)
+
+func (s State) String() string {
+	switch s {
+	case Standby:
+		return "standby"
+	case Engaged:
+		return "engaged"
+	default:
+		return "off"
+	}
+}
+
+// CruiseControl holds the vehicle speed with a PID controller on the wheel speed
+// The driver model engages it from its own goroutine, so every method is safe for concurrent use
+type CruiseControl struct {
+	mu sync.Mutex
+
+	// PID gains, error in km/h
+	Kp float64
+	Ki float64
+	Kd float64
+
+	IntegralLimit  float64 // Anti-windup clamp of the integral term
+	BrakeDeadband  float64 // Negative output ignored before braking, avoids brake chatter
+	BrakeGain      float64 // Nm of brake per unit of negative output
+	MaxBrakeTorque float64 // Max brake torque per wheel in Nm
+	MinSetSpeedKMH float64 // Lowest speed the system can be set at
+
+	state       State
+	setSpeedKMH float64
+	speedKMH    float64
+	errorKMH    float64
+	integral    float64
+	throttle    float64
+	brakeTorque float64
+	initialized bool
+}
+
+// NewCruiseControl creates a cruise control switched off
+func NewCruiseControl() *CruiseControl {
+	return &CruiseControl{
+		Kp:             0.08,
+		Ki:             0.02,
+		Kd:             0.02,
+		IntegralLimit:  25,
+		BrakeDeadband:  0.05,
+		BrakeGain:      2000,
+		MaxBrakeTorque: 1500,
+		MinSetSpeedKMH: 30,
+		state:          Off,
+	}
+}
+
+// Set engages the cruise control at the current speed
+// Returns false if the car is slower than the minimum set speed
+func (c *CruiseControl) Set() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.engage(c.speedKMH)
+}
+
+// SetSpeed engages the cruise control at the given speed in km/h
+func (c *CruiseControl) SetSpeed(speedKMH float64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.engage(speedKMH)
+}
+
+// Resume engages again at the memorized set speed after a cancel
+func (c *CruiseControl) Resume() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state != Standby {
+		return false
+	}
+	return c.engage(c.setSpeedKMH)
+}
+
+// Cancel stops controlling the car but keeps the set speed for a later resume
+// This is what happens when the driver taps the brake or presses the clutch
+func (c *CruiseControl) Cancel() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state == Engaged {
+		c.state = Standby
+	}
+	c.release()
+}
+
+// SwitchOff disables the system and forgets the set speed
+func (c *CruiseControl) SwitchOff() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.state = Off
+	c.setSpeedKMH = 0
+	c.release()
+}
+
+func (c *CruiseControl) engage(speedKMH float64) bool {
+	if speedKMH < c.MinSetSpeedKMH {
+		return false
+	}
+
+	c.state = Engaged
+	c.setSpeedKMH = speedKMH
+	c.integral = 0
+	c.initialized = false
+	return true
+}
+
+func (c *CruiseControl) release() {
+	c.throttle = 0
+	c.brakeTorque = 0
+	c.integral = 0
+}
+
+// Update runs one step of the speed controller
+// Parameters:
+//
+//	speedKMH: vehicle speed from the wheels in km/h
+//	deltaTime: time elapsed in seconds
+func (c *CruiseControl) Update(speedKMH float64, deltaTime float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	previousSpeed := c.speedKMH
+	c.speedKMH = speedKMH
+
+	if c.state != Engaged {
+		c.errorKMH = 0
+		return
+	}
+
+	c.errorKMH = c.setSpeedKMH - speedKMH
+
+	// Derivative on measurement avoids a kick when the set speed changes
+	derivative := 0.0
+	if c.initialized && deltaTime > 0 {
+		derivative = -(speedKMH - previousSpeed) / deltaTime
+	}
+	c.initialized = true
+
+	output := c.Kp*c.errorKMH + c.Ki*c.integral + c.Kd*derivative
+
+	// Anti-windup: only integrate when the output is not saturated in the direction of the error
+	saturatedHigh := output >= 1 && c.errorKMH > 0
+	saturatedLow := c.brakeOutput(output) >= c.MaxBrakeTorque && c.errorKMH < 0
+	if !saturatedHigh && !saturatedLow {
+		c.integral += c.errorKMH * deltaTime
+		c.integral = math.Max(-c.IntegralLimit, math.Min(c.IntegralLimit, c.integral))
+	}
+
+	c.throttle = math.Max(0, math.Min(1, output))
+	c.brakeTorque = c.brakeOutput(output)
+}
+
+// brakeOutput converts the negative part of the controller output into brake torque
+func (c *CruiseControl) brakeOutput(output float64) float64 {
+	if output >= -c.BrakeDeadband {
+		return 0
+	}
+	return math.Min(c.MaxBrakeTorque, (-output-c.BrakeDeadband)*c.BrakeGain)
+}
+
+// GetThrottle returns the throttle demand for the engine (0.0 to 1.0)
+func (c *CruiseControl) GetThrottle() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.throttle
+}
+
+// GetBrakeTorque returns the brake torque demand per wheel in Nm
+func (c *CruiseControl) GetBrakeTorque() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.brakeTorque
+}
+
+// GetState returns the current operating mode
+func (c *CruiseControl) GetState() State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.state
+}
+
+// GetData returns the cruise control telemetry
+func (c *CruiseControl) GetData() Telemetry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Telemetry{
+		State:       c.state.String(),
+		SetSpeedKMH: c.setSpeedKMH,
+		SpeedKMH:    c.speedKMH,
+		ErrorKMH:    c.errorKMH,
+		Integral:    c.integral,
+		Throttle:    c.throttle,
+		BrakeTorque: c.brakeTorque,
+	}
+}
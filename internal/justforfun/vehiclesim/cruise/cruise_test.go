@@ -0,0 +1,117 @@
+package cruise
+
+import (
+	"math"
+	"testing"
+)
+
+// car is a point mass driven by the cruise control: 3 m/s² at full throttle, 1 m/s² per 1500 Nm of
+// brake torque, and a constant pull of the road in m/s² (a climb when positive)
+type car struct {
+	speedKMH float64
+	pull     float64
+}
+
+func (c *car) step(cc *CruiseControl, deltaTime float64) {
+	cc.Update(c.speedKMH, deltaTime)
+	accel := 3*cc.GetThrottle() - cc.GetBrakeTorque()/1500 - c.pull
+	c.speedKMH = math.Max(0, c.speedKMH+accel*3.6*deltaTime)
+}
+
+// TestCruiseControlHoldsSpeed holds the set speed on a climb and on a descent, without a steady error
+func TestCruiseControlHoldsSpeed(t *testing.T) {
+	for _, pull := range []float64{0.6, -0.4} {
+		cc := NewCruiseControl()
+		road := &car{speedKMH: 100, pull: pull}
+		cc.Update(road.speedKMH, 0.1)
+		if !cc.Set() {
+			t.Fatal("Set at 100 km/h refused")
+		}
+
+		for i := 0; i < 1200; i++ {
+			road.step(cc, 0.1)
+		}
+		if math.Abs(road.speedKMH-100) > 0.5 {
+			t.Errorf("pull %.1f m/s²: %.2f km/h after 2 minutes, want 100 km/h", pull, road.speedKMH)
+		}
+		if pull > 0 && (cc.GetThrottle() <= 0 || cc.GetBrakeTorque() != 0) {
+			t.Errorf("climbing: throttle %.2f, brake %.0f Nm, want throttle alone", cc.GetThrottle(), cc.GetBrakeTorque())
+		}
+		if pull < 0 && (cc.GetThrottle() != 0 || cc.GetBrakeTorque() <= 0) {
+			t.Errorf("descending: throttle %.2f, brake %.0f Nm, want brakes alone", cc.GetThrottle(), cc.GetBrakeTorque())
+		}
+	}
+}
+
+// TestCruiseControlAntiWindup keeps the integral still while the throttle is saturated
+// and within IntegralLimit when it is not
+func TestCruiseControlAntiWindup(t *testing.T) {
+	cc := NewCruiseControl()
+	cc.SetSpeed(100)
+	for i := 0; i < 600; i++ {
+		cc.Update(70, 0.1)
+	}
+	if data := cc.GetData(); data.Throttle != 1 || data.Integral != 0 {
+		t.Errorf("30 km/h below the set speed: throttle %.2f, integral %.1f, want full throttle and no windup", data.Throttle, data.Integral)
+	}
+
+	cc.SetSpeed(100)
+	for i := 0; i < 600; i++ {
+		cc.Update(95, 0.1)
+	}
+	if data := cc.GetData(); data.Integral != cc.IntegralLimit {
+		t.Errorf("5 km/h below the set speed for a minute: integral %.1f, want the limit %.1f", data.Integral, cc.IntegralLimit)
+	}
+
+	// Flat out up a steep hill, the car has to settle back at the set speed without a long overshoot
+	cc.SetSpeed(100)
+	hill := &car{speedKMH: 100, pull: 3.5}
+	for i := 0; i < 300; i++ {
+		hill.step(cc, 0.1)
+	}
+	hill.pull = 0.5
+	maxKMH := 0.0
+	for i := 0; i < 600; i++ {
+		hill.step(cc, 0.1)
+		maxKMH = math.Max(maxKMH, hill.speedKMH)
+	}
+	if maxKMH > 103 || math.Abs(hill.speedKMH-100) > 0.5 {
+		t.Errorf("after the hill: peak %.1f km/h, settled at %.1f km/h, want at most 103 and about 100", maxKMH, hill.speedKMH)
+	}
+}
+
+// TestCruiseControlStates goes through set, cancel, resume and switch off
+func TestCruiseControlStates(t *testing.T) {
+	cc := NewCruiseControl()
+	cc.Update(20, 0.1)
+	if cc.Set() || cc.GetState() != Off {
+		t.Fatalf("Set at 20 km/h: %s, want refused below %.0f km/h", cc.GetState(), cc.MinSetSpeedKMH)
+	}
+
+	cc.Update(80, 0.1)
+	if !cc.Set() || cc.GetState() != Engaged {
+		t.Fatalf("Set at 80 km/h: %s, want engaged", cc.GetState())
+	}
+	cc.Update(70, 0.1)
+	if cc.GetThrottle() == 0 {
+		t.Error("10 km/h below the set speed: no throttle")
+	}
+
+	cc.Cancel()
+	if data := cc.GetData(); data.State != "standby" || data.SetSpeedKMH != 80 || data.Throttle != 0 || data.Integral != 0 {
+		t.Errorf("after Cancel: %v, want standby at 80 km/h with the outputs released", data)
+	}
+	cc.Update(70, 0.1)
+	if cc.GetThrottle() != 0 {
+		t.Errorf("standby: throttle %.2f, want 0", cc.GetThrottle())
+	}
+
+	if !cc.Resume() || cc.GetState() != Engaged || cc.GetData().SetSpeedKMH != 80 {
+		t.Errorf("after Resume: %v, want engaged at 80 km/h", cc.GetData())
+	}
+
+	cc.SwitchOff()
+	if cc.Resume() || cc.GetState() != Off || cc.GetData().SetSpeedKMH != 0 {
+		t.Errorf("after SwitchOff: %v, want off with no set speed to resume", cc.GetData())
+	}
+}
@@ -0,0 +1,23 @@
+package cruise
+
+import "fmt"
+
+type Telemetry struct {
+	State       string
+	SetSpeedKMH float64
+	SpeedKMH    float64
+	ErrorKMH    float64 // Set speed minus current speed
+	Integral    float64 // Integral term of the PID, useful to tune the anti-windup
+	Throttle    float64 // Throttle demand (0.0 to 1.0)
+	BrakeTorque float64 // Brake demand per wheel in Nm
+}
+
+// String implements the String interface for human-readable formatting
+func (d Telemetry) String() string {
+	return fmt.Sprintf("Cruise [State: %s, SetSpeed: %.1f KMH, Error: %.1f KMH, Throttle: %.1f %%, Brake: %.0f Nm]\n",
+		d.State,
+		d.SetSpeedKMH,
+		d.ErrorKMH,
+		d.Throttle*100,
+		d.BrakeTorque)
+}
@@ -1,13 +1,15 @@
 package differential
 
+import "go-playground/internal/justforfun/vehiclesim/units"
+
 const TypeRDiffRatio = 3.84
 
 type Differential struct {
 	gearRatio   float64 // Differential ratio
-	wheelSpeedL float64 // Angular velocity of the left wheel
-	wheelSpeedR float64 // Angular velocity of the right wheel
-	torqueL     float64 // Torque sent to the left wheel
-	torqueR     float64 // Torque sent to the right wheel
+	wheelSpeedL float64 // Angular velocity of the left wheel in RPM
+	wheelSpeedR float64 // Angular velocity of the right wheel in RPM
+	torqueL     float64 // Torque sent to the left wheel in Nm
+	torqueR     float64 // Torque sent to the right wheel in Nm
 	slipRatio   float64 // Slip ratio between the wheels
 }
 
@@ -19,15 +21,15 @@ func NewBasicDifferential(gearRatio float64) *Differential {
 }
 
 // Update calculates the speeds and torque for the wheels based on the input
-func (d *Differential) Update(inputShaftRPM float64, inputTorque float64, slipRatio float64) {
+func (d *Differential) Update(inputSpeed units.AngularVelocity, inputTorque units.Torque, slipRatio float64) {
 	// slipRatio is an external input and should be calculated in based on steering angle, terrain, wheel grip, etc.
 
 	// Basic output ratio based on a differential ratio
-	wheelRPM := inputShaftRPM / d.gearRatio
+	wheelRPM := inputSpeed.RPM() / d.gearRatio
 
-	// Distribute the torque (basic, no slip)
-	d.torqueL = inputTorque / 2
-	d.torqueR = inputTorque / 2
+	// The final drive multiplies the torque, an open differential splits it evenly
+	d.torqueL = inputTorque.NewtonMeters() * d.gearRatio / 2
+	d.torqueR = inputTorque.NewtonMeters() * d.gearRatio / 2
 
 	// Apply a slip coefficient
 	d.wheelSpeedL = wheelRPM * (1.0 - slipRatio/2.0)
@@ -37,9 +39,22 @@ func (d *Differential) Update(inputShaftRPM float64, inputTorque float64, slipRa
 	d.slipRatio = slipRatio
 }
 
+// GetGearRatio returns the differential ratio
+func (d *Differential) GetGearRatio() float64 {
+	return d.gearRatio
+}
+
+// GetWheelSpeeds returns the speeds the differential drives the left and right wheels at
+func (d *Differential) GetWheelSpeeds() (left, right units.AngularVelocity) {
+	return units.RPM.Of(d.wheelSpeedL), units.RPM.Of(d.wheelSpeedR)
+}
+
 func (d *Differential) GetData() Telemetry {
 	return Telemetry{
 		WheelSpeedL: d.wheelSpeedL,
 		WheelSpeedR: d.wheelSpeedR,
+		TorqueL:     d.torqueL,
+		TorqueR:     d.torqueR,
+		SlipRatio:   d.slipRatio,
 	}
 }
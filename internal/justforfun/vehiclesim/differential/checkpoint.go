@@ -0,0 +1,30 @@
+package differential
+
+// Checkpoint is the state of the differential needed to resume a simulation
+type Checkpoint struct {
+	WheelSpeedL float64
+	WheelSpeedR float64
+	TorqueL     float64
+	TorqueR     float64
+	SlipRatio   float64
+}
+
+// Checkpoint returns the current state of the differential
+func (d *Differential) Checkpoint() Checkpoint {
+	return Checkpoint{
+		WheelSpeedL: d.wheelSpeedL,
+		WheelSpeedR: d.wheelSpeedR,
+		TorqueL:     d.torqueL,
+		TorqueR:     d.torqueR,
+		SlipRatio:   d.slipRatio,
+	}
+}
+
+// Restore sets the differential back to a saved state, the ratio comes from the vehicle spec
+func (d *Differential) Restore(c Checkpoint) {
+	d.wheelSpeedL = c.WheelSpeedL
+	d.wheelSpeedR = c.WheelSpeedR
+	d.torqueL = c.TorqueL
+	d.torqueR = c.TorqueR
+	d.slipRatio = c.SlipRatio
+}
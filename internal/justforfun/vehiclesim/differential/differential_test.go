@@ -1,40 +1,37 @@
 package differential
 
 import (
+	"go-playground/internal/justforfun/vehiclesim/units"
+	"math"
 	"testing"
 )
 
-// TestDifferentialBehavior demonstrates the behavior of the differential under different slipRatio values
-// For now, only print the results for manual observation.
+// TestDifferentialBehavior checks the differential turns the input down by its ratio on average, spreads
+// the wheel speeds by the slip ratio and splits the multiplied torque evenly without creating power
 func TestDifferentialBehavior(t *testing.T) {
 	diff := NewBasicDifferential(TypeRDiffRatio)
+	input, torque := units.RPM.Of(2000), units.NewtonMeters.Of(150)
+	baseRPM := input.RPM() / diff.GetGearRatio()
 
-	testSlipRatios := []float64{0.0, 0.05, -0.05, 0.25, 2.0}
-	inputRPM := 2000.0
-	inputTorque := 150.0
-
-	// The gear ratio is taken from the differential itself.
-	gearRatio := diff.gearRatio
-	expectedBaseRPM := inputRPM / gearRatio
-
-	t.Log("--- Testing differential behavior ---")
-	t.Logf("Input RPM: %.2f, Gear Ratio: %.2f, Expected Base Wheel RPM: %.2f", inputRPM, gearRatio, expectedBaseRPM)
-	t.Log("---------------------------------------------------------------------")
-
-	for _, slip := range testSlipRatios {
-		diff.Update(inputRPM, inputTorque, slip)
+	for _, slip := range []float64{0.0, 0.05, -0.05, 0.25, 2.0} {
+		diff.Update(input, torque, slip)
 		data := diff.GetData()
+		t.Logf("SlipRatio: %-5.2f -> Left wheel: %7.2f RPM, Right wheel: %7.2f RPM", slip, data.WheelSpeedL, data.WheelSpeedR)
 
-		// Use Logf for print results for manual observation only with the test is launched with flag -v
-		t.Logf("SlipRatio: %-5.2f -> Left wheel: %7.2f RPM, Right wheel: %7.2f RPM",
-			slip, data.WheelSpeedL, data.WheelSpeedR)
+		if got := (data.WheelSpeedL + data.WheelSpeedR) / 2; math.Abs(got-baseRPM) > 1e-9 {
+			t.Errorf("slip %.2f: average wheel speed %.3f RPM, want the input / ratio = %.3f", slip, got, baseRPM)
+		}
+		if got, want := data.WheelSpeedR-data.WheelSpeedL, baseRPM*slip; math.Abs(got-want) > 1e-9 {
+			t.Errorf("slip %.2f: right - left wheel %.3f RPM, want %.3f", slip, got, want)
+		}
+		if data.TorqueL != data.TorqueR || math.Abs(data.TorqueL+data.TorqueR-torque.NewtonMeters()*TypeRDiffRatio) > 1e-9 {
+			t.Errorf("slip %.2f: wheel torques %.3f and %.3f Nm, want an even split of the input × ratio", slip, data.TorqueL, data.TorqueR)
+		}
 
-		// Check that the wheel speeds are equal for slipRatio = 0.0
-		if slip == 0.0 {
-			if data.WheelSpeedL != data.WheelSpeedR {
-				//
-				t.Errorf("For slipRatio 0.0, expected WheelSpeedL (%.2f) == WheelSpeedR (%.2f), but it wasn't like that", data.WheelSpeedL, data.WheelSpeedR)
-			}
+		left, right := diff.GetWheelSpeeds()
+		out := units.PowerOf(units.NewtonMeters.Of(data.TorqueL), left) + units.PowerOf(units.NewtonMeters.Of(data.TorqueR), right)
+		if in := units.PowerOf(torque, input); math.Abs(out.Watts()-in.Watts()) > 1e-6 {
+			t.Errorf("slip %.2f: delivers %s out of %s", slip, out, in)
 		}
 	}
 }
@@ -5,10 +5,16 @@ import "fmt"
 type Telemetry struct {
 	WheelSpeedL float64
 	WheelSpeedR float64
+	TorqueL     float64 // Torque sent to the left wheel in Nm
+	TorqueR     float64 // Torque sent to the right wheel in Nm
+	SlipRatio   float64 // Speed difference between the wheels, positive when turning left
 }
 
 func (d Telemetry) String() string {
-	return fmt.Sprintf("Differential [WheelSpeedL: %.0f RPM, WheelSpeedR: %.0f RPM]\n",
+	return fmt.Sprintf("Differential [WheelSpeedL: %.0f RPM, WheelSpeedR: %.0f RPM, TorqueL: %.0f Nm, TorqueR: %.0f Nm, SlipRatio: %.4f]\n",
 		d.WheelSpeedL,
-		d.WheelSpeedR)
+		d.WheelSpeedR,
+		d.TorqueL,
+		d.TorqueR,
+		d.SlipRatio)
 }
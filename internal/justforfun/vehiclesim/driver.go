@@ -0,0 +1,289 @@
+package vehiclesim
+
+import (
+	"go-playground/internal/justforfun/vehiclesim/units"
+	"math"
+)
+
+// Driver moves the pedals, the gear lever and the driver aid buttons of a vehicle
+// Drive is called once per simulation step, before the vehicle is updated
+type Driver interface {
+	Drive(v *Vehicle, deltaTime float64)
+}
+
+// DriverProfile sets how a ScriptedDriver drives
+type DriverProfile struct {
+	Name             string
+	UpshiftRPM       float64 // Shift up above this RPM
+	DownshiftRPM     float64 // Shift down below this RPM
+	ThrottleStep     float64 // Pedal travel per ThrottleInterval while ramping
+	ThrottleInterval float64 // Seconds between pedal steps
+	MaxThrottle      float64 // Deepest pedal position of the ramp (0.0 to 1.0)
+	LaunchControl    bool    // Standing start with launch control
+	CruiseControl    bool    // Engage the cruise control at the top of each ramp
+	RevMatch         bool    // Blip the throttle on downshifts
+}
+
+// NormalProfile is the driver of the single vehicle simulation
+func NormalProfile() DriverProfile {
+	return DriverProfile{
+		Name:             "normal",
+		UpshiftRPM:       4000,
+		DownshiftRPM:     2000,
+		ThrottleStep:     0.05,
+		ThrottleInterval: 0.5,
+		MaxThrottle:      1.0,
+		LaunchControl:    true,
+		CruiseControl:    true,
+		RevMatch:         autoRevMatch,
+	}
+}
+
+// CalmProfile shifts early and never floors the pedal
+func CalmProfile() DriverProfile {
+	return DriverProfile{
+		Name:             "calm",
+		UpshiftRPM:       3000,
+		DownshiftRPM:     1200,
+		ThrottleStep:     0.025,
+		ThrottleInterval: 0.5,
+		MaxThrottle:      0.6,
+		LaunchControl:    false,
+		CruiseControl:    true,
+		RevMatch:         false,
+	}
+}
+
+// SportyProfile revs high, ramps fast and skips the cruise control
+func SportyProfile() DriverProfile {
+	return DriverProfile{
+		Name:             "sporty",
+		UpshiftRPM:       6500,
+		DownshiftRPM:     2500,
+		ThrottleStep:     0.1,
+		ThrottleInterval: 0.5,
+		MaxThrottle:      1.0,
+		LaunchControl:    true,
+		CruiseControl:    false,
+		RevMatch:         true,
+	}
+}
+
+// driverStep is an action followed by a wait in simulated seconds
+// When until is set, the wait ends as soon as it returns true
+type driverStep struct {
+	action func()
+	wait   float64
+	until  func() bool
+}
+
+// timeline runs driver steps against the simulated clock instead of sleeping
+type timeline struct {
+	steps   []driverStep
+	waiting float64
+	until   func() bool
+}
+
+func (t *timeline) add(action func(), wait float64) {
+	t.steps = append(t.steps, driverStep{action: action, wait: wait})
+}
+
+func (t *timeline) addUntil(action func(), maxWait float64, until func() bool) {
+	t.steps = append(t.steps, driverStep{action: action, wait: maxWait, until: until})
+}
+
+// idle reports whether every step has run and its wait has elapsed
+func (t *timeline) idle() bool {
+	return len(t.steps) == 0 && t.waiting <= 0
+}
+
+// advance runs every step whose turn has come in the next deltaTime seconds
+func (t *timeline) advance(deltaTime float64) {
+	if t.until != nil && t.until() {
+		t.waiting = 0
+	} else {
+		t.waiting -= deltaTime
+	}
+
+	for t.waiting <= 0 && len(t.steps) > 0 {
+		step := t.steps[0]
+		t.steps = t.steps[1:]
+		if step.action != nil {
+			step.action()
+		}
+		t.waiting += step.wait
+		t.until = step.until
+	}
+}
+
+// ScriptedDriver repeats the throttle ramps, cruise control sequence and gear shifts of the
+// original simulation on the simulated clock, so it works at any time scale
+type ScriptedDriver struct {
+	Profile    DriverProfile
+	StartDelay float64 // Seconds at idle before the standing start
+
+	pedals   timeline
+	shifter  timeline
+	started  bool
+	launched bool
+}
+
+// NewScriptedDriver creates a driver that follows the given profile
+func NewScriptedDriver(profile DriverProfile) *ScriptedDriver {
+	return &ScriptedDriver{
+		Profile: profile,
+	}
+}
+
+// Drive implements Driver
+func (d *ScriptedDriver) Drive(v *Vehicle, deltaTime float64) {
+	if !d.started {
+		d.started = true
+		if v.Body.GetSpeed() > launchSpeedMS {
+			// Taking over a moving car, e.g. resumed from a checkpoint, skips the standing start
+			d.launched = true
+		} else {
+			d.pedals.add(nil, d.StartDelay)
+			d.queueStandingStart(v)
+		}
+	}
+
+	d.pedals.advance(deltaTime)
+	if d.pedals.idle() {
+		d.queueThrottleRamp(v)
+	}
+
+	if !d.launched {
+		return
+	}
+	d.shifter.advance(deltaTime)
+	if d.shifter.idle() {
+		d.queueShiftCheck(v)
+	}
+}
+
+// queueStandingStart gets the car moving in first gear
+func (d *ScriptedDriver) queueStandingStart(v *Vehicle) {
+	d.pedals.add(func() {
+		v.Gearbox.SetClutch(0.0)
+		v.Gearbox.SetGear(1)
+		if d.Profile.LaunchControl {
+			// Floor the throttle with the clutch pressed and let the ECU hold the launch RPM
+			v.Engine.GetLaunchControl().Arm()
+			v.Engine.SetAcceleratorPos(1.0)
+		} else {
+			v.Engine.SetAcceleratorPos(0.3)
+		}
+	}, 3)
+
+	// Dump the clutch with launch control, release it gently without
+	clutchStep, clutchWait := 0.25, 0.05
+	if !d.Profile.LaunchControl {
+		clutchStep, clutchWait = 0.1, 0.15
+	}
+	for clutch := 0.0; clutch <= 1.0; clutch += clutchStep {
+		position := clutch
+		d.pedals.add(func() { v.Gearbox.SetClutch(position) }, clutchWait)
+	}
+	d.pedals.add(func() { v.Gearbox.SetClutch(1.0) }, 2)
+
+	// Hand over to the throttle ramps from idle, the shifts start 2 seconds later
+	d.pedals.add(func() {
+		v.Engine.SetAcceleratorPos(0.0)
+		d.launched = true
+		d.shifter.add(nil, 2)
+	}, 1)
+}
+
+// queueThrottleRamp presses the pedal gradually and holds it, then lets the cruise control take over
+func (d *ScriptedDriver) queueThrottleRamp(v *Vehicle) {
+	for pos := 0.0; pos <= d.Profile.MaxThrottle; pos += d.Profile.ThrottleStep {
+		position := pos
+		d.pedals.add(func() { v.Engine.SetAcceleratorPos(position) }, d.Profile.ThrottleInterval)
+	}
+	// Hold the pedal briefly
+	d.pedals.add(nil, 2)
+	d.pedals.add(func() { d.queueCruise(v) }, 0)
+}
+
+// queueCruise engages the cruise control at the current speed when the profile uses it,
+// then releases the pedal gradually
+func (d *ScriptedDriver) queueCruise(v *Vehicle) {
+	if d.Profile.CruiseControl && v.CruiseControl.Set() {
+		v.Engine.SetAcceleratorPos(0.0)
+		d.pedals.add(nil, 10)
+
+		// Tap the brake, the car coasts with the set speed memorized
+		d.pedals.add(func() { v.CruiseControl.Cancel() }, 3)
+
+		// Resume back to the set speed
+		d.pedals.add(func() { v.CruiseControl.Resume() }, 5)
+		d.pedals.add(func() {
+			v.CruiseControl.Cancel()
+			v.Engine.SetAcceleratorPos(d.Profile.MaxThrottle)
+		}, 0)
+	}
+
+	// Gradual deceleration
+	for pos := d.Profile.MaxThrottle; pos >= 0.0; pos -= d.Profile.ThrottleStep {
+		position := pos
+		d.pedals.add(func() { v.Engine.SetAcceleratorPos(position) }, d.Profile.ThrottleInterval)
+	}
+	// Idle pause
+	d.pedals.add(func() { v.Engine.SetAcceleratorPos(0.0) }, 2)
+}
+
+// queueShiftCheck shifts when the RPM leaves the profile band and checks again after 500 ms
+func (d *ScriptedDriver) queueShiftCheck(v *Vehicle) {
+	rpm := v.Engine.GetSpeed().RPM()
+	gear := v.Gearbox.GetCurrentGear()
+
+	switch {
+	case rpm > d.Profile.UpshiftRPM && gear < 7:
+		d.shifter.queueGearShift(v, v.Gearbox.ShiftUp, nil)
+
+	case rpm < d.Profile.DownshiftRPM && gear > 1:
+		var revMatchRPM func() float64
+		if d.Profile.RevMatch {
+			revMatchRPM = func() float64 { return v.SyncRPM(v.Gearbox.GetCurrentGear()) }
+		}
+		d.shifter.queueGearShift(v, v.Gearbox.ShiftDown, revMatchRPM)
+	}
+
+	d.shifter.add(nil, 0.5)
+}
+
+// queueGearShift runs the clutch sequence of a manual shift
+// revMatchRPM returns the engine RPM to blip to once the new gear is selected, nil to skip the blip
+func (t *timeline) queueGearShift(v *Vehicle, shiftGear func() bool, revMatchRPM func() float64) {
+	var currentAccel float64
+
+	// Save the current throttle position and reduce acceleration
+	t.add(func() {
+		currentAccel = v.Engine.GetAcceleratorPos()
+		v.Engine.SetAcceleratorPos(0.3)
+	}, 0.1)
+
+	t.add(func() { v.Gearbox.SetClutch(0.0) }, 0.2) // Press clutch
+	t.add(func() { shiftGear() }, 0.2)              // Change gear
+
+	if revMatchRPM != nil {
+		// Blip so the engine reaches the speed of the lower gear before the clutch bites
+		var targetRPM float64
+		t.addUntil(func() {
+			targetRPM = revMatchRPM()
+			v.Engine.StartRevMatch(units.RPM.Of(targetRPM))
+		}, 0.8, func() bool {
+			return math.Abs(v.Engine.GetSpeed().RPM()-targetRPM) <= 150
+		})
+	}
+
+	// Release clutch gradually
+	for clutch := 0.0; clutch <= 1.0; clutch += 0.2 {
+		position := clutch
+		t.add(func() { v.Gearbox.SetClutch(position) }, 0.05)
+	}
+
+	// Restore throttle
+	t.add(func() { v.Engine.SetAcceleratorPos(currentAccel) }, 0)
+}
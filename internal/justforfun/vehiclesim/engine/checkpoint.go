@@ -0,0 +1,123 @@
+package engine
+
+import "math/rand"
+
+// countingSource counts the numbers drawn from a seeded source,
+// so the position of the random sequence can be saved and restored
+type countingSource struct {
+	source rand.Source64
+	seed   int64
+	draws  uint64
+}
+
+func newCountingSource(seed int64) *countingSource {
+	return &countingSource{
+		source: rand.NewSource(seed).(rand.Source64),
+		seed:   seed,
+	}
+}
+
+func (s *countingSource) Int63() int64 {
+	s.draws++
+	return s.source.Int63()
+}
+
+func (s *countingSource) Uint64() uint64 {
+	s.draws++
+	return s.source.Uint64()
+}
+
+func (s *countingSource) Seed(seed int64) {
+	s.source.Seed(seed)
+	s.seed = seed
+	s.draws = 0
+}
+
+// Checkpoint is the state of the engine needed to resume a simulation
+// The calibration comes from NewEngine and the vehicle spec, it is not saved
+type Checkpoint struct {
+	RPM              float64
+	Torque           float64
+	OilTemp          float64
+	OilPressure      float64
+	WaterTemp        float64
+	AcceleratorPos   float64
+	ThrottleLimit    float64
+	TorqueLimit      float64
+	CruiseThrottle   float64
+	FuelRateLH       float64
+	FuelUsedL        float64
+	RevMatchRPM      float64
+	CombustionFactor float64
+	DrivelineRPM     float64
+	DrivelineLock    bool
+	InertiaTorque    float64
+
+	LimiterFuelCut bool
+	LimiterFactor  float64
+	LaunchState    LaunchState
+	LaunchElapsed  float64
+	LaunchRPM      float64
+
+	Seed  int64  // Seed of the random fluctuations
+	Draws uint64 // Random numbers drawn since seeding
+}
+
+// Checkpoint returns the current state of the engine
+func (m *Engine) Checkpoint() Checkpoint {
+	return Checkpoint{
+		RPM:              m.rpm,
+		Torque:           m.torque,
+		OilTemp:          m.oilTemp,
+		OilPressure:      m.oilPressure,
+		WaterTemp:        m.waterTemp,
+		AcceleratorPos:   m.acceleratorPos,
+		ThrottleLimit:    m.throttleLimit,
+		TorqueLimit:      m.torqueLimit,
+		CruiseThrottle:   m.cruiseThrottle,
+		FuelRateLH:       m.fuelConsumption,
+		FuelUsedL:        m.fuelUsed,
+		RevMatchRPM:      m.revMatchRPM,
+		CombustionFactor: m.combustionFactor,
+		DrivelineRPM:     m.drivelineRPM,
+		DrivelineLock:    m.drivelineLock,
+		InertiaTorque:    m.inertiaTorque,
+		LimiterFuelCut:   m.revLimiter.fuelCut,
+		LimiterFactor:    m.revLimiter.factor,
+		LaunchState:      m.launchControl.state,
+		LaunchElapsed:    m.launchControl.elapsed,
+		LaunchRPM:        m.launchControl.TargetRPM,
+		Seed:             m.source.seed,
+		Draws:            m.source.draws,
+	}
+}
+
+// Restore sets the engine back to a saved state, the random sequence continues where it was saved
+func (m *Engine) Restore(c Checkpoint) {
+	m.rpm = c.RPM
+	m.torque = c.Torque
+	m.oilTemp = c.OilTemp
+	m.oilPressure = c.OilPressure
+	m.waterTemp = c.WaterTemp
+	m.acceleratorPos = c.AcceleratorPos
+	m.throttleLimit = c.ThrottleLimit
+	m.torqueLimit = c.TorqueLimit
+	m.cruiseThrottle = c.CruiseThrottle
+	m.fuelConsumption = c.FuelRateLH
+	m.fuelUsed = c.FuelUsedL
+	m.revMatchRPM = c.RevMatchRPM
+	m.combustionFactor = c.CombustionFactor
+	m.drivelineRPM = c.DrivelineRPM
+	m.drivelineLock = c.DrivelineLock
+	m.inertiaTorque = c.InertiaTorque
+	m.revLimiter.fuelCut = c.LimiterFuelCut
+	m.revLimiter.factor = c.LimiterFactor
+	m.launchControl.state = c.LaunchState
+	m.launchControl.elapsed = c.LaunchElapsed
+	m.launchControl.TargetRPM = c.LaunchRPM
+
+	m.SetSeed(c.Seed)
+	for m.source.draws < c.Draws {
+		m.source.Uint64()
+	}
+}
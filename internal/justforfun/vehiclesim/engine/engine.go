@@ -1,22 +1,40 @@
 package engine
 
 import (
+	"go-playground/internal/justforfun/vehiclesim/units"
 	"math"
 	"math/rand"
+	"time"
+)
+
+const (
+	// IdleRPM is the lowest speed the engine runs at, below it the clutch has to slip
+	IdleRPM = 800
+	// clutchLockRate in 1/s of the engaged clutch closing the engine to clutch disc speed difference,
+	// half of it every 0.1 s
+	clutchLockRate = 10 * math.Ln2
+	// flywheelInertia of the crankshaft and flywheel in kg·m², released as torque when the clutch slows the engine
+	flywheelInertia = 0.2
+	// thermostatTemp is the coolant temperature in °C the thermostat regulates to
+	thermostatTemp = 90
 )
 
 type Engine struct {
 	// Engine state
-	Rpm             float64
+	rpm             float64
 	torque          float64
 	oilTemp         float64
 	acceleratorPos  float64 // 0.0 to 1.0 (0% to 100%)
-	fuelConsumption float64
+	throttleLimit   float64 // Max throttle allowed by driver aids like traction control (0.0 to 1.0)
+	torqueLimit     float64 // Share of the combustion torque allowed by driver aids (0.0 to 1.0)
+	cruiseThrottle  float64 // Throttle requested by the cruise control (0.0 to 1.0)
+	fuelConsumption float64 // L/h
+	fuelUsed        float64 // L
 	oilPressure     float64
-	waterTemp       float64
+	waterTemp       float64 // Coolant temperature
 
 	// Engine limits
-	MaxRPM                float64
+	maxRPM                float64
 	maxTorque             float64
 	maxTheoreticalPowerKW float64
 	maxTemp               float64
@@ -28,16 +46,36 @@ type Engine struct {
 	// torque curve parameters
 	rpmMaxTorque float64 // RPM where maximum torque is reached
 	rpmMaxPower  float64 // RPM where maximum power is reached
+
+	// ECU
+	revLimiter       *RevLimiter
+	launchControl    *LaunchControl
+	revMatchRPM      float64 // Target RPM of an automatic downshift blip, 0 when inactive
+	combustionFactor float64 // Fraction of combustion torque allowed by the ECU (0-1)
+
+	// Driveline
+	drivelineRPM  float64 // Speed of the clutch disc, imposed by the wheels
+	drivelineLock bool    // Whether the wheels hold the clutch disc, false in neutral or while the tires spin
+	inertiaTorque float64 // Torque released by the flywheel while the clutch slows the engine
+	wheelSlip     float64 // Slip of the driven wheels, read by launch control
+	clutchLimit   float64 // Max torque the clutch can transmit in Nm
+
+	// Source of the random fluctuations, seeded to reproduce a run
+	source *countingSource
+	rng    *rand.Rand
 }
 
 func NewEngine() *Engine {
 
-	return &Engine{
-		Rpm:                   800, // Low Idle
+	m := &Engine{
+		rpm:                   800, // Low Idle
 		torque:                0,
 		oilTemp:               80, // Initial oil temperature
+		waterTemp:             thermostatTemp,
 		acceleratorPos:        0,
-		MaxRPM:                8500,  // Max RPM
+		throttleLimit:         1,
+		torqueLimit:           1,
+		maxRPM:                8500,  // Max RPM
 		maxTorque:             450,   // Nm
 		maxTheoreticalPowerKW: 150.0, // kW, adjust to specifications
 		maxTemp:               120,   // Max oil temperature
@@ -45,11 +83,23 @@ func NewEngine() *Engine {
 		inertia:               0.3,   // Inertia Engine factor (0-1)
 		rpmMaxTorque:          3500,  // Typical RPM for maximum torque
 		rpmMaxPower:           5500,  // Typical RPM for maximum power
+		revLimiter:            NewRevLimiter(HardCut, 8200),
+		launchControl:         NewLaunchControl(4000),
+		combustionFactor:      1,
+		clutchLimit:           675, // 1.5 times the max torque
 	}
+	m.SetSeed(time.Now().UnixNano())
+	return m
+}
+
+// SetSeed makes the random fluctuations of the engine reproducible
+func (m *Engine) SetSeed(seed int64) {
+	m.source = newCountingSource(seed)
+	m.rng = rand.New(m.source)
 }
 
-func randomInRange(min, max float64) float64 {
-	return rand.Float64()*(max-min) + min
+func (m *Engine) randomInRange(min, max float64) float64 {
+	return m.rng.Float64()*(max-min) + min
 }
 
 func (m *Engine) SetAcceleratorPos(position float64) {
@@ -57,14 +107,101 @@ func (m *Engine) SetAcceleratorPos(position float64) {
 	m.acceleratorPos = math.Max(0, math.Min(1, position))
 }
 
-// GetRPM retorna las revoluciones por minuto actuales del motor
-func (m *Engine) GetRPM() float64 {
-	return m.Rpm
+// GetAcceleratorPos returns the accelerator pedal position (0.0 to 1.0)
+func (m *Engine) GetAcceleratorPos() float64 {
+	return m.acceleratorPos
+}
+
+// SetThrottleOverride limits the effective throttle regardless of the accelerator pedal
+// Used by driver aids such as traction control, 1.0 means no intervention
+func (m *Engine) SetThrottleOverride(limit float64) {
+	m.throttleLimit = math.Max(0, math.Min(1, limit))
+}
+
+// SetTorqueLimit cuts the combustion torque at once regardless of the throttle, like the ignition
+// retard of traction control. 1.0 means no intervention
+func (m *Engine) SetTorqueLimit(limit float64) {
+	m.torqueLimit = math.Max(0, math.Min(1, limit))
+}
+
+// SetCruiseThrottle sets the throttle requested by the cruise control
+// The driver can always press the pedal further than the cruise control
+func (m *Engine) SetCruiseThrottle(demand float64) {
+	m.cruiseThrottle = math.Max(0, math.Min(1, demand))
+}
+
+// effectiveThrottle returns the throttle opening after driver aid interventions
+func (m *Engine) effectiveThrottle() float64 {
+	return math.Min(math.Max(m.acceleratorPos, m.cruiseThrottle), m.throttleLimit)
+}
+
+// SetDrivelineSpeed sets the speed of the clutch disc imposed by the wheels
+// locked is false when nothing holds the disc, e.g. in neutral or while the tires spin
+func (m *Engine) SetDrivelineSpeed(speed units.AngularVelocity, locked bool) {
+	m.drivelineRPM = math.Max(0, speed.RPM())
+	m.drivelineLock = locked
+}
+
+// SetWheelSlip passes the largest longitudinal slip of the driven wheels to launch control
+func (m *Engine) SetWheelSlip(slip float64) {
+	m.wheelSlip = slip
+}
+
+// SetRevLimiter replaces the rev limiter strategy and calibration
+func (m *Engine) SetRevLimiter(limiter *RevLimiter) {
+	m.revLimiter = limiter
+}
+
+// GetRevLimiter returns the current rev limiter
+func (m *Engine) GetRevLimiter() *RevLimiter {
+	return m.revLimiter
+}
+
+// GetLaunchControl returns the launch control so it can be armed and calibrated
+func (m *Engine) GetLaunchControl() *LaunchControl {
+	return m.launchControl
+}
+
+// StartRevMatch blips the engine to the given speed while the clutch is pressed
+// The blip ends automatically once the clutch engages again
+func (m *Engine) StartRevMatch(target units.AngularVelocity) {
+	m.revMatchRPM = math.Max(800, math.Min(m.revLimiter.LimitRPM, target.RPM()))
+}
+
+// StopRevMatch cancels an ongoing blip
+func (m *Engine) StopRevMatch() {
+	m.revMatchRPM = 0
+}
+
+// IsRevMatching reports whether the ECU is blipping the engine for a downshift
+func (m *Engine) IsRevMatching() bool {
+	return m.revMatchRPM > 0
+}
+
+// GetRevMatchSpeed returns the target speed of the ongoing blip, 0 when inactive
+func (m *Engine) GetRevMatchSpeed() units.AngularVelocity {
+	return units.RPM.Of(m.revMatchRPM)
 }
 
-// GetTorque retorna el torque actual del motor en Nm
-func (m *Engine) GetTorque() float64 {
-	return m.torque
+// GetSpeed retorna la velocidad actual del cigüeñal
+func (m *Engine) GetSpeed() units.AngularVelocity {
+	return units.RPM.Of(m.rpm)
+}
+
+// GetMaxSpeed returns the mechanical speed limit of the engine
+func (m *Engine) GetMaxSpeed() units.AngularVelocity {
+	return units.RPM.Of(m.maxRPM)
+}
+
+// GetTorque retorna el torque actual del motor
+func (m *Engine) GetTorque() units.Torque {
+	return units.NewtonMeters.Of(m.torque)
+}
+
+// GetClutchTorque returns the torque delivered to the clutch: the combustion torque
+// plus the torque released by the flywheel, up to what the clutch can transmit
+func (m *Engine) GetClutchTorque() units.Torque {
+	return units.NewtonMeters.Of(math.Min(m.clutchLimit, m.torque+m.inertiaTorque))
 }
 
 // Update actualiza el estado del motor basado en acelerador y posición del clutch
@@ -73,46 +210,104 @@ func (m *Engine) GetTorque() float64 {
 //	clutchPosition: posición del clutch (0.0 = disengaged, 1.0 = engaged)
 //	deltaTime: tiempo transcurrido en segundos
 func (m *Engine) Update(clutchPosition float64, deltaTime float64) {
+	m.launchControl.update(clutchPosition, m.acceleratorPos, deltaTime)
+	if m.IsRevMatching() && clutchPosition > 0.9 {
+		m.StopRevMatch()
+	}
 
 	// Update RPM considering the clutch
 	// Si el clutch está presionado (disengaged), el motor se ralentiza más libremente
 	clutchSlip := 1.0 - clutchPosition
 
 	// If the clutch is pressed (clutch disengaged), the engine spins more freely.
-	rpmDrop := m.Rpm * clutchSlip * 0.1
+	rpmDrop := m.rpm * clutchSlip * 0.1
+	if m.launchControl.State() == LaunchHolding || m.IsRevMatching() {
+		// The ECU governs the free-revving engine against the launch or blip limit instead
+		rpmDrop = 0
+	}
 
 	m.updateRPM(deltaTime)
-	m.Rpm -= rpmDrop
+	m.rpm -= rpmDrop
+
+	// The clutch drags the engine towards the speed of the wheels, faster the more it is engaged.
+	// Launch control keeps the clutch slipping at the launch RPM until it is released.
+	m.inertiaTorque = 0
+	if m.drivelineLock && m.launchControl.State() != LaunchHolding && deltaTime > 0 {
+		previousRPM := m.rpm
+		m.rpm += (m.drivelineRPM - m.rpm) * clutchLockFraction(clutchPosition, deltaTime)
+		m.rpm = math.Max(IdleRPM, math.Min(m.maxRPM, m.rpm))
+
+		// Slowing down the flywheel pushes its energy through the clutch
+		m.inertiaTorque = math.Max(0, flywheelInertia*units.RPM.Of(previousRPM-m.rpm).RadPerSec()/deltaTime)
+	}
 
 	m.UpdateTorque()
+	m.updateFuel(deltaTime)
 	m.updateOilTemp(deltaTime)
+	m.updateCoolantTemp(deltaTime)
 
 	// Nota: La orquestación del acoplamiento con la transmisión es responsabilidad
 	// de simulation.VehicleSimulation(), no de Engine.
 	// Engine ahora es un componente independiente que no conoce de la transmisión.
 }
 
+// clutchLockFraction returns the share of the engine to clutch disc speed difference the clutch closes
+// in deltaTime, the slipping clutch closes it slower. The same share goes in a second whatever the step size.
+func clutchLockFraction(clutchPosition float64, deltaTime float64) float64 {
+	return 1 - math.Exp(-clutchPosition*clutchLockRate*deltaTime)
+}
+
 func (m *Engine) updateRPM(deltaTime float64) {
+	// The limiter decides how much combustion is allowed before moving towards the target
+	m.combustionFactor = m.revLimiter.Apply(m.rpm, m.currentLimitRPM())
+
+	throttle := m.effectiveThrottle()
+	response := m.inertia
+	if m.IsRevMatching() {
+		// Blip: full throttle on the unloaded engine, which revs much quicker with the clutch open
+		throttle = 1
+		response = m.inertia * 4
+	}
+
 	// Calculate target RPM based on throttle position
-	rpmTarget := m.acceleratorPos*(m.MaxRPM-800) + 800
+	rpmTarget := throttle*m.combustionFactor*m.torqueLimit*(m.maxRPM-800) + 800
 
 	// Add random variation to simulate fluctuations
-	noise := randomInRange(-50, 50)
+	noise := m.randomInRange(-50, 50)
 
 	// Interpolate smoothly towards the target using inertia
-	m.Rpm = m.Rpm + (rpmTarget-m.Rpm)*m.inertia*deltaTime + noise
+	m.rpm = m.rpm + (rpmTarget-m.rpm)*response*deltaTime + noise
+
+	// Mechanical limit. The rev limiter should cut before reaching it
+	m.rpm = math.Max(800, math.Min(m.maxRPM, m.rpm))
+}
 
-	// Limit RPM. To cut!!
-	m.Rpm = math.Max(800, math.Min(m.MaxRPM, m.Rpm))
+// currentLimitRPM returns the active RPM limit, lowered to the launch RPM while launch control holds
+// and to the blip target while rev matching
+func (m *Engine) currentLimitRPM() float64 {
+	switch {
+	case m.launchControl.State() == LaunchHolding:
+		return m.launchControl.TargetRPM
+	case m.IsRevMatching():
+		return m.revMatchRPM
+	default:
+		return m.revLimiter.LimitRPM
+	}
 }
 
+// realisticTorqueCurve returns the torque of the curve at rpm for the current throttle
 func (m *Engine) realisticTorqueCurve(rpm float64) float64 {
+	return m.fullLoadTorque(rpm) * m.effectiveThrottle()
+}
+
+// fullLoadTorque returns the torque of the curve at rpm with the throttle wide open
+func (m *Engine) fullLoadTorque(rpm float64) float64 {
 	// Normalize RPM to the 0-1 range
-	rpmNorm := rpm / m.MaxRPM
+	rpmNorm := rpm / m.maxRPM
 
 	// Parameters to adjust the shape of the curve
-	torqueMaxRPM := m.rpmMaxTorque / m.MaxRPM
-	powerMaxRPM := m.rpmMaxPower / m.MaxRPM
+	torqueMaxRPM := m.rpmMaxTorque / m.maxRPM
+	powerMaxRPM := m.rpmMaxPower / m.maxRPM
 
 	// Create a curve that:
 	// - Starts low at idle
@@ -133,15 +328,15 @@ func (m *Engine) realisticTorqueCurve(rpm float64) float64 {
 	// Combine all the factors
 	torqueFactor := baseCurve * idleFactor * (0.7 + 0.3*highDrop)
 
-	// Multiply by the maximum torque and the throttle position
-	return torqueFactor * m.maxTorque * m.acceleratorPos
+	// Multiply by the maximum torque
+	return torqueFactor * m.maxTorque
 }
 
 func (m *Engine) UpdateTorque() {
-	m.torque = m.realisticTorqueCurve(m.Rpm)
+	m.torque = m.realisticTorqueCurve(m.rpm) * m.combustionFactor * m.torqueLimit * m.launchControl.torqueFactor(m.wheelSlip)
 
 	// Add a small random variation (1-2% of current torque)
-	smallRandomTorqueVariation := m.torque * randomInRange(-0.02, 0.02)
+	smallRandomTorqueVariation := m.torque * m.randomInRange(-0.02, 0.02)
 	m.torque += smallRandomTorqueVariation
 
 	// Make sure it is not negative
@@ -152,10 +347,10 @@ func (m *Engine) UpdateTorque() {
 func (m *Engine) updateOilTemp(deltaTime float64) {
 	// Temperature increases with RPM and load
 	tempTarget := m.minTemp +
-		(m.maxTemp-m.minTemp)*(0.3*m.Rpm/m.MaxRPM+0.7*m.acceleratorPos)
+		(m.maxTemp-m.minTemp)*(0.3*m.rpm/m.maxRPM+0.7*m.effectiveThrottle())
 
 	// Add random variation
-	noise := randomInRange(-0.5, 0.5)
+	noise := m.randomInRange(-0.5, 0.5)
 
 	// Gradual change in temperature
 	m.oilTemp += (tempTarget-m.oilTemp)*0.1*deltaTime + noise
@@ -164,25 +359,47 @@ func (m *Engine) updateOilTemp(deltaTime float64) {
 	m.oilTemp = math.Max(m.minTemp, math.Min(m.maxTemp, m.oilTemp))
 }
 
+// updateCoolantTemp keeps the coolant at the thermostat temperature until the oil gets
+// hotter than it, then the radiator cannot keep up and the coolant follows the oil
+func (m *Engine) updateCoolantTemp(deltaTime float64) {
+	target := thermostatTemp + 0.5*math.Max(0, m.oilTemp-thermostatTemp)
+	m.waterTemp += (target - m.waterTemp) * 0.05 * deltaTime
+}
+
+// calculateLoad returns the torque as a share of the full throttle torque at the current RPM
+func (m *Engine) calculateLoad() float64 {
+	fullLoad := m.fullLoadTorque(m.rpm)
+	if fullLoad <= 0 {
+		return 0
+	}
+	return math.Max(0, math.Min(1, m.torque/fullLoad))
+}
+
 // randomEngineEvents Function to simulate random engine events
 func (m *Engine) randomEngineEvents() string {
 	// 0.1% chance
-	if rand.Float64() < 0.001 {
+	if m.rng.Float64() < 0.001 {
 		events := []string{
 			"temperature_fluctuation",
 			"oil_pressure_drop",
 			"abnormal_vibration",
 		}
-		return events[rand.Intn(len(events))]
+		return events[m.rng.Intn(len(events))]
 	}
 	return "normal"
 }
 
 func (m *Engine) getState() string {
 	switch {
-	case m.Rpm < 850:
+	case m.launchControl.IsActive():
+		return "launch_control"
+	case m.IsRevMatching():
+		return "rev_match"
+	case m.revLimiter.IsCutting():
+		return "rpm_limit"
+	case m.rpm < 850:
 		return "low_idle"
-	case m.Rpm >= m.MaxRPM*0.95:
+	case m.rpm >= m.maxRPM*0.95:
 		return "rpm_limit"
 	case m.oilTemp >= m.maxTemp*0.9:
 		return "oilTemp_high"
@@ -191,16 +408,25 @@ func (m *Engine) getState() string {
 	}
 }
 
+// GetPower returns the power at the crankshaft
+func (m *Engine) GetPower() units.Power {
+	return units.PowerOf(units.NewtonMeters.Of(m.torque), units.RPM.Of(m.rpm))
+}
+
 // calculatePowerKw calculates the power in kW (kilowatt) based on the torque and RPM.
-// The conversion factor is 9549.297 (1 kW = 745.7 W)
 func (m *Engine) calculatePowerKw() float64 {
-	return (m.torque * m.Rpm) / 9549.297
+	return m.GetPower().Kilowatts()
 }
 
 // calculatePowerHp calculates the power in HP (horsepower) based on the torque and RPM.
-// The conversion factor is 1.341 (1 HP = 745.7 W)
 func (m *Engine) calculatePowerHp() float64 {
-	return (m.torque * m.Rpm) / 9549.297 * 1.341
+	return m.GetPower().Horsepower()
+}
+
+// CurveTorque returns the torque of the curve at the given speed for the current throttle,
+// without the combustion fluctuations of UpdateTorque
+func (m *Engine) CurveTorque(speed units.AngularVelocity) units.Torque {
+	return units.NewtonMeters.Of(m.realisticTorqueCurve(speed.RPM()))
 }
 
 // calculateEngineEfficiency calculates an approximate efficiency
@@ -215,12 +441,15 @@ func (m *Engine) GetData() Telemetry {
 	powerHP := m.calculatePowerHp()
 
 	return Telemetry{
-		RPM:                 m.Rpm,
+		RPM:                 m.rpm,
 		Torque:              m.torque,
 		OilTemp:             m.oilTemp,
+		CoolantTemp:         m.waterTemp,
+		Load:                m.calculateLoad(),
 		AcceleratorPosition: m.acceleratorPos,
 		PowerKW:             powerKW,
 		PowerHP:             powerHP,
+		FuelRateLH:          m.fuelConsumption,
 		EngineState:         m.getState(),
 	}
 
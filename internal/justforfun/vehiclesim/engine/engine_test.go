@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"go-playground/internal/justforfun/vehiclesim/units"
+	"math"
+	"testing"
+)
+
+// TestEnginePower checks the reported power is the torque times the angular velocity over the whole map,
+// and that the curve never gives more torque with less throttle
+func TestEnginePower(t *testing.T) {
+	m := NewEngine()
+	m.SetSeed(1)
+
+	for rpm := float64(IdleRPM); rpm <= m.maxRPM; rpm += 250 {
+		previous := -1.0
+		for _, throttle := range []float64{0, 0.25, 0.5, 0.75, 1} {
+			m.SetAcceleratorPos(throttle)
+			m.rpm = rpm
+			m.UpdateTorque()
+			data := m.GetData()
+
+			want := data.Torque * rpm * 2 * math.Pi / 60
+			if math.Abs(data.PowerKW*1000-want) > 1e-9*math.Max(1, want) {
+				t.Errorf("%.0f rpm, throttle %.2f: %.3f kW, want torque × ω = %.3f kW", rpm, throttle, data.PowerKW, want/1000)
+			}
+			if math.Abs(data.PowerHP*745.69987158227022-data.PowerKW*1000) > 1e-6 {
+				t.Errorf("%.0f rpm, throttle %.2f: %.3f hp is not %.3f kW", rpm, throttle, data.PowerHP, data.PowerKW)
+			}
+			if m.GetPower() != units.PowerOf(units.NewtonMeters.Of(data.Torque), units.RPM.Of(rpm)) {
+				t.Errorf("%.0f rpm, throttle %.2f: GetPower %s does not match the telemetry", rpm, throttle, m.GetPower())
+			}
+
+			if curve := m.CurveTorque(units.RPM.Of(rpm)).NewtonMeters(); curve < previous {
+				t.Errorf("%.0f rpm: %.1f Nm at throttle %.2f, below the %.1f Nm of less throttle", rpm, curve, throttle, previous)
+			}
+			previous = m.CurveTorque(units.RPM.Of(rpm)).NewtonMeters()
+		}
+	}
+}
+
+// TestEngineTorqueLimit checks a driver aid cuts the combustion torque in proportion at once
+func TestEngineTorqueLimit(t *testing.T) {
+	full, cut := NewEngine(), NewEngine()
+	full.SetSeed(1)
+	cut.SetSeed(1)
+	cut.SetTorqueLimit(0.4)
+	for _, m := range []*Engine{full, cut} {
+		m.SetAcceleratorPos(1)
+		m.rpm = 3500
+		m.UpdateTorque()
+	}
+	if math.Abs(cut.GetTorque().NewtonMeters()-0.4*full.GetTorque().NewtonMeters()) > 1e-9 {
+		t.Errorf("torque limit 0.4: %s, want 40%% of %s", cut.GetTorque(), full.GetTorque())
+	}
+}
+
+// TestEngineClutchCoupling checks the engaged clutch drags the engine to the speed of the wheels, passing
+// the energy of the slowing flywheel on as torque, and leaves it free when the tires do not hold it
+func TestEngineClutchCoupling(t *testing.T) {
+	for _, locked := range []bool{true, false} {
+		m := NewEngine()
+		m.SetSeed(1)
+		m.SetAcceleratorPos(0.6)
+		m.rpm = 5000
+		m.SetDrivelineSpeed(units.RPM.Of(2000), locked)
+
+		m.Update(1, 0.1)
+		if locked && (m.GetClutchTorque() <= m.GetTorque() || m.GetClutchTorque() > units.NewtonMeters.Of(675)) {
+			t.Errorf("clutch slowing the engine: %s at the clutch from %s of combustion, want more up to the 675 Nm the clutch holds",
+				m.GetClutchTorque(), m.GetTorque())
+		}
+		if !locked && m.GetClutchTorque() != m.GetTorque() {
+			t.Errorf("tires spinning: %s at the clutch, want the %s of combustion", m.GetClutchTorque(), m.GetTorque())
+		}
+
+		for i := 0; i < 30; i++ {
+			m.Update(1, 0.1)
+		}
+		if locked && math.Abs(m.GetSpeed().RPM()-2000) > 300 {
+			t.Errorf("clutch engaged: engine at %s, want close to the 2000 rpm of the wheels", m.GetSpeed())
+		}
+		if !locked && m.GetSpeed() < units.RPM.Of(4000) {
+			t.Errorf("tires spinning: engine dragged down to %s", m.GetSpeed())
+		}
+	}
+
+	// With the clutch pressed the wheels do not reach the engine
+	m := NewEngine()
+	m.SetSeed(1)
+	m.SetAcceleratorPos(0.6)
+	m.rpm = 5000
+	m.SetDrivelineSpeed(units.RPM.Of(2000), true)
+	m.Update(0, 0.1)
+	if m.GetClutchTorque() != m.GetTorque() {
+		t.Errorf("clutch pressed: %s at the clutch, want the %s of combustion", m.GetClutchTorque(), m.GetTorque())
+	}
+}
+
+// TestClutchLockFraction checks the clutch closes the same speed difference over a second at any step size
+func TestClutchLockFraction(t *testing.T) {
+	if got := clutchLockFraction(1, 0.1); math.Abs(got-0.5) > 1e-12 {
+		t.Errorf("fully engaged for 0.1 s: %.4f of the difference closed, want half", got)
+	}
+	want := 1 - clutchLockFraction(0.8, 1)
+	for _, stepSize := range []float64{0.01, 0.1, 0.25} {
+		left := 1.0
+		for i := 0; i < int(math.Round(1/stepSize)); i++ {
+			left *= 1 - clutchLockFraction(0.8, stepSize)
+		}
+		if math.Abs(left-want) > 1e-12 {
+			t.Errorf("%g s steps: %.6f of the difference left after a second, want %.6f", stepSize, left, want)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package engine
+
+import "math"
+
+const (
+	// brakeSpecificFuelConsumption of a petrol engine around its best efficiency in g/kWh
+	brakeSpecificFuelConsumption = 260.0
+	// idleFuelFlow keeps the engine running without load in g/s
+	idleFuelFlow = 0.25
+	// fuelDensity of petrol in g/L
+	fuelDensity = 745.0
+	// overrunCutRPM is the speed above which the ECU cuts fuel when the throttle is closed
+	overrunCutRPM = 1200.0
+)
+
+// updateFuel integrates the fuel burnt from the power produced
+func (m *Engine) updateFuel(deltaTime float64) {
+	flowGS := idleFuelFlow + math.Max(0, m.calculatePowerKw())*brakeSpecificFuelConsumption/3600
+
+	// Closed throttle on the overrun or a rev limiter cut inject no fuel
+	if (m.effectiveThrottle() == 0 && m.rpm > overrunCutRPM) || m.combustionFactor == 0 {
+		flowGS = 0
+	}
+
+	m.fuelConsumption = flowGS / fuelDensity * 3600
+	m.fuelUsed += flowGS / fuelDensity * deltaTime
+}
+
+// GetFuelRate returns the instantaneous fuel consumption in L/h
+func (m *Engine) GetFuelRate() float64 {
+	return m.fuelConsumption
+}
+
+// GetFuelUsed returns the fuel burnt since the engine was created in liters
+func (m *Engine) GetFuelUsed() float64 {
+	return m.fuelUsed
+}
@@ -0,0 +1,125 @@
+package engine
+
+import "math"
+
+// LaunchState is the phase of the launch control sequence
+type LaunchState int
+
+const (
+	LaunchOff LaunchState = iota
+	// LaunchArmed waits for the driver to press the clutch with the throttle floored
+	LaunchArmed
+	// LaunchHolding keeps the engine at the launch RPM while the clutch is pressed
+	LaunchHolding
+	// LaunchReleasing ramps the torque back after the clutch is released and cuts it on wheelspin
+	LaunchReleasing
+)
+
+func (s LaunchState) String() string {
+	switch s {
+	case LaunchArmed:
+		return "armed"
+	case LaunchHolding:
+		return "holding"
+	case LaunchReleasing:
+		return "releasing"
+	default:
+		return "off"
+	}
+}
+
+// LaunchControl holds a target RPM with the clutch in and manages torque on launch
+type LaunchControl struct {
+	TargetRPM      float64 // RPM held while the clutch is pressed
+	MinThrottle    float64 // Throttle position needed to start holding
+	LaunchTorque   float64 // Fraction of torque allowed right after the clutch is released
+	TorqueRampTime float64 // Seconds to ramp from LaunchTorque back to full torque
+	TargetSlip     float64 // Wheel slip allowed while releasing, the torque is cut above it
+	SlipTorqueCut  float64 // Fraction of torque cut per unit of slip above TargetSlip
+
+	state   LaunchState
+	elapsed float64
+}
+
+// NewLaunchControl creates a disarmed launch control with typical calibration values
+func NewLaunchControl(targetRPM float64) *LaunchControl {
+	return &LaunchControl{
+		TargetRPM:      targetRPM,
+		MinThrottle:    0.9,
+		LaunchTorque:   0.6,
+		TorqueRampTime: 1.5,
+		TargetSlip:     0.15,
+		SlipTorqueCut:  1,
+		state:          LaunchOff,
+	}
+}
+
+// Arm enables launch control for the next standing start
+func (lc *LaunchControl) Arm() {
+	if lc.state == LaunchOff {
+		lc.state = LaunchArmed
+	}
+}
+
+// Disarm cancels launch control whatever the current phase is
+func (lc *LaunchControl) Disarm() {
+	lc.state = LaunchOff
+	lc.elapsed = 0
+}
+
+// State returns the current launch phase
+func (lc *LaunchControl) State() LaunchState {
+	return lc.state
+}
+
+// IsActive reports whether launch control is currently overriding the engine
+func (lc *LaunchControl) IsActive() bool {
+	return lc.state == LaunchHolding || lc.state == LaunchReleasing
+}
+
+// update advances the launch sequence
+// Parameters:
+//
+//	clutchPosition: posición del clutch (0.0 = disengaged, 1.0 = engaged)
+//	acceleratorPos: posición del acelerador (0.0 to 1.0)
+//	deltaTime: tiempo transcurrido en segundos
+func (lc *LaunchControl) update(clutchPosition float64, acceleratorPos float64, deltaTime float64) {
+	switch lc.state {
+	case LaunchArmed:
+		if clutchPosition < 0.1 && acceleratorPos >= lc.MinThrottle {
+			lc.state = LaunchHolding
+		}
+
+	case LaunchHolding:
+		if acceleratorPos < lc.MinThrottle {
+			// Driver lifted before launching, wait for a new attempt
+			lc.state = LaunchArmed
+		} else if clutchPosition > 0.9 {
+			lc.state = LaunchReleasing
+			lc.elapsed = 0
+		}
+
+	case LaunchReleasing:
+		lc.elapsed += deltaTime
+		if lc.elapsed >= lc.TorqueRampTime || acceleratorPos < 0.5 {
+			lc.Disarm()
+		}
+	}
+}
+
+// torqueFactor returns the fraction of torque allowed during the launch ramp, lowered further
+// when the measured slip of the driven wheels goes above TargetSlip
+func (lc *LaunchControl) torqueFactor(slip float64) float64 {
+	if lc.state != LaunchReleasing {
+		return 1
+	}
+	factor := 1.0
+	if lc.TorqueRampTime > 0 {
+		progress := math.Min(1, lc.elapsed/lc.TorqueRampTime)
+		factor = lc.LaunchTorque + (1-lc.LaunchTorque)*progress
+	}
+	if excess := slip - lc.TargetSlip; excess > 0 {
+		factor *= math.Max(0, 1-lc.SlipTorqueCut*excess)
+	}
+	return factor
+}
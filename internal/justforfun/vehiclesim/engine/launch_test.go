@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"math"
+	"testing"
+)
+
+// TestLaunchControl goes through a launch: armed, holding with the clutch pressed, then the torque ramp
+func TestLaunchControl(t *testing.T) {
+	lc := NewLaunchControl(4000)
+	lc.update(0, 1, 0.1)
+	if lc.State() != LaunchOff {
+		t.Fatalf("disarmed launch control is %s, want off", lc.State())
+	}
+
+	lc.Arm()
+	lc.update(0, 0.5, 0.1)
+	if lc.State() != LaunchArmed {
+		t.Fatalf("half throttle: %s, want armed", lc.State())
+	}
+	lc.update(0, 1, 0.1)
+	if lc.State() != LaunchHolding || !lc.IsActive() {
+		t.Fatalf("clutch pressed at full throttle: %s, want holding", lc.State())
+	}
+	lc.update(0, 0.5, 0.1)
+	if lc.State() != LaunchArmed {
+		t.Fatalf("lifting while holding: %s, want armed", lc.State())
+	}
+
+	lc.update(0, 1, 0.1)
+	lc.update(1, 1, 0.1)
+	if lc.State() != LaunchReleasing || lc.torqueFactor(0) != lc.LaunchTorque {
+		t.Fatalf("clutch released: %s with torque factor %.2f, want releasing at %.2f", lc.State(), lc.torqueFactor(0), lc.LaunchTorque)
+	}
+	// Wheelspin cuts the torque in proportion to the slip above the target
+	if got, want := lc.torqueFactor(lc.TargetSlip+0.1), lc.LaunchTorque*(1-0.1*lc.SlipTorqueCut); math.Abs(got-want) > 1e-9 {
+		t.Errorf("slip 0.1 above the target: torque factor %.3f, want %.3f", got, want)
+	}
+	if got := lc.torqueFactor(lc.TargetSlip + 2/lc.SlipTorqueCut); got != 0 {
+		t.Errorf("wheels spinning: torque factor %.3f, want 0", got)
+	}
+	previous := lc.torqueFactor(0)
+	for i := 0; i < 14; i++ {
+		lc.update(1, 1, 0.1)
+		if factor := lc.torqueFactor(0); factor <= previous || factor > 1 {
+			t.Fatalf("%.1f s after the launch: torque factor %.3f after %.3f, want a ramp up to 1", lc.elapsed, factor, previous)
+		}
+		previous = lc.torqueFactor(0)
+	}
+	lc.update(1, 1, 0.1)
+	if lc.State() != LaunchOff || lc.torqueFactor(1) != 1 {
+		t.Errorf("after the %.1f s ramp: %s with torque factor %.2f, want off at full torque", lc.TorqueRampTime, lc.State(), lc.torqueFactor(1))
+	}
+}
+
+// TestLaunchControlHoldsRPM keeps the engine at the launch RPM while the clutch is pressed at full throttle
+func TestLaunchControlHoldsRPM(t *testing.T) {
+	m := NewEngine()
+	m.SetSeed(1)
+	m.GetLaunchControl().Arm()
+	m.SetAcceleratorPos(1)
+
+	minRPM, maxRPM := math.Inf(1), 0.0
+	for i := 0; i < 300; i++ {
+		m.Update(0, 0.1)
+		if i >= 100 {
+			minRPM, maxRPM = math.Min(minRPM, m.GetSpeed().RPM()), math.Max(maxRPM, m.GetSpeed().RPM())
+		}
+	}
+	if minRPM < 3500 || maxRPM > 4200 {
+		t.Errorf("holding between %.0f and %.0f rpm, want about 4000 rpm", minRPM, maxRPM)
+	}
+	if state := m.GetData().EngineState; state != "launch_control" {
+		t.Errorf("engine state %s, want launch_control", state)
+	}
+}
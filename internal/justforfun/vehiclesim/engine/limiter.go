@@ -0,0 +1,112 @@
+package engine
+
+import "math"
+
+// RevLimiterStrategy selects how the engine ECU cuts power when the RPM limit is reached
+type RevLimiterStrategy int
+
+const (
+	// HardCut shuts off fuel at the limit and restores it once RPM falls below the hysteresis band
+	HardCut RevLimiterStrategy = iota
+	// SoftCut retards ignition progressively inside a window before the limit
+	SoftCut
+	// RollingCut cuts cylinders one by one as the RPM overshoots the limit
+	RollingCut
+)
+
+func (s RevLimiterStrategy) String() string {
+	switch s {
+	case HardCut:
+		return "hard_cut"
+	case SoftCut:
+		return "soft_cut"
+	case RollingCut:
+		return "rolling_cut"
+	default:
+		return "unknown"
+	}
+}
+
+// RevLimiter models the ECU rev limiter. Each update returns the fraction of
+// combustion torque (0.0 to 1.0) that the engine is allowed to produce.
+type RevLimiter struct {
+	Strategy   RevLimiterStrategy
+	LimitRPM   float64
+	Hysteresis float64 // RPM below the limit where a hard cut restores fuel
+	SoftWindow float64 // RPM range before the limit where ignition is retarded
+	CutWindow  float64 // RPM overshoot needed to cut every cylinder in a rolling cut
+	Cylinders  int
+
+	fuelCut bool
+	factor  float64
+}
+
+// NewRevLimiter creates a rev limiter with typical calibration values for the given strategy
+func NewRevLimiter(strategy RevLimiterStrategy, limitRPM float64) *RevLimiter {
+	return &RevLimiter{
+		Strategy:   strategy,
+		LimitRPM:   limitRPM,
+		Hysteresis: 250,
+		SoftWindow: 300,
+		CutWindow:  200,
+		Cylinders:  6,
+		factor:     1,
+	}
+}
+
+// Apply evaluates the limiter at the given RPM against limitRPM and returns the combustion factor.
+// limitRPM is passed explicitly so launch control can reuse the same strategy with a lower limit.
+func (l *RevLimiter) Apply(rpm float64, limitRPM float64) float64 {
+	switch l.Strategy {
+	case SoftCut:
+		l.factor = l.softCut(rpm, limitRPM)
+	case RollingCut:
+		l.factor = l.rollingCut(rpm, limitRPM)
+	default:
+		l.factor = l.hardCut(rpm, limitRPM)
+	}
+	return l.factor
+}
+
+// IsCutting reports whether the last evaluation removed any combustion torque
+func (l *RevLimiter) IsCutting() bool {
+	return l.factor < 1
+}
+
+// hardCut keeps fuel off between the limit and the hysteresis band, which makes the RPM bounce
+func (l *RevLimiter) hardCut(rpm float64, limitRPM float64) float64 {
+	switch {
+	case rpm >= limitRPM:
+		l.fuelCut = true
+	case rpm < limitRPM-l.Hysteresis:
+		l.fuelCut = false
+	}
+
+	if l.fuelCut {
+		return 0
+	}
+	return 1
+}
+
+// softCut fades the torque linearly across the window before the limit
+func (l *RevLimiter) softCut(rpm float64, limitRPM float64) float64 {
+	windowStart := limitRPM - l.SoftWindow
+	if rpm <= windowStart {
+		return 1
+	}
+	return math.Max(0, 1-(rpm-windowStart)/l.SoftWindow)
+}
+
+// rollingCut removes whole cylinders proportionally to the overshoot above the limit
+func (l *RevLimiter) rollingCut(rpm float64, limitRPM float64) float64 {
+	if rpm < limitRPM || l.Cylinders <= 0 {
+		return 1
+	}
+
+	overshoot := (rpm - limitRPM) / l.CutWindow
+	cutCylinders := math.Min(float64(l.Cylinders), math.Ceil(overshoot*float64(l.Cylinders)))
+	// Always cut at least one cylinder once the limit is reached
+	cutCylinders = math.Max(1, cutCylinders)
+
+	return 1 - cutCylinders/float64(l.Cylinders)
+}
@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"math"
+	"testing"
+)
+
+// TestRevLimiter checks the combustion factor each strategy allows around the limit
+func TestRevLimiter(t *testing.T) {
+	tests := []struct {
+		strategy RevLimiterStrategy
+		rpm      []float64 // Evaluated in order, the hard cut remembers the cut
+		want     []float64
+	}{
+		// Fuel stays off inside the hysteresis band on the way down
+		{HardCut, []float64{7900, 8200, 8100, 7960, 7940}, []float64{1, 0, 0, 0, 1}},
+		// Ignition is retarded linearly across the 300 rpm window
+		{SoftCut, []float64{7900, 8050, 8200, 8400}, []float64{1, 0.5, 0, 0}},
+		// One of the six cylinders at the limit, all of them 200 rpm above
+		{RollingCut, []float64{8199, 8200, 8300, 8400}, []float64{1, 5.0 / 6, 0.5, 0}},
+	}
+	for _, tt := range tests {
+		limiter := NewRevLimiter(tt.strategy, 8200)
+		for i, rpm := range tt.rpm {
+			got := limiter.Apply(rpm, limiter.LimitRPM)
+			if math.Abs(got-tt.want[i]) > 1e-9 {
+				t.Errorf("%s at %.0f rpm: factor %.3f, want %.3f", tt.strategy, rpm, got, tt.want[i])
+			}
+			if limiter.IsCutting() != (tt.want[i] < 1) {
+				t.Errorf("%s at %.0f rpm: IsCutting = %v with factor %.3f", tt.strategy, rpm, limiter.IsCutting(), got)
+			}
+		}
+	}
+}
+
+// TestRevLimiterHoldsEngine floors the throttle of the unloaded engine, each strategy keeps it near the limit
+func TestRevLimiterHoldsEngine(t *testing.T) {
+	for _, strategy := range []RevLimiterStrategy{HardCut, SoftCut, RollingCut} {
+		m := NewEngine()
+		m.SetSeed(1)
+		m.SetRevLimiter(NewRevLimiter(strategy, 7000))
+		m.SetAcceleratorPos(1)
+
+		maxRPM := 0.0
+		for i := 0; i < 600; i++ {
+			m.Update(1, 0.1)
+			maxRPM = math.Max(maxRPM, m.GetSpeed().RPM())
+		}
+		if maxRPM > 7100 || maxRPM < 6500 {
+			t.Errorf("%s: engine peaked at %.0f rpm with a 7000 rpm limit", strategy, maxRPM)
+		}
+	}
+}
@@ -2,27 +2,32 @@ package engine
 
 import "fmt"
 
+// Telemetry is the engine state as plain numbers for the sinks, in the units of the telemetry
+// schema: rpm, Nm, °C, kW and hp. The typed quantities stay in the Engine methods.
 type Telemetry struct {
 	RPM                 float64
 	Torque              float64
 	OilTemp             float64
+	CoolantTemp         float64
+	Load                float64 // Torque as a share of the full throttle torque at the current RPM (0.0 to 1.0)
 	AcceleratorPosition float64
 	PowerKW             float64
 	PowerHP             float64
+	FuelRateLH          float64
 	EngineState         string
 }
 
 // String implements the String interface for human-readable formatting
 func (d Telemetry) String() string {
 	return fmt.Sprintf(
-		"Engine [Speed: %.0f, AcelPos: %.1f %s, torque: %.1f Nm, OilTemp: %.1f°C, Power: %.1f kW, Power: %.1f HP, State: %s]\n",
+		"Engine [Speed: %.0f, AcelPos: %.1f %%, torque: %.1f Nm, OilTemp: %.1f°C, Power: %.1f kW, Power: %.1f HP, Fuel: %.1f L/h, State: %s]\n",
 		d.RPM,
 		d.getAcceleratorPositionPercentile(),
-		" %%",
 		d.Torque,
 		d.OilTemp,
 		d.PowerKW,
 		d.PowerHP,
+		d.FuelRateLH,
 		d.EngineState,
 	)
 }
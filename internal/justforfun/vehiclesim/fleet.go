@@ -0,0 +1,199 @@
+package vehiclesim
+
+import (
+	"context"
+	"fmt"
+	"go-playground/internal/justforfun/vehiclesim/engine"
+	"go-playground/internal/justforfun/vehiclesim/influx"
+	"math"
+	"math/rand"
+	"os"
+	"os/signal"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// FleetConfig sets the size and pace of a fleet simulation
+type FleetConfig struct {
+	Vehicles  int           // Number of simulated vehicles
+	Workers   int           // Goroutines stepping vehicles, bounds the CPU usage (0 = number of CPUs)
+	TimeScale float64       // Simulated seconds per wall clock second (0 = as fast as possible)
+	Duration  time.Duration // Simulated time to run (0 = until interrupted)
+	Seed      int64         // Base seed, vehicle i uses Seed+i
+	StepSize  float64       // Simulated seconds per step (0 = 0.1)
+}
+
+// FleetStats summarizes a fleet run
+type FleetStats struct {
+	Steps       int           // Simulation steps of every vehicle
+	Snapshots   int64         // Snapshots handed to the sink
+	WriteErrors int64         // Snapshots the sink failed to write
+	WallTime    time.Duration // Real time spent
+}
+
+// String implements the String interface for human-readable formatting
+func (s FleetStats) String() string {
+	return fmt.Sprintf("Fleet [Steps: %d, Snapshots: %d, WriteErrors: %d, WallTime: %s]\n",
+		s.Steps,
+		s.Snapshots,
+		s.WriteErrors,
+		s.WallTime.Round(time.Millisecond))
+}
+
+// fleetSpecs are the vehicle variants of the fleet, assigned round robin
+var fleetSpecs = []VehicleSpec{
+	DefaultVehicleSpec(),
+	{TireSpec: "225/45R17", DifferentialRatio: 3.46, MassKg: 1420, RevLimiter: engine.SoftCut, LimitRPM: 7800, TractionControl: true},
+	{TireSpec: "275/35R20", DifferentialRatio: 4.10, MassKg: 1700, RevLimiter: engine.RollingCut, LimitRPM: 8200, TractionControl: false},
+}
+
+// fleetProfiles are the driver profiles of the fleet, combined with every spec
+var fleetProfiles = []func() DriverProfile{NormalProfile, CalmProfile, SportyProfile}
+
+// fleetMember is a vehicle of the fleet with its driver
+type fleetMember struct {
+	vehicle *Vehicle
+	driver  Driver
+}
+
+// FleetSimulation runs the fleet against InfluxDB until the duration elapses or Ctrl+C
+func FleetSimulation(config FleetConfig) {
+	fmt.Printf("Starting fleet simulation with %d vehicles\n", config.Vehicles)
+
+	sink := NewInfluxSink(influx.ConfigInfluxDB{
+		Org:    "docs",
+		Bucket: "vehicle-simulation",
+	})
+	defer sink.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	stats, err := RunFleet(ctx, config, sink)
+	if err != nil {
+		panic(fmt.Sprintf("Error running fleet: %v", err))
+	}
+	fmt.Print(stats.String())
+}
+
+// RunFleet steps every vehicle of the fleet in lockstep and writes their snapshots to the sink
+// Each step is split across a pool of workers, so the vehicles never run more than one step apart
+func RunFleet(ctx context.Context, config FleetConfig, sink TelemetrySink) (FleetStats, error) {
+	if config.Vehicles <= 0 {
+		return FleetStats{}, fmt.Errorf("fleet needs at least one vehicle, got %d", config.Vehicles)
+	}
+	if config.Workers <= 0 {
+		config.Workers = runtime.NumCPU()
+	}
+	if config.StepSize < 0 {
+		return FleetStats{}, fmt.Errorf("fleet step size must be positive, got %g s", config.StepSize)
+	}
+	if config.StepSize == 0 {
+		config.StepSize = 0.1
+	}
+	if config.Duration < 0 || (config.Duration > 0 && config.Duration.Seconds() < config.StepSize) {
+		return FleetStats{}, fmt.Errorf("fleet duration %s is shorter than a %g s step", config.Duration, config.StepSize)
+	}
+
+	members, err := newFleet(config)
+	if err != nil {
+		return FleetStats{}, err
+	}
+
+	var stats FleetStats
+	var snapshots, writeErrors atomic.Int64
+	var wg sync.WaitGroup
+
+	jobs := make(chan *fleetMember)
+	defer close(jobs)
+	for w := 0; w < config.Workers; w++ {
+		go func() {
+			for member := range jobs {
+				member.driver.Drive(member.vehicle, config.StepSize)
+				snapshot := member.vehicle.Step(config.StepSize)
+				if err := sink.Write(snapshot); err != nil {
+					writeErrors.Add(1)
+				}
+				snapshots.Add(1)
+				wg.Done()
+			}
+		}()
+	}
+
+	// Pace the steps when a time scale is set
+	var pace <-chan time.Time
+	if config.TimeScale > 0 {
+		ticker := time.NewTicker(time.Duration(config.StepSize / config.TimeScale * float64(time.Second)))
+		defer ticker.Stop()
+		pace = ticker.C
+	}
+
+	totalSteps := 0 // Until interrupted
+	if config.Duration > 0 {
+		// Rounded, the quotient of decimal steps falls just short of whole numbers: 0.3/0.1 is 2.9999…
+		totalSteps = max(1, int(math.Round(config.Duration.Seconds()/config.StepSize)))
+	}
+	stepsPerReport := max(1, int(math.Round(10/config.StepSize))) // Progress every 10 simulated seconds
+	started := time.Now()
+
+	for totalSteps == 0 || stats.Steps < totalSteps {
+		if pace != nil {
+			select {
+			case <-ctx.Done():
+			case <-pace:
+			}
+		}
+		if ctx.Err() != nil {
+			fmt.Println("Stopping fleet simulation")
+			break
+		}
+
+		wg.Add(len(members))
+		for _, member := range members {
+			jobs <- member
+		}
+		wg.Wait()
+		stats.Steps++
+
+		if stats.Steps%stepsPerReport == 0 {
+			fmt.Printf("Fleet at %.0f s: %d snapshots, %d write errors\n", float64(stats.Steps)*config.StepSize, snapshots.Load(), writeErrors.Load())
+		}
+	}
+
+	stats.Snapshots = snapshots.Load()
+	stats.WriteErrors = writeErrors.Load()
+	stats.WallTime = time.Since(started)
+	return stats, nil
+}
+
+// newFleet builds the vehicles, combining specs and driver profiles, with a seeded start delay
+// so the fleet does not move in lockstep
+func newFleet(config FleetConfig) ([]*fleetMember, error) {
+	theRoute, err := loadRoute()
+	if err != nil {
+		return nil, fmt.Errorf("error loading route: %v", err)
+	}
+	start, heading := loadStartPosition()
+	startTime := time.Now()
+
+	members := make([]*fleetMember, 0, config.Vehicles)
+	for i := 0; i < config.Vehicles; i++ {
+		seed := config.Seed + int64(i)
+		spec := fleetSpecs[i%len(fleetSpecs)]
+		profile := fleetProfiles[(i/len(fleetSpecs))%len(fleetProfiles)]()
+
+		vehicle, err := NewVehicle(fmt.Sprintf("vehicle-%03d", i+1), spec, seed, theRoute, start, heading, startTime)
+		if err != nil {
+			return nil, err
+		}
+
+		driver := NewScriptedDriver(profile)
+		driver.StartDelay = rand.New(rand.NewSource(seed)).Float64() * 10
+
+		members = append(members, &fleetMember{vehicle: vehicle, driver: driver})
+	}
+	return members, nil
+}
@@ -0,0 +1,91 @@
+package vehiclesim
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memorySink keeps the last snapshot of each vehicle
+type memorySink struct {
+	mu    sync.Mutex
+	last  map[string]Snapshot
+	count int
+}
+
+func newMemorySink() *memorySink {
+	return &memorySink{last: map[string]Snapshot{}}
+}
+
+func (s *memorySink) Write(snapshot Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last[snapshot.VehicleID] = snapshot
+	s.count++
+	return nil
+}
+
+func (s *memorySink) Close() error {
+	return nil
+}
+
+// TestRunFleet checks every vehicle writes one tagged snapshot per step
+// and the same seed reproduces the same run whatever the number of workers
+func TestRunFleet(t *testing.T) {
+	config := FleetConfig{Vehicles: 6, Workers: 3, Duration: 60 * time.Second, Seed: 42}
+
+	first := newMemorySink()
+	stats, err := RunFleet(context.Background(), config, first)
+	if err != nil {
+		t.Fatalf("RunFleet: %v", err)
+	}
+	if stats.Steps != 600 || first.count != 600*config.Vehicles {
+		t.Fatalf("got %d steps and %d snapshots, want 600 and %d", stats.Steps, first.count, 600*config.Vehicles)
+	}
+	if len(first.last) != config.Vehicles {
+		t.Fatalf("got %d vehicle IDs, want %d", len(first.last), config.Vehicles)
+	}
+
+	config.Workers = 1
+	second := newMemorySink()
+	if _, err := RunFleet(context.Background(), config, second); err != nil {
+		t.Fatalf("RunFleet: %v", err)
+	}
+	for id, want := range first.last {
+		got := second.last[id]
+		if got.Engine.RPM != want.Engine.RPM || got.Route.DistanceM != want.Route.DistanceM {
+			t.Errorf("%s: got %.1f rpm at %.1f m, want %.1f rpm at %.1f m", id, got.Engine.RPM, got.Route.DistanceM, want.Engine.RPM, want.Route.DistanceM)
+		}
+	}
+}
+
+// TestRunFleetStepSize checks steps longer than the duration or the report interval still end the run,
+// and rejects a configuration that cannot take a single step
+func TestRunFleetStepSize(t *testing.T) {
+	for _, tt := range []struct {
+		duration  time.Duration
+		stepSize  float64
+		wantSteps int
+	}{
+		{30 * time.Second, 15, 2},
+		{1400 * time.Millisecond, 1, 1},
+		{300 * time.Millisecond, 0.1, 3},
+	} {
+		config := FleetConfig{Vehicles: 1, Duration: tt.duration, StepSize: tt.stepSize, Seed: 42}
+		stats, err := RunFleet(context.Background(), config, newMemorySink())
+		if err != nil || stats.Steps != tt.wantSteps {
+			t.Errorf("%s in %g s steps: %d steps, %v, want %d", tt.duration, tt.stepSize, stats.Steps, err, tt.wantSteps)
+		}
+	}
+
+	for _, config := range []FleetConfig{
+		{Vehicles: 1, Duration: 50 * time.Millisecond},
+		{Vehicles: 1, Duration: time.Second, StepSize: -0.1},
+		{Vehicles: 1, Duration: -time.Second},
+	} {
+		if _, err := RunFleet(context.Background(), config, newMemorySink()); err == nil {
+			t.Errorf("%s in %g s steps: no error", config.Duration, config.StepSize)
+		}
+	}
+}
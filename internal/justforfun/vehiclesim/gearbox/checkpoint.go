@@ -0,0 +1,71 @@
+package gearbox
+
+// Checkpoint is the state of the gearbox needed to resume a simulation
+type Checkpoint struct {
+	CurrentGear             int
+	ClutchPosition          float64
+	InputShaft              float64
+	InputShaftTorque        float64
+	OutputShaft             float64
+	OutputShaftTorque       float64
+	InputShaftAcceleration  float64
+	OutputShaftAcceleration float64
+}
+
+// Checkpoint returns the current state of the gearbox
+func (g *ManualGearbox) Checkpoint() Checkpoint {
+	return Checkpoint{
+		CurrentGear:             g.currentGear,
+		ClutchPosition:          g.ClutchPosition,
+		InputShaft:              g.InputShaft,
+		InputShaftTorque:        g.InputShaftTorque,
+		OutputShaft:             g.OutputShaft,
+		OutputShaftTorque:       g.OutputShaftTorque,
+		InputShaftAcceleration:  g.inputShaftAcceleration,
+		OutputShaftAcceleration: g.outputShaftAcceleration,
+	}
+}
+
+// Restore sets the gearbox back to a saved state
+func (g *ManualGearbox) Restore(c Checkpoint) {
+	g.currentGear = c.CurrentGear
+	g.ClutchPosition = c.ClutchPosition
+	g.InputShaft = c.InputShaft
+	g.InputShaftTorque = c.InputShaftTorque
+	g.OutputShaft = c.OutputShaft
+	g.OutputShaftTorque = c.OutputShaftTorque
+	g.inputShaftAcceleration = c.InputShaftAcceleration
+	g.outputShaftAcceleration = c.OutputShaftAcceleration
+}
+
+// MonitorCheckpoint is the state of a shift being measured by the ShiftMonitor
+type MonitorCheckpoint struct {
+	InShift     bool
+	Current     ShiftEvent
+	Biting      bool
+	PreTorque   float64
+	Previous    ShiftSample
+	HasPrevious bool
+}
+
+// Checkpoint returns the shift in progress
+func (m *ShiftMonitor) Checkpoint() MonitorCheckpoint {
+	return MonitorCheckpoint{
+		InShift:     m.inShift,
+		Current:     m.current,
+		Biting:      m.biting,
+		PreTorque:   m.preTorque,
+		Previous:    m.previous,
+		HasPrevious: m.hasPrevious,
+	}
+}
+
+// Restore sets the monitor back to a saved state
+func (m *ShiftMonitor) Restore(c MonitorCheckpoint) {
+	m.inShift = c.InShift
+	m.current = c.Current
+	m.biting = c.Biting
+	m.preTorque = c.PreTorque
+	m.previous = c.Previous
+	m.hasPrevious = c.HasPrevious
+}
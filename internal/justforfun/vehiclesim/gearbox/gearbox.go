@@ -1,6 +1,9 @@
 package gearbox
 
-import "math"
+import (
+	"go-playground/internal/justforfun/vehiclesim/units"
+	"math"
+)
 
 // Gearbox define la interfaz para cualquier sistema de caja de cambios
 // Permite que diferentes tipos de cajas (manual, automática, CVT, etc.)
@@ -8,20 +11,20 @@ import "math"
 type Gearbox interface {
 	// Update procesa entrada de motor (RPM/Torque) y calcula salida a las ruedas
 	// Parámetros:
-	//   inputRPM: revoluciones por minuto del eje de entrada (desde motor)
-	//   inputTorque: torque en Nm del eje de entrada (desde motor)
+	//   inputSpeed: velocidad del eje de entrada (desde motor)
+	//   inputTorque: torque del eje de entrada (desde motor)
 	//   deltaTime: tiempo transcurrido en segundos desde la última actualización
-	Update(inputRPM float64, inputTorque float64, deltaTime float64)
+	Update(inputSpeed units.AngularVelocity, inputTorque units.Torque, deltaTime float64)
 
 	// GetData retorna telemetría completa de la caja de cambios
 	// Cada implementación retorna su propio tipo concreto (Telemetry, etc.)
 	GetData() interface{}
 
-	// GetOutputShaft retorna RPM en eje de salida (hacia diferenciales/ruedas)
-	GetOutputShaft() float64
+	// GetOutputShaft retorna la velocidad del eje de salida (hacia diferenciales/ruedas)
+	GetOutputShaft() units.AngularVelocity
 
-	// GetOutputTorque retorna torque en Nm en eje de salida
-	GetOutputTorque() float64
+	// GetOutputTorque retorna el torque en eje de salida
+	GetOutputTorque() units.Torque
 
 	// SetClutch establece posición del clutch entre 0.0 (disengaged) y 1.0 (engaged)
 	SetClutch(position float64)
@@ -49,7 +52,6 @@ type ManualGearbox struct {
 	currentGear    int
 	maxGears       int
 	gearRatios     []float64
-	finalDrive     float64
 	ClutchPosition float64 // 0.0 = clutch disengaged, 1.0 = clutch engaged
 
 	InputShaft        float64
@@ -84,7 +86,6 @@ func NewManualGearbox() Gearbox {
 			0.755, // 6
 			0.635, // 7
 		},
-		finalDrive: 4.471, // Final differential ratio
 		// Initialization of inertias with typical values
 		inputShaftInertia: 0.1, // kg·m²
 		gearInertias: []float64{
@@ -123,7 +124,21 @@ func (g *ManualGearbox) ShiftDown() bool {
 }
 
 func (g *ManualGearbox) GetCurrentRatio() float64 {
-	return g.gearRatios[g.currentGear] * g.finalDrive
+	return g.gearRatios[g.currentGear]
+}
+
+// GetGearRatio returns the ratio of a given gear, 0 for neutral or invalid gears
+// The final drive ratio is applied by the differential
+func (g *ManualGearbox) GetGearRatio(gear int) float64 {
+	if gear <= 0 || gear > g.maxGears {
+		return 0
+	}
+	return g.gearRatios[gear]
+}
+
+// GetCurrentGear returns the engaged gear, 0 = neutral
+func (g *ManualGearbox) GetCurrentGear() int {
+	return g.currentGear
 }
 
 // setOutputShaft Calculates output shaft RPM based on input shaft RPM
@@ -131,7 +146,12 @@ func (g *ManualGearbox) setOutputShaft(rpm float64) float64 {
 	if g.currentGear == 0 {
 		return 0
 	}
-	return rpm / (g.gearRatios[g.currentGear] * g.finalDrive)
+	return rpm / g.gearRatios[g.currentGear]
+}
+
+// GetMaxGear returns the highest gear of the gearbox
+func (g *ManualGearbox) GetMaxGear() int {
+	return g.maxGears
 }
 
 func (g *ManualGearbox) SetGear(targetGear int) bool {
@@ -143,9 +163,9 @@ func (g *ManualGearbox) SetGear(targetGear int) bool {
 }
 
 // GetOutputShaftTorque Calculate the torque at the wheels
-func (g *ManualGearbox) GetOutputShaftTorque(engineTorque float64) float64 {
+func (g *ManualGearbox) GetOutputShaftTorque(engineTorque units.Torque) units.Torque {
 	efficiency := 0.92 // Transmission efficiency
-	return engineTorque * g.GetCurrentRatio() * efficiency * g.ClutchPosition
+	return units.NewtonMeters.Of(engineTorque.NewtonMeters() * g.GetCurrentRatio() * efficiency * g.ClutchPosition)
 }
 
 // Function to calculate the inertia of the input shaft
@@ -208,27 +228,26 @@ func (g *ManualGearbox) updateAngularAccelerations(deltaTime float64) {
 }
 
 // Update implementa la interfaz Transmission
-// Procesa entrada de motor (inputRPM, inputTorque) y calcula salida a las ruedas
+// Procesa entrada de motor (inputSpeed, inputTorque) y calcula salida a las ruedas
 // Parameters:
 //
-//	inputShaftRPM: revoluciones por minuto del eje de entrada (desde motor)
-//	inputShaftTorque: torque en Nm del eje de entrada (desde motor)
+//	inputSpeed: velocidad del eje de entrada (desde motor)
+//	inputTorque: torque del eje de entrada (desde motor)
 //	deltaTime: tiempo transcurrido en segundos
-func (g *ManualGearbox) Update(inputShaftRPM float64, inputShaftTorque float64, deltaTime float64) {
+func (g *ManualGearbox) Update(inputSpeed units.AngularVelocity, inputTorque units.Torque, deltaTime float64) {
 	// Store inputs para compatibilidad backwards (debugging)
-	g.InputShaft = inputShaftRPM
-	g.InputShaftTorque = inputShaftTorque
+	g.InputShaft = inputSpeed.RPM()
+	g.InputShaftTorque = inputTorque.NewtonMeters()
 
 	// Updates angular accelerations
 	g.updateAngularAccelerations(deltaTime)
 
 	if g.ClutchPosition > 0 {
-		// Calculate the exit velocity considering the inertias
-		baseOutputRPM := g.setOutputShaft(inputShaftRPM)
-		inertiaEffect := g.outputShaftAcceleration * deltaTime
-
-		g.OutputShaft = baseOutputRPM + (inertiaEffect * 9.549) // Convert rad/s² to RPM/s
-		g.OutputShaftTorque = g.GetOutputShaftTorque(inputShaftTorque)
+		// The output shaft follows the gear ratio. The shaft accelerations only see the inertia of the
+		// gearbox, not the mass of the car, which the tires resist: adding them as an offset would spin
+		// the output shaft thousands of RPM away from the ratio
+		g.OutputShaft = g.setOutputShaft(g.InputShaft)
+		g.OutputShaftTorque = g.GetOutputShaftTorque(inputTorque).NewtonMeters()
 
 	} else {
 		// Clutch is disengaged, so the output shaft tends to zero
@@ -240,15 +259,15 @@ func (g *ManualGearbox) Update(inputShaftRPM float64, inputShaftTorque float64,
 }
 
 // GetOutputShaft implementa la interfaz Transmission
-// Retorna RPM en eje de salida (hacia diferenciales/ruedas)
-func (g *ManualGearbox) GetOutputShaft() float64 {
-	return g.OutputShaft
+// Retorna la velocidad del eje de salida (hacia diferenciales/ruedas)
+func (g *ManualGearbox) GetOutputShaft() units.AngularVelocity {
+	return units.RPM.Of(g.OutputShaft)
 }
 
 // GetOutputTorque implementa la interfaz Transmission
-// Retorna torque en Nm en eje de salida (hacia diferenciales/ruedas)
-func (g *ManualGearbox) GetOutputTorque() float64 {
-	return g.OutputShaftTorque
+// Retorna el torque en eje de salida (hacia diferenciales/ruedas)
+func (g *ManualGearbox) GetOutputTorque() units.Torque {
+	return units.NewtonMeters.Of(g.OutputShaftTorque)
 }
 
 // GetData implementa la interfaz Gearbox
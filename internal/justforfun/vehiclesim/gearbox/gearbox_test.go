@@ -0,0 +1,77 @@
+package gearbox
+
+import (
+	"go-playground/internal/justforfun/vehiclesim/units"
+	"math"
+	"testing"
+)
+
+// TestManualGearboxRatios checks the engaged gearbox turns the input speed down and the torque up by the
+// ratio of every gear, minus the transmission losses, and never delivers more power than it receives
+func TestManualGearboxRatios(t *testing.T) {
+	g := NewManualGearbox().(*ManualGearbox)
+	input, torque := units.RPM.Of(3000), units.NewtonMeters.Of(300)
+
+	for gear := 1; gear <= 7; gear++ {
+		for _, clutch := range []float64{0.2, 0.5, 1} {
+			g.SetGear(gear)
+			g.SetClutch(clutch)
+			g.Update(input, torque, 0.1)
+
+			ratio := g.GetGearRatio(gear)
+			if got, want := g.GetOutputShaft().RPM(), input.RPM()/ratio; math.Abs(got-want) > 1e-9 {
+				t.Errorf("gear %d clutch %.1f: output %.3f rpm, want %.3f", gear, clutch, got, want)
+			}
+			if got, want := g.GetOutputTorque().NewtonMeters(), torque.NewtonMeters()*ratio*0.92*clutch; math.Abs(got-want) > 1e-9 {
+				t.Errorf("gear %d clutch %.1f: output %.3f Nm, want %.3f", gear, clutch, got, want)
+			}
+			if out, in := units.PowerOf(g.GetOutputTorque(), g.GetOutputShaft()), units.PowerOf(torque, input); out > in {
+				t.Errorf("gear %d clutch %.1f: delivers %s out of %s", gear, clutch, out, in)
+			}
+		}
+	}
+
+	g.SetGear(0)
+	g.Update(input, torque, 0.1)
+	if g.GetOutputShaft() != 0 || g.GetOutputTorque() != 0 {
+		t.Errorf("neutral: output %s and %s, want nothing", g.GetOutputShaft(), g.GetOutputTorque())
+	}
+}
+
+// TestManualGearboxClutchDisengaged checks the output shaft spins down once the clutch is pressed
+func TestManualGearboxClutchDisengaged(t *testing.T) {
+	g := NewManualGearbox().(*ManualGearbox)
+	g.SetGear(3)
+	g.SetClutch(1)
+	g.Update(units.RPM.Of(4000), units.NewtonMeters.Of(200), 0.1)
+
+	g.SetClutch(0)
+	previous := g.GetOutputShaft()
+	for i := 0; i < 50; i++ {
+		g.Update(units.RPM.Of(4000), units.NewtonMeters.Of(200), 0.1)
+		if speed := g.GetOutputShaft(); speed < 0 || speed >= previous {
+			t.Fatalf("step %d: output shaft at %s after %s, want it slowing down", i, speed, previous)
+		}
+		previous = g.GetOutputShaft()
+	}
+	if previous.RPM() > 1 {
+		t.Errorf("output shaft still at %s after 5 s with the clutch pressed", previous)
+	}
+}
+
+// TestManualGearboxNoInertiaOffset holds full torque in first gear for a few seconds, the output shaft
+// has to stay on the ratio however fast the gearbox alone would accelerate under that torque
+func TestManualGearboxNoInertiaOffset(t *testing.T) {
+	g := NewManualGearbox().(*ManualGearbox)
+	g.SetGear(1)
+	g.SetClutch(1)
+	ratio := g.GetGearRatio(1)
+
+	for i := 0; i < 50; i++ {
+		input := units.RPM.Of(1000 + 100*float64(i))
+		g.Update(input, units.NewtonMeters.Of(450), 0.1)
+		if got, want := g.GetOutputShaft().RPM(), input.RPM()/ratio; math.Abs(got-want) > 1e-9 {
+			t.Fatalf("step %d: output %.1f rpm, want %.1f", i, got, want)
+		}
+	}
+}
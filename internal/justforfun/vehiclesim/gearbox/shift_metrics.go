@@ -0,0 +1,136 @@
+package gearbox
+
+import (
+	"fmt"
+	"go-playground/internal/justforfun/vehiclesim/units"
+	"math"
+)
+
+// ShiftSample is the state of the drivetrain in one simulation step, as seen by the ShiftMonitor
+type ShiftSample struct {
+	Gear           int
+	ClutchPosition float64 // 0.0 = disengaged, 1.0 = engaged
+	EngineRPM      float64
+	EngineTorque   float64 // Nm
+	OutputTorque   float64 // Nm at the gearbox output shaft
+	SyncRPM        float64 // Engine RPM that matches the road speed in the current gear
+	Acceleration   float64 // Longitudinal acceleration of the vehicle in m/s²
+}
+
+// ShiftEvent contains the quality metrics of one completed gear shift
+type ShiftEvent struct {
+	FromGear           int
+	ToGear             int
+	Duration           float64 // Seconds from clutch press to full engagement
+	TorqueInterruption float64 // Seconds with the output torque under 20% of the pre-shift torque
+	PeakJerk           float64 // Max absolute rate of change of the acceleration in m/s³
+	ClutchEnergy       float64 // Energy dissipated by the clutch while slipping in Joules
+	RPMMismatch        float64 // Engine RPM minus sync RPM when the clutch starts biting
+}
+
+// Direction returns "up" or "down"
+func (e ShiftEvent) Direction() string {
+	if e.ToGear > e.FromGear {
+		return "up"
+	}
+	return "down"
+}
+
+// String implements the String interface for human-readable formatting
+func (e ShiftEvent) String() string {
+	return fmt.Sprintf("Shift [%d->%d, Duration: %.2f s, TorqueInterruption: %.2f s, PeakJerk: %.1f m/s³, ClutchEnergy: %.0f J, RPMMismatch: %.0f rpm]\n",
+		e.FromGear,
+		e.ToGear,
+		e.Duration,
+		e.TorqueInterruption,
+		e.PeakJerk,
+		e.ClutchEnergy,
+		e.RPMMismatch)
+}
+
+// ShiftMonitor measures the quality of manual gear shifts from the drivetrain state
+// A shift starts when the clutch leaves the fully engaged position and finishes when it is
+// fully engaged again with a different gear. Clutch dips without a gear change are ignored.
+type ShiftMonitor struct {
+	inShift      bool
+	current      ShiftEvent
+	biting       bool
+	preTorque    float64
+	previous     ShiftSample
+	hasPrevious  bool
+	clutchClosed float64 // Clutch position considered fully engaged
+}
+
+// NewShiftMonitor creates a monitor waiting for the next shift
+func NewShiftMonitor() *ShiftMonitor {
+	return &ShiftMonitor{
+		clutchClosed: 0.99,
+	}
+}
+
+// Update feeds one simulation step into the monitor
+// Returns the metrics and true when a shift has just been completed
+func (sm *ShiftMonitor) Update(sample ShiftSample, deltaTime float64) (ShiftEvent, bool) {
+	defer func() {
+		sm.previous = sample
+		sm.hasPrevious = true
+	}()
+
+	if !sm.inShift {
+		if sm.hasPrevious && sample.ClutchPosition < sm.clutchClosed {
+			sm.startShift()
+			sm.accumulate(sample, deltaTime)
+		}
+		return ShiftEvent{}, false
+	}
+
+	sm.accumulate(sample, deltaTime)
+
+	if sample.ClutchPosition < sm.clutchClosed {
+		return ShiftEvent{}, false
+	}
+
+	sm.inShift = false
+	if sample.Gear == sm.current.FromGear {
+		return ShiftEvent{}, false
+	}
+
+	sm.current.ToGear = sample.Gear
+	return sm.current, true
+}
+
+// startShift resets the accumulators using the last sample before the clutch was pressed
+func (sm *ShiftMonitor) startShift() {
+	sm.inShift = true
+	sm.biting = false
+	sm.preTorque = math.Abs(sm.previous.OutputTorque)
+	sm.current = ShiftEvent{
+		FromGear: sm.previous.Gear,
+	}
+}
+
+func (sm *ShiftMonitor) accumulate(sample ShiftSample, deltaTime float64) {
+	sm.current.Duration += deltaTime
+
+	if math.Abs(sample.OutputTorque) < 0.2*sm.preTorque {
+		sm.current.TorqueInterruption += deltaTime
+	}
+
+	if deltaTime > 0 {
+		jerk := math.Abs(sample.Acceleration-sm.previous.Acceleration) / deltaTime
+		sm.current.PeakJerk = math.Max(sm.current.PeakJerk, jerk)
+	}
+
+	// Once the new gear is selected, the first step with the clutch biting gives the RPM mismatch
+	if sample.Gear != sm.current.FromGear && sample.Gear != 0 && sample.ClutchPosition > 0 && !sm.biting {
+		sm.biting = true
+		sm.current.RPMMismatch = sample.EngineRPM - sample.SyncRPM
+	}
+
+	// The slipping clutch dissipates the transmitted torque times the speed difference
+	if sample.ClutchPosition > 0 && sample.ClutchPosition < sm.clutchClosed {
+		slipSpeed := units.RPM.Of(math.Abs(sample.EngineRPM - sample.SyncRPM))
+		transmitted := units.NewtonMeters.Of(sample.EngineTorque * sample.ClutchPosition)
+		sm.current.ClutchEnergy += units.PowerOf(transmitted, slipSpeed).Watts() * deltaTime
+	}
+}
@@ -0,0 +1,58 @@
+package gearbox
+
+import (
+	"math"
+	"testing"
+)
+
+// TestShiftMonitor feeds a 2->3 upshift step by step and checks every metric of the event
+func TestShiftMonitor(t *testing.T) {
+	samples := []ShiftSample{
+		{Gear: 2, ClutchPosition: 1, EngineRPM: 6000, EngineTorque: 200, OutputTorque: 500, SyncRPM: 6000, Acceleration: 2},
+		// Clutch pressed, the drive torque is gone
+		{Gear: 2, ClutchPosition: 0, EngineRPM: 5000, OutputTorque: 0, SyncRPM: 6000, Acceleration: 0},
+		{Gear: 3, ClutchPosition: 0, EngineRPM: 4500, OutputTorque: 0, SyncRPM: 3000, Acceleration: 0},
+		// The clutch bites 1000 rpm above the road speed
+		{Gear: 3, ClutchPosition: 0.5, EngineRPM: 4000, EngineTorque: 100, OutputTorque: 200, SyncRPM: 3000, Acceleration: 1},
+		{Gear: 3, ClutchPosition: 1, EngineRPM: 3000, EngineTorque: 200, OutputTorque: 400, SyncRPM: 3000, Acceleration: 1.5},
+	}
+
+	sm := NewShiftMonitor()
+	var event ShiftEvent
+	for i, sample := range samples {
+		var done bool
+		event, done = sm.Update(sample, 0.1)
+		if done != (i == len(samples)-1) {
+			t.Fatalf("step %d: shift done = %v", i, done)
+		}
+	}
+
+	// 50 Nm through the half engaged clutch slipping at 1000 rpm for 0.1 s
+	wantEnergy := 50 * 1000 * 2 * math.Pi / 60 * 0.1
+	want := ShiftEvent{FromGear: 2, ToGear: 3, Duration: 0.4, TorqueInterruption: 0.2, PeakJerk: 20, ClutchEnergy: wantEnergy, RPMMismatch: 1000}
+	if event.FromGear != want.FromGear || event.ToGear != want.ToGear || event.Direction() != "up" ||
+		math.Abs(event.Duration-want.Duration) > 1e-9 ||
+		math.Abs(event.TorqueInterruption-want.TorqueInterruption) > 1e-9 ||
+		math.Abs(event.PeakJerk-want.PeakJerk) > 1e-9 ||
+		math.Abs(event.ClutchEnergy-want.ClutchEnergy) > 1e-9 ||
+		event.RPMMismatch != want.RPMMismatch {
+		t.Errorf("got %s want %s", event, want)
+	}
+}
+
+// TestShiftMonitorClutchDip ignores a clutch press that ends in the same gear
+func TestShiftMonitorClutchDip(t *testing.T) {
+	sm := NewShiftMonitor()
+	for _, clutch := range []float64{1, 0, 0.5, 1} {
+		if event, done := sm.Update(ShiftSample{Gear: 4, ClutchPosition: clutch, EngineRPM: 3000, SyncRPM: 3000}, 0.1); done {
+			t.Fatalf("clutch dip reported as %s", event)
+		}
+	}
+
+	// The next shift starts from scratch
+	sm.Update(ShiftSample{Gear: 4, ClutchPosition: 0}, 0.1)
+	event, done := sm.Update(ShiftSample{Gear: 3, ClutchPosition: 1}, 0.1)
+	if !done || event.FromGear != 4 || event.Direction() != "down" || math.Abs(event.Duration-0.2) > 1e-9 {
+		t.Errorf("got %s (done %v) want a 0.2 s 4->3 downshift", event, done)
+	}
+}
@@ -0,0 +1,140 @@
+package vehiclesim
+
+import (
+	"bytes"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"go-playground/internal/justforfun/vehiclesim/route"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update-golden", false, "rewrite the telemetry of testdata/golden")
+
+const (
+	// Seeded runs repeat bit for bit on one platform but not across architectures: arm64, ppc64 and
+	// s390x fuse multiply-adds, rounding differently. A run amplifies a relative change of its inputs
+	// about 3000 times (the cruise control integral the most), the tolerances leave room for that
+	goldenRelTolerance = 1e-4
+	goldenAbsTolerance = 1e-6
+	goldenMaxErrors    = 10
+
+	goldenStepSize    = 0.1
+	goldenSampleSteps = 50 // One row every 5 simulated seconds
+)
+
+// goldenScenario is a seeded run whose telemetry is kept in testdata/golden/<name>.csv
+type goldenScenario struct {
+	name     string
+	seed     int64
+	route    *route.Route
+	driver   func(spec VehicleSpec) Driver
+	duration float64 // Simulated seconds
+}
+
+var goldenScenarios = []goldenScenario{
+	{name: "normal_route", seed: 7, route: route.Default(), duration: 180,
+		driver: func(VehicleSpec) Driver { return NewScriptedDriver(NormalProfile()) }},
+	{name: "sporty_route", seed: 42, route: route.Default(), duration: 180,
+		driver: func(VehicleSpec) Driver { return NewScriptedDriver(SportyProfile()) }},
+	{name: "zero_to_100", seed: 1, route: route.Straight(testTrackLengthM), duration: 20,
+		driver: func(spec VehicleSpec) Driver { return NewPerformanceDriver(ZeroTo100(), spec) }},
+}
+
+// goldenTelemetry runs the scenario and samples every channel, the first row is the header
+func goldenTelemetry(t *testing.T, scenario goldenScenario) [][]string {
+	spec := DefaultVehicleSpec()
+	vehicle, err := NewVehicle("vehicle-001", spec, scenario.seed, scenario.route, defaultStart, 0, time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("NewVehicle: %v", err)
+	}
+	driver := scenario.driver(spec)
+
+	header := []string{"time_s"}
+	for _, channel := range Channels {
+		header = append(header, channel.Name)
+	}
+	rows := [][]string{header}
+	for step := 1; float64(step)*goldenStepSize <= scenario.duration+1e-9; step++ {
+		driver.Drive(vehicle, goldenStepSize)
+		snapshot := vehicle.Step(goldenStepSize)
+		if step%goldenSampleSteps != 0 {
+			continue
+		}
+		row := []string{strconv.FormatFloat(vehicle.Elapsed(), 'f', 1, 64)}
+		for _, channel := range Channels {
+			row = append(row, strconv.FormatFloat(channel.Value(snapshot), 'g', -1, 64))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// TestGoldenTelemetry compares seeded runs with the telemetry recorded in testdata/golden.
+// Run with -update-golden to accept an intended change of the simulation.
+func TestGoldenTelemetry(t *testing.T) {
+	for _, scenario := range goldenScenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			got := goldenTelemetry(t, scenario)
+			golden := filepath.Join("testdata", "golden", scenario.name+".csv")
+			if *updateGolden {
+				var file bytes.Buffer
+				if err := csv.NewWriter(&file).WriteAll(got); err != nil {
+					t.Fatalf("encoding %s: %v", golden, err)
+				}
+				if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+					t.Fatalf("updating %s: %v", golden, err)
+				}
+				if err := os.WriteFile(golden, file.Bytes(), 0o644); err != nil {
+					t.Fatalf("updating %s: %v", golden, err)
+				}
+			}
+
+			data, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("reading %s: %v, run go test -run TestGoldenTelemetry -update-golden", golden, err)
+			}
+			want, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+			if err != nil {
+				t.Fatalf("invalid %s: %v", golden, err)
+			}
+			if err := compareTelemetry(got, want); err != nil {
+				t.Fatalf("%v\n%s is outdated, run go test -run TestGoldenTelemetry -update-golden if the change is intended", err, golden)
+			}
+		})
+	}
+}
+
+// compareTelemetry compares the sampled telemetry with the golden rows within the tolerances
+func compareTelemetry(got, want [][]string) error {
+	if len(got) != len(want) {
+		return fmt.Errorf("%d rows, want %d", len(got)-1, len(want)-1)
+	}
+	if fmt.Sprint(got[0]) != fmt.Sprint(want[0]) {
+		return fmt.Errorf("channels %v, want %v", got[0], want[0])
+	}
+
+	var errs []string
+	for i := 1; i < len(want) && len(errs) < goldenMaxErrors; i++ {
+		for j := 0; j < len(want[i]) && len(errs) < goldenMaxErrors; j++ {
+			gotValue, _ := strconv.ParseFloat(got[i][j], 64)
+			wantValue, err := strconv.ParseFloat(want[i][j], 64)
+			if err != nil {
+				return fmt.Errorf("row %d, %s: %v", i, want[0][j], err)
+			}
+			if math.Abs(gotValue-wantValue) > goldenAbsTolerance+goldenRelTolerance*math.Abs(wantValue) {
+				errs = append(errs, fmt.Sprintf("%s s, %s = %v, want %v", got[i][0], want[0][j], gotValue, wantValue))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("telemetry diverged:\n%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package gps
+
+// Checkpoint is the state of the tracker needed to resume a simulation, with the points recorded so far
+type Checkpoint struct {
+	Latitude   float64 // Radians
+	Longitude  float64 // Radians
+	Heading    float64 // Radians
+	SinceLast  float64
+	HasSamples bool
+	Points     []TrackPoint
+}
+
+// Checkpoint returns the current state of the tracker
+func (t *Tracker) Checkpoint() Checkpoint {
+	return Checkpoint{
+		Latitude:   t.latitude,
+		Longitude:  t.longitude,
+		Heading:    t.heading,
+		SinceLast:  t.sinceLast,
+		HasSamples: t.hasSamples,
+		Points:     append([]TrackPoint(nil), t.points...),
+	}
+}
+
+// Restore sets the tracker back to a saved state
+func (t *Tracker) Restore(c Checkpoint) {
+	t.latitude = c.Latitude
+	t.longitude = c.Longitude
+	t.heading = c.Heading
+	t.sinceLast = c.SinceLast
+	t.hasSamples = c.HasSamples
+	t.points = append([]TrackPoint(nil), c.Points...)
+}
@@ -0,0 +1,172 @@
+package gps
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// gpxExtensionsNamespace qualifies the vehiclesim elements inside the GPX extensions
+const gpxExtensionsNamespace = "urn:go-playground:vehiclesim:gpx:1"
+
+// GPX 1.1 document, speed, RPM and gear go in the extensions of each point
+// under the vs prefix, GPX readers skip the elements of namespaces they do not know
+type gpxDocument struct {
+	XMLName xml.Name `xml:"gpx"`
+	Version string   `xml:"version,attr"`
+	Creator string   `xml:"creator,attr"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	XmlnsVS string   `xml:"xmlns:vs,attr"`
+	Track   gpxTrack `xml:"trk"`
+}
+
+type gpxTrack struct {
+	Name    string     `xml:"name"`
+	Segment gpxSegment `xml:"trkseg"`
+}
+
+type gpxSegment struct {
+	Points []gpxPoint `xml:"trkpt"`
+}
+
+type gpxPoint struct {
+	Latitude   float64       `xml:"lat,attr"`
+	Longitude  float64       `xml:"lon,attr"`
+	Elevation  float64       `xml:"ele"`
+	Time       string        `xml:"time"`
+	Extensions gpxExtensions `xml:"extensions"`
+}
+
+type gpxExtensions struct {
+	SpeedKMH float64 `xml:"vs:speed_kmh"`
+	RPM      float64 `xml:"vs:rpm"`
+	Gear     int     `xml:"vs:gear"`
+	Heading  float64 `xml:"vs:heading"`
+}
+
+// WriteGPX writes the trajectory as a GPX 1.1 track
+func WriteGPX(w io.Writer, name string, points []TrackPoint) error {
+	document := gpxDocument{
+		Version: "1.1",
+		Creator: "go-playground vehiclesim",
+		Xmlns:   "http://www.topografix.com/GPX/1/1",
+		XmlnsVS: gpxExtensionsNamespace,
+		Track:   gpxTrack{Name: name},
+	}
+
+	for _, point := range points {
+		document.Track.Segment.Points = append(document.Track.Segment.Points, gpxPoint{
+			Latitude:  point.Latitude,
+			Longitude: point.Longitude,
+			Elevation: point.ElevationM,
+			Time:      point.Time.UTC().Format(time.RFC3339Nano),
+			Extensions: gpxExtensions{
+				SpeedKMH: point.SpeedKMH,
+				RPM:      point.RPM,
+				Gear:     point.Gear,
+				Heading:  point.HeadingDeg,
+			},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return fmt.Errorf("error encoding GPX: %v", err)
+	}
+	return nil
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// WriteGeoJSON writes the trajectory as a GeoJSON FeatureCollection
+// The first feature is the whole track as a LineString, followed by one Point per sample
+// with speed, RPM and gear as properties
+func WriteGeoJSON(w io.Writer, name string, points []TrackPoint) error {
+	line := make([][]float64, 0, len(points))
+	features := make([]geoJSONFeature, 0, len(points)+1)
+
+	for _, point := range points {
+		// GeoJSON positions are [longitude, latitude, elevation]
+		coordinates := []float64{point.Longitude, point.Latitude, point.ElevationM}
+		line = append(line, coordinates)
+
+		features = append(features, geoJSONFeature{
+			Type:     "Feature",
+			Geometry: geoJSONGeometry{Type: "Point", Coordinates: coordinates},
+			Properties: map[string]interface{}{
+				"time":      point.Time.UTC().Format(time.RFC3339Nano),
+				"speed_kmh": point.SpeedKMH,
+				"rpm":       point.RPM,
+				"gear":      point.Gear,
+				"heading":   point.HeadingDeg,
+			},
+		})
+	}
+
+	track := geoJSONFeature{
+		Type:       "Feature",
+		Geometry:   geoJSONGeometry{Type: "LineString", Coordinates: line},
+		Properties: map[string]interface{}{"name": name},
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(geoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: append([]geoJSONFeature{track}, features...),
+	}); err != nil {
+		return fmt.Errorf("error encoding GeoJSON: %v", err)
+	}
+	return nil
+}
+
+// SaveRun writes the trajectory to <dir>/<name>.gpx and <dir>/<name>.geojson
+func SaveRun(dir string, name string, points []TrackPoint) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("error creating output directory: %v", err)
+	}
+
+	exporters := map[string]func(io.Writer, string, []TrackPoint) error{
+		".gpx":     WriteGPX,
+		".geojson": WriteGeoJSON,
+	}
+
+	for extension, export := range exporters {
+		file, err := os.Create(filepath.Join(dir, name+extension))
+		if err != nil {
+			return fmt.Errorf("error creating %s file: %v", extension, err)
+		}
+
+		err = export(file, name, points)
+		closeErr := file.Close()
+		if err != nil {
+			return err
+		}
+		if closeErr != nil {
+			return closeErr
+		}
+	}
+	return nil
+}
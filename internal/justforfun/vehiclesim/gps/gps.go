@@ -0,0 +1,109 @@
+package gps
+
+import (
+	"go-playground/internal/justforfun/vehiclesim/units"
+	"math"
+	"time"
+)
+
+// earthRadiusM is the mean radius of the Earth used for dead reckoning
+const earthRadiusM = 6371000.0
+
+// Coordinate is a WGS84 position in decimal degrees
+type Coordinate struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Sample is the vehicle state fed to the tracker in each simulation step
+type Sample struct {
+	Time       time.Time
+	SpeedMS    float64 // Ground speed in m/s
+	YawRate    float64 // rad/s, positive turns left
+	ElevationM float64
+	RPM        float64
+	Gear       int
+}
+
+// TrackPoint is a recorded position of the trajectory
+type TrackPoint struct {
+	Time       time.Time
+	Latitude   float64
+	Longitude  float64
+	ElevationM float64
+	HeadingDeg float64 // Compass heading, 0 = north, 90 = east
+	SpeedKMH   float64
+	RPM        float64
+	Gear       int
+}
+
+// Tracker integrates heading and speed into a latitude/longitude trajectory (dead reckoning)
+type Tracker struct {
+	SampleInterval float64 // Seconds between recorded points
+
+	latitude   float64 // Radians
+	longitude  float64 // Radians
+	heading    float64 // Radians, 0 = north, clockwise
+	sinceLast  float64
+	hasSamples bool
+	points     []TrackPoint
+}
+
+// NewTracker creates a tracker at the start coordinate heading the given compass direction in degrees
+func NewTracker(start Coordinate, headingDeg float64) *Tracker {
+	return &Tracker{
+		SampleInterval: 1.0,
+		latitude:       start.Latitude * math.Pi / 180,
+		longitude:      start.Longitude * math.Pi / 180,
+		heading:        headingDeg * math.Pi / 180,
+	}
+}
+
+// Update integrates one simulation step and records a point every SampleInterval seconds
+// Parameters:
+//
+//	sample: vehicle state in this step
+//	deltaTime: time elapsed in seconds
+func (t *Tracker) Update(sample Sample, deltaTime float64) {
+	// A positive yaw rate turns left, which decreases the compass heading
+	t.heading = math.Mod(t.heading-sample.YawRate*deltaTime+2*math.Pi, 2*math.Pi)
+
+	distance := sample.SpeedMS * deltaTime
+	north := distance * math.Cos(t.heading)
+	east := distance * math.Sin(t.heading)
+
+	t.latitude += north / earthRadiusM
+	t.longitude += east / (earthRadiusM * math.Cos(t.latitude))
+
+	t.sinceLast += deltaTime
+	if t.hasSamples && t.sinceLast < t.SampleInterval {
+		return
+	}
+	t.sinceLast = 0
+	t.hasSamples = true
+
+	t.points = append(t.points, TrackPoint{
+		Time:       sample.Time,
+		Latitude:   t.latitude * 180 / math.Pi,
+		Longitude:  t.longitude * 180 / math.Pi,
+		ElevationM: sample.ElevationM,
+		HeadingDeg: t.heading * 180 / math.Pi,
+		SpeedKMH:   units.MetersPerSecond.Of(sample.SpeedMS).KilometersPerHour(),
+		RPM:        sample.RPM,
+		Gear:       sample.Gear,
+	})
+}
+
+// GetPoints returns the recorded trajectory
+func (t *Tracker) GetPoints() []TrackPoint {
+	return t.points
+}
+
+// GetData returns the current position
+func (t *Tracker) GetData() Telemetry {
+	return Telemetry{
+		Latitude:   t.latitude * 180 / math.Pi,
+		Longitude:  t.longitude * 180 / math.Pi,
+		HeadingDeg: t.heading * 180 / math.Pi,
+	}
+}
@@ -0,0 +1,76 @@
+package gps
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"math"
+	"testing"
+	"time"
+)
+
+// TestTrackerDrivesFullCircle drives a full circle and expects to be back at the start
+func TestTrackerDrivesFullCircle(t *testing.T) {
+	start := Coordinate{Latitude: 41.57, Longitude: 2.2611}
+	tracker := NewTracker(start, 90)
+
+	// 100 m radius at 10 m/s, one lap takes 2*pi*100/10 seconds
+	speed := 10.0
+	yawRate := speed / 100
+	steps := int(math.Round(2 * math.Pi * 100 / speed / 0.01))
+	for i := 0; i < steps; i++ {
+		tracker.Update(Sample{Time: time.Unix(int64(i), 0), SpeedMS: speed, YawRate: yawRate}, 0.01)
+	}
+
+	position := tracker.GetData()
+	// 1e-5 degrees is around one meter
+	if math.Abs(position.Latitude-start.Latitude) > 1e-5 || math.Abs(position.Longitude-start.Longitude) > 1e-5 {
+		t.Errorf("expected to be back at the start, got %.6f, %.6f", position.Latitude, position.Longitude)
+	}
+	if math.Abs(position.HeadingDeg-90) > 0.5 && math.Abs(position.HeadingDeg-90) < 359.5 {
+		t.Errorf("expected heading 90° after a full circle, got %.2f", position.HeadingDeg)
+	}
+}
+
+// TestExportFormats checks that both exports are valid documents with one entry per point
+func TestExportFormats(t *testing.T) {
+	points := []TrackPoint{
+		{Time: time.Unix(0, 0), Latitude: 41.57, Longitude: 2.26, SpeedKMH: 0, RPM: 800, Gear: 1},
+		{Time: time.Unix(1, 0), Latitude: 41.5701, Longitude: 2.26, SpeedKMH: 36, RPM: 3000, Gear: 2},
+	}
+
+	var gpxBuffer bytes.Buffer
+	if err := WriteGPX(&gpxBuffer, "test", points); err != nil {
+		t.Fatalf("unexpected GPX error: %v", err)
+	}
+	// A namespace aware reader finds the extensions in the vehiclesim namespace
+	var document struct {
+		Points []struct {
+			Extensions struct {
+				Gear int `xml:"urn:go-playground:vehiclesim:gpx:1 gear"`
+			} `xml:"extensions"`
+		} `xml:"trk>trkseg>trkpt"`
+	}
+	if err := xml.Unmarshal(gpxBuffer.Bytes(), &document); err != nil {
+		t.Fatalf("invalid GPX: %v", err)
+	}
+	if len(document.Points) != 2 || document.Points[1].Extensions.Gear != 2 {
+		t.Errorf("unexpected GPX points: %+v", document.Points)
+	}
+
+	var geoJSONBuffer bytes.Buffer
+	if err := WriteGeoJSON(&geoJSONBuffer, "test", points); err != nil {
+		t.Fatalf("unexpected GeoJSON error: %v", err)
+	}
+	var collection geoJSONFeatureCollection
+	if err := json.Unmarshal(geoJSONBuffer.Bytes(), &collection); err != nil {
+		t.Fatalf("invalid GeoJSON: %v", err)
+	}
+	// One LineString plus one Point per sample
+	if len(collection.Features) != 3 || collection.Features[0].Geometry.Type != "LineString" {
+		t.Errorf("unexpected GeoJSON features: %+v", collection.Features)
+	}
+	if collection.Features[2].Properties["rpm"] != 3000.0 {
+		t.Errorf("expected rpm property 3000, got %v", collection.Features[2].Properties["rpm"])
+	}
+}
@@ -0,0 +1,17 @@
+package gps
+
+import "fmt"
+
+type Telemetry struct {
+	Latitude   float64 // Decimal degrees
+	Longitude  float64 // Decimal degrees
+	HeadingDeg float64 // Compass heading, 0 = north, 90 = east
+}
+
+// String implements the String interface for human-readable formatting
+func (d Telemetry) String() string {
+	return fmt.Sprintf("GPS [Lat: %.6f, Lon: %.6f, Heading: %.1f°]\n",
+		d.Latitude,
+		d.Longitude,
+		d.HeadingDeg)
+}
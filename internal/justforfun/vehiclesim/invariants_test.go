@@ -0,0 +1,88 @@
+package vehiclesim
+
+import (
+	"go-playground/internal/justforfun/vehiclesim/body"
+	"go-playground/internal/justforfun/vehiclesim/route"
+	"go-playground/internal/justforfun/vehiclesim/units"
+	"math"
+	"testing"
+	"time"
+)
+
+// near reports whether got is within the relative tolerance of want, or within 1e-9 of it near zero
+func near(got, want, tolerance float64) bool {
+	return math.Abs(got-want) <= math.Max(1e-9, tolerance*math.Abs(want))
+}
+
+// TestPhysicsInvariants drives every profile for a few minutes and checks the laws each step has to obey:
+// power is torque times speed, the engaged gearbox and the differential keep their ratios, no component
+// delivers more power than it receives and the body never gains more energy than the wheels gave it
+func TestPhysicsInvariants(t *testing.T) {
+	profiles := map[string]DriverProfile{"calm": CalmProfile(), "normal": NormalProfile(), "sporty": SportyProfile()}
+	for name, profile := range profiles {
+		t.Run(name, func(t *testing.T) {
+			vehicle, err := NewVehicle("vehicle-001", DefaultVehicleSpec(), 7, route.Default(), defaultStart, 0, time.Unix(0, 0))
+			if err != nil {
+				t.Fatalf("NewVehicle: %v", err)
+			}
+			driver := NewScriptedDriver(profile)
+			diffRatio := vehicle.Differential.GetGearRatio()
+			mass := vehicle.Body.GetMass()
+
+			const deltaTime = 0.1
+			var wheelEnergy, startElevation float64
+			for i := 0; i < 3000; i++ {
+				driver.Drive(vehicle, deltaTime)
+				s := vehicle.Step(deltaTime)
+				if i == 0 {
+					startElevation = s.Route.ElevationM
+				}
+				fail := func(format string, args ...any) {
+					t.Helper()
+					t.Fatalf("step %d: "+format+"\n%s%s%s", append([]any{i}, append(args, s.Engine.String(), s.Gearbox.String(), s.Differential.String())...)...)
+				}
+
+				power := units.PowerOf(units.NewtonMeters.Of(s.Engine.Torque), units.RPM.Of(s.Engine.RPM))
+				if !near(s.Engine.PowerKW, power.Kilowatts(), 1e-9) || !near(s.Engine.PowerHP, power.Horsepower(), 1e-9) {
+					fail("engine power %.3f kW / %.3f hp is not torque × speed = %s", s.Engine.PowerKW, s.Engine.PowerHP, power)
+				}
+
+				gear := s.Gearbox.CurrentGear
+				if gear > 0 && s.Gearbox.ClutchPosition > 0 {
+					ratio := vehicle.Gearbox.GetGearRatio(gear)
+					if !near(s.Gearbox.OutputShaft, s.Gearbox.InputShaft/ratio, 1e-9) {
+						fail("gearbox output %.3f rpm is not the input / %.3f", s.Gearbox.OutputShaft, ratio)
+					}
+				}
+				powerIn := s.Gearbox.InputShaftTorque * units.RPM.Of(s.Gearbox.InputShaft).RadPerSec()
+				powerOut := s.Gearbox.OutputShaftTorque * units.RPM.Of(s.Gearbox.OutputShaft).RadPerSec()
+				if gear > 0 && s.Gearbox.ClutchPosition > 0 && math.Abs(powerOut) > math.Abs(powerIn)+1e-6 {
+					fail("gearbox delivers %.1f W out of %.1f W", powerOut, powerIn)
+				}
+
+				wheelRPM := (s.Differential.WheelSpeedL + s.Differential.WheelSpeedR) / 2
+				if !near(wheelRPM, s.Gearbox.OutputShaft/diffRatio, 1e-9) {
+					fail("differential output %.3f rpm is not the input / %.2f", wheelRPM, diffRatio)
+				}
+				wheelTorque := s.Differential.TorqueL + s.Differential.TorqueR
+				if !near(wheelTorque, s.Gearbox.OutputShaftTorque*diffRatio, 1e-9) {
+					fail("differential output %.1f Nm is not the input × %.2f", wheelTorque, diffRatio)
+				}
+				wheelPower := s.Differential.TorqueL*units.RPM.Of(s.Differential.WheelSpeedL).RadPerSec() +
+					s.Differential.TorqueR*units.RPM.Of(s.Differential.WheelSpeedR).RadPerSec()
+				if math.Abs(wheelPower) > math.Abs(powerOut)*(1+1e-9)+1e-6 {
+					fail("differential delivers %.1f W out of %.1f W", wheelPower, powerOut)
+				}
+
+				// Drag, rolling resistance, tire slip and the brakes only take energy away: the kinetic
+				// and potential energy of the body stays below the work the wheels delivered
+				wheelEnergy += math.Max(0, wheelPower) * deltaTime
+				kinetic := 0.5 * mass * s.Body.SpeedMS * s.Body.SpeedMS
+				potential := mass * body.Gravity * (s.Route.ElevationM - startElevation)
+				if kinetic+potential > wheelEnergy*1.001+1 {
+					fail("the body has %.0f J of kinetic and potential energy, the wheels delivered %.0f J", kinetic+potential, wheelEnergy)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package vehiclesim
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go-playground/internal/justforfun/redpanda/producer"
+	"sync"
+	"time"
+)
+
+// kafkaFlushTimeout bounds the wait for the buffered records on Close
+const kafkaFlushTimeout = 10 * time.Second
+
+// Encodings of the Kafka records
+const (
+	KafkaJSON = "json"
+	KafkaAvro = "avro"
+)
+
+// KafkaConfig is the cluster, topic and record format of the Kafka (or Redpanda) output
+type KafkaConfig struct {
+	Brokers  []string
+	Topic    string
+	Encoding string // KafkaJSON (see TelemetryJSONSchema) or KafkaAvro (see TelemetryAvroSchema)
+
+	// SchemaID is the Schema Registry ID of TelemetryAvroSchema, when set the Avro records
+	// start with the registry wire format header (magic byte and schema ID)
+	SchemaID uint32
+
+	Linger          time.Duration // Wait for more records before sending a batch, 0 for the client default
+	MaxBatchBytes   int32         // 0 for the client default
+	DeliveryTimeout time.Duration // Give up on a record not acknowledged in time (at least 1s), 0 to retry forever
+}
+
+// KafkaSink produces one record per snapshot, keyed by vehicle ID so that the snapshots of a vehicle
+// stay in order in a partition. Records are batched in the background, delivery errors are reported
+// by the next Write and by Close.
+type KafkaSink struct {
+	client      *kgo.Client
+	topic       string
+	contentType string
+	encode      func(snapshot Snapshot) ([]byte, error)
+
+	mu      sync.Mutex
+	failed  int   // Records not delivered since the last report
+	lastErr error // Error of the last of them
+}
+
+// NewKafkaSink creates the producer, the connection to the brokers is opened on the first record
+func NewKafkaSink(config KafkaConfig) (*KafkaSink, error) {
+	sink := &KafkaSink{topic: config.Topic}
+	switch config.Encoding {
+	case KafkaJSON, "":
+		sink.contentType = "application/json"
+		sink.encode = EncodeTelemetryJSON
+	case KafkaAvro:
+		sink.contentType = "application/avro"
+		sink.encode = func(snapshot Snapshot) ([]byte, error) {
+			if config.SchemaID == 0 {
+				return EncodeTelemetryAvro(snapshot), nil
+			}
+			header := binary.BigEndian.AppendUint32([]byte{0}, config.SchemaID)
+			return append(header, EncodeTelemetryAvro(snapshot)...), nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown Kafka encoding %q, expected %s or %s", config.Encoding, KafkaJSON, KafkaAvro)
+	}
+
+	producerConfig := producer.Config{
+		Brokers:         config.Brokers,
+		Topic:           config.Topic,
+		Linger:          config.Linger,
+		MaxBatchBytes:   config.MaxBatchBytes,
+		DeliveryTimeout: config.DeliveryTimeout,
+	}
+	// Hashes the key like the Java client, consumers can find the partition of a vehicle
+	client, err := producer.NewClient(producerConfig, kgo.RecordPartitioner(kgo.StickyKeyPartitioner(nil)))
+	if err != nil {
+		return nil, err
+	}
+	sink.client = client
+	return sink, nil
+}
+
+// Write queues the record of the snapshot and reports the records that failed since the last call
+func (s *KafkaSink) Write(snapshot Snapshot) error {
+	value, err := s.encode(snapshot)
+	if err != nil {
+		return err
+	}
+	record := &kgo.Record{
+		Key:       []byte(snapshot.VehicleID),
+		Value:     value,
+		Timestamp: snapshot.Time,
+		Headers: []kgo.RecordHeader{
+			{Key: "content-type", Value: []byte(s.contentType)},
+			{Key: "schema-version", Value: []byte(TelemetrySchemaVersion)},
+		},
+	}
+	s.client.Produce(context.Background(), record, func(_ *kgo.Record, err error) {
+		if err != nil {
+			s.mu.Lock()
+			s.failed++
+			s.lastErr = err
+			s.mu.Unlock()
+		}
+	})
+	return s.deliveryError()
+}
+
+// Close waits for the buffered records and reports those that could not be delivered
+func (s *KafkaSink) Close() error {
+	defer s.client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), kafkaFlushTimeout)
+	defer cancel()
+	if err := s.client.Flush(ctx); err != nil {
+		return fmt.Errorf("error flushing records to %s: %v", s.topic, err)
+	}
+	return s.deliveryError()
+}
+
+// deliveryError returns the delivery failures since the last call, nil when there are none
+func (s *KafkaSink) deliveryError() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failed == 0 {
+		return nil
+	}
+	err := fmt.Errorf("error delivering %d records to %s: %v", s.failed, s.topic, s.lastErr)
+	s.failed, s.lastErr = 0, nil
+	return err
+}
@@ -0,0 +1,129 @@
+package vehiclesim
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"go-playground/internal/justforfun/vehiclesim/route"
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTelemetryEncoding decodes the JSON and Avro records of a snapshot along the Avro schema
+func TestTelemetryEncoding(t *testing.T) {
+	vehicle, err := NewVehicle("vehicle-001", DefaultVehicleSpec(), 7, route.Default(), defaultStart, 0, time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("NewVehicle: %v", err)
+	}
+	driver := NewScriptedDriver(NormalProfile())
+	var snapshot Snapshot
+	for i := 0; i < 100; i++ {
+		driver.Drive(vehicle, 0.1)
+		snapshot = vehicle.Step(0.1)
+	}
+
+	var schema struct {
+		Fields []struct {
+			Name string `json:"name"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(TelemetryAvroSchema()), &schema); err != nil {
+		t.Fatalf("invalid Avro schema: %v", err)
+	}
+	if len(schema.Fields) != len(TelemetrySchema)+2 {
+		t.Fatalf("schema has %d fields, want %d", len(schema.Fields), len(TelemetrySchema)+2)
+	}
+
+	encoded, err := EncodeTelemetryJSON(snapshot)
+	if err != nil {
+		t.Fatalf("EncodeTelemetryJSON: %v", err)
+	}
+	var record map[string]any
+	if err := json.Unmarshal(encoded, &record); err != nil {
+		t.Fatalf("invalid JSON record: %v", err)
+	}
+	if len(record) != len(schema.Fields) || record["vehicle_id"] != "vehicle-001" || record["timestamp"] != float64(snapshot.Time.UnixMilli()) {
+		t.Errorf("JSON record = %v", record)
+	}
+
+	avro := bytes.NewReader(EncodeTelemetryAvro(snapshot))
+	length, _ := binary.ReadVarint(avro)
+	id := make([]byte, length)
+	_, _ = avro.Read(id)
+	timestamp, _ := binary.ReadVarint(avro)
+	if string(id) != "vehicle-001" || timestamp != snapshot.Time.UnixMilli() {
+		t.Errorf("Avro record of %q at %d", id, timestamp)
+	}
+	for i, field := range TelemetrySchema {
+		if schema.Fields[i+2].Name != field.Channel {
+			t.Fatalf("Avro field %d is %s, want %s", i+2, schema.Fields[i+2].Name, field.Channel)
+		}
+		var value any
+		switch field.Type {
+		case FloatField:
+			var bits uint64
+			err = binary.Read(avro, binary.LittleEndian, &bits)
+			value = math.Float64frombits(bits)
+		case IntField:
+			var number int64
+			number, err = binary.ReadVarint(avro)
+			value = float64(number) // Like the JSON numbers
+		case BoolField:
+			var b byte
+			b, err = avro.ReadByte()
+			value = b == 1
+		case StringField:
+			var length int64
+			length, err = binary.ReadVarint(avro)
+			text := make([]byte, length)
+			_, _ = avro.Read(text)
+			value = string(text)
+		}
+		if err != nil {
+			t.Fatalf("Avro record ends before %s: %v", field.Channel, err)
+		}
+		if value != record[field.Channel] {
+			t.Errorf("%s = %v in Avro, %v in JSON", field.Channel, value, record[field.Channel])
+		}
+	}
+	if avro.Len() != 0 {
+		t.Errorf("%d bytes left after the Avro record", avro.Len())
+	}
+}
+
+// TestKafkaSinkDeliveryError reports the records that could not reach the cluster
+func TestKafkaSinkDeliveryError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close() // Nothing listens on the port anymore
+
+	if _, err := NewKafkaSink(KafkaConfig{Brokers: []string{addr}, Topic: "telemetry", Encoding: "xml"}); err == nil {
+		t.Error("NewKafkaSink with an unknown encoding: expected an error")
+	}
+
+	sink, err := NewKafkaSink(KafkaConfig{
+		Brokers:         []string{addr},
+		Topic:           "telemetry",
+		Encoding:        KafkaAvro,
+		SchemaID:        1,
+		DeliveryTimeout: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewKafkaSink: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := sink.Write(Snapshot{VehicleID: "vehicle-001", Time: time.Unix(int64(i), 0)}); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	err = sink.Close()
+	if err == nil || !strings.Contains(err.Error(), "error delivering 3 records to telemetry") {
+		t.Errorf("Close = %v, want the 3 undelivered records", err)
+	}
+}
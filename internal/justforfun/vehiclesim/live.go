@@ -0,0 +1,233 @@
+package vehiclesim
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// maxTimeScale bounds the pace of a live simulation, faster runs should use the fleet or batch modes
+const maxTimeScale = 100.0
+
+// ControlledDriver drives with an autopilot until commands are given from outside,
+// then it only applies those commands until the autopilot is handed back the car
+type ControlledDriver struct {
+	mu        sync.Mutex
+	autopilot Driver
+	manual    bool
+	pending   []Command
+	rejected  error // Last command the vehicle refused
+}
+
+// NewControlledDriver creates a driver that lets autopilot drive
+func NewControlledDriver(autopilot Driver) *ControlledDriver {
+	return &ControlledDriver{autopilot: autopilot}
+}
+
+// Drive implements Driver
+func (d *ControlledDriver) Drive(v *Vehicle, deltaTime float64) {
+	d.mu.Lock()
+	pending := d.pending
+	d.pending = nil
+	manual := d.manual
+	d.mu.Unlock()
+
+	for _, command := range pending {
+		if err := command.apply(v); err != nil {
+			rejected := fmt.Errorf("command %s rejected: %v", command.Input, err)
+			log.Print(rejected)
+			d.mu.Lock()
+			d.rejected = rejected
+			d.mu.Unlock()
+		}
+	}
+	if !manual {
+		d.autopilot.Drive(v, deltaTime)
+	}
+}
+
+// Command queues commands for the next step and takes the car from the autopilot
+func (d *ControlledDriver) Command(commands ...Command) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.pending = append(d.pending, commands...)
+	d.manual = true
+	d.rejected = nil
+}
+
+// Rejected returns why the vehicle refused a command given since the last call to Command, nil when it
+// took them all
+func (d *ControlledDriver) Rejected() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.rejected
+}
+
+// SetManual takes the car from the autopilot or hands it back
+func (d *ControlledDriver) SetManual(manual bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.manual = manual
+}
+
+// IsManual reports whether the autopilot is off
+func (d *ControlledDriver) IsManual() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.manual
+}
+
+// LiveStatus is the pace of a live simulation
+type LiveStatus struct {
+	Paused    bool    `json:"paused"`
+	TimeScale float64 `json:"time_scale"`
+	ElapsedS  float64 `json:"elapsed_s"`
+	Steps     int     `json:"steps"`
+}
+
+// LiveSimulation steps one vehicle on the wall clock and can be paused, stepped and sped up while it runs
+// Every method is safe for concurrent use
+type LiveSimulation struct {
+	mu        sync.Mutex
+	vehicle   *Vehicle
+	driver    Driver
+	stepSize  float64
+	timeScale float64
+	paused    bool
+	steps     int
+	latest    Snapshot
+	onStep    func(Snapshot)
+	changed   chan struct{}
+}
+
+// NewLiveSimulation creates a live simulation stepping stepSize simulated seconds at a time, in real time
+// onStep is called after every step with the simulation locked, so it may read the vehicle
+func NewLiveSimulation(vehicle *Vehicle, driver Driver, stepSize float64, onStep func(Snapshot)) *LiveSimulation {
+	if onStep == nil {
+		onStep = func(Snapshot) {}
+	}
+	return &LiveSimulation{
+		vehicle:   vehicle,
+		driver:    driver,
+		stepSize:  stepSize,
+		timeScale: 1,
+		onStep:    onStep,
+		changed:   make(chan struct{}, 1),
+	}
+}
+
+// Run steps the vehicle until ctx is done
+func (s *LiveSimulation) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.period())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.changed:
+			ticker.Reset(s.period())
+			continue
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		if !s.paused {
+			s.stepLocked()
+		}
+		s.mu.Unlock()
+	}
+}
+
+// period returns the wall clock time between steps
+func (s *LiveSimulation) period() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return time.Duration(s.stepSize / s.timeScale * float64(time.Second))
+}
+
+func (s *LiveSimulation) stepLocked() {
+	s.driver.Drive(s.vehicle, s.stepSize)
+	s.latest = s.vehicle.Step(s.stepSize)
+	s.steps++
+	s.onStep(s.latest)
+}
+
+// Pause stops stepping until Resume
+func (s *LiveSimulation) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.paused = true
+}
+
+// Resume steps again on the wall clock
+func (s *LiveSimulation) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.paused = false
+}
+
+// Step advances a paused simulation by n steps and returns the last snapshot
+func (s *LiveSimulation) Step(n int) (Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.paused {
+		return Snapshot{}, fmt.Errorf("the simulation must be paused to step it")
+	}
+	if n <= 0 {
+		return Snapshot{}, fmt.Errorf("invalid number of steps %d", n)
+	}
+	for i := 0; i < n; i++ {
+		s.stepLocked()
+	}
+	return s.latest, nil
+}
+
+// SetTimeScale sets the simulated seconds per wall clock second
+func (s *LiveSimulation) SetTimeScale(scale float64) error {
+	if scale <= 0 || scale > maxTimeScale {
+		return fmt.Errorf("time scale must be above 0 and up to %.0f, got %g", maxTimeScale, scale)
+	}
+
+	s.mu.Lock()
+	s.timeScale = scale
+	s.mu.Unlock()
+
+	// Wake the loop so the new pace applies at once
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Status returns the pace and the simulated time
+func (s *LiveSimulation) Status() LiveStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return LiveStatus{
+		Paused:    s.paused,
+		TimeScale: s.timeScale,
+		ElapsedS:  s.vehicle.Elapsed(),
+		Steps:     s.steps,
+	}
+}
+
+// Latest returns the snapshot of the last step, false before the first step
+func (s *LiveSimulation) Latest() (Snapshot, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.latest, s.steps > 0
+}
@@ -0,0 +1,33 @@
+package vehiclesim
+
+import "go-playground/internal/justforfun/vehiclesim/obd"
+
+// OBDData converts a snapshot to the values reported by the emulated OBD-II ECU
+// runTimeS is the time since the engine started, the engine runs from the first step
+func OBDData(snapshot Snapshot, runTimeS float64) obd.Data {
+	gearRatio := 0.0
+	if snapshot.Gearbox.CurrentGear > 0 && snapshot.Gearbox.OutputShaft > 0 {
+		gearRatio = snapshot.Gearbox.InputShaft / snapshot.Gearbox.OutputShaft
+	}
+
+	return obd.Data{
+		RPM:          snapshot.Engine.RPM,
+		SpeedKMH:     snapshot.Wheels.VehicleSpeedKMH,
+		CoolantTempC: snapshot.Engine.CoolantTemp,
+		OilTempC:     snapshot.Engine.OilTemp,
+		Throttle:     snapshot.Engine.AcceleratorPosition,
+		EngineLoad:   snapshot.Engine.Load,
+		FuelRateLH:   snapshot.Engine.FuelRateLH,
+		Gear:         snapshot.Gearbox.CurrentGear,
+		GearRatio:    gearRatio,
+		RunTimeS:     runTimeS,
+	}
+}
+
+// NewOBDServer emulates an ELM327 adapter plugged into the live simulation, reporting the injected faults
+func NewOBDServer(live *LiveSimulation, faults *obd.Faults) *obd.Server {
+	return obd.NewServer(obd.NewECU(func() (obd.Data, bool) {
+		snapshot, ok := live.Latest()
+		return OBDData(snapshot, live.Status().ElapsedS), ok
+	}, faults))
+}
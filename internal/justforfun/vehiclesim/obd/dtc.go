@@ -0,0 +1,100 @@
+package obd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// dtcSystems are the letters of the first two bits of a trouble code
+const dtcSystems = "PCBU" // Powertrain, chassis, body, network
+
+// DTC is a diagnostic trouble code in its two byte OBD-II encoding
+type DTC uint16
+
+// ParseDTC parses a trouble code like "P0301"
+func ParseDTC(code string) (DTC, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 5 {
+		return 0, fmt.Errorf("invalid trouble code %q, expected a letter and 4 digits like P0301", code)
+	}
+	system := strings.IndexByte(dtcSystems, code[0])
+	if system < 0 || code[1] > '3' {
+		return 0, fmt.Errorf("invalid trouble code %q, expected P, C, B or U followed by 0 to 3", code)
+	}
+	value, err := strconv.ParseUint(code[1:], 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid trouble code %q: %v", code, err)
+	}
+	return DTC(system)<<14 | DTC(value), nil
+}
+
+// ParseDTCs parses a comma separated list of trouble codes, e.g. "P0301,P0420"
+func ParseDTCs(list string) ([]DTC, error) {
+	var codes []DTC
+	for _, code := range strings.Split(list, ",") {
+		if strings.TrimSpace(code) == "" {
+			continue
+		}
+		dtc, err := ParseDTC(code)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, dtc)
+	}
+	return codes, nil
+}
+
+func (d DTC) String() string {
+	return fmt.Sprintf("%c%04X", dtcSystems[d>>14], uint16(d&0x3FFF))
+}
+
+// Faults are the trouble codes stored by the ECU, safe for concurrent use
+type Faults struct {
+	mu    sync.Mutex
+	codes []DTC
+}
+
+func NewFaults() *Faults {
+	return &Faults{}
+}
+
+// Inject stores trouble codes as if the ECU had detected them, each code is stored once
+func (f *Faults) Inject(codes ...DTC) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, code := range codes {
+		stored := false
+		for _, existing := range f.codes {
+			stored = stored || existing == code
+		}
+		if !stored {
+			f.codes = append(f.codes, code)
+		}
+	}
+}
+
+// Clear erases the stored trouble codes and turns off the check engine light
+func (f *Faults) Clear() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.codes = nil
+}
+
+// Codes returns the stored trouble codes in the order they were injected
+func (f *Faults) Codes() []DTC {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]DTC(nil), f.codes...)
+}
+
+// Strings returns the stored trouble codes as text, e.g. ["P0301"]
+func (f *Faults) Strings() []string {
+	codes := f.Codes()
+	texts := make([]string, len(codes))
+	for i, code := range codes {
+		texts[i] = code.String()
+	}
+	return texts
+}
@@ -0,0 +1,175 @@
+package obd
+
+import "math"
+
+// OBD-II services answered by the ECU
+const (
+	ModeCurrentData = 0x01
+	ModeStoredDTCs  = 0x03
+	ModeClearDTCs   = 0x04
+	ModePendingDTCs = 0x07
+
+	positiveResponse  = 0x40 // Added to the mode of the request in the response
+	maxPIDsPerRequest = 6    // ISO 15765-4 allows up to 6 PIDs in one mode 01 request
+)
+
+// Data are the values reported by the emulated ECU
+type Data struct {
+	RPM          float64
+	SpeedKMH     float64
+	CoolantTempC float64
+	OilTempC     float64
+	Throttle     float64 // 0.0 to 1.0
+	EngineLoad   float64 // 0.0 to 1.0
+	FuelRateLH   float64
+	Gear         int     // 0 = neutral
+	GearRatio    float64 // 0 when unknown
+	RunTimeS     float64 // Time since the engine started
+}
+
+// Source returns the current values of the vehicle, false while there is nothing to report yet
+type Source func() (Data, bool)
+
+// pidEncoder returns the data bytes of a mode 01 PID
+type pidEncoder func(data Data, codes []DTC) []byte
+
+// currentDataPIDs are the mode 01 PIDs answered by the ECU, the supported PID bitmaps
+// (0x00, 0x20, ...) are derived from them
+var currentDataPIDs = map[byte]pidEncoder{
+	0x01: monitorStatus,
+	0x04: func(data Data, _ []DTC) []byte { return []byte{percent(data.EngineLoad)} },
+	0x05: func(data Data, _ []DTC) []byte { return []byte{temperature(data.CoolantTempC)} },
+	0x0C: func(data Data, _ []DTC) []byte { return word(data.RPM * 4) },
+	0x0D: func(data Data, _ []DTC) []byte { return []byte{byte(clamp(math.Round(data.SpeedKMH), 0, 255))} },
+	0x11: func(data Data, _ []DTC) []byte { return []byte{percent(data.Throttle)} },
+	0x1F: func(data Data, _ []DTC) []byte { return word(data.RunTimeS) },
+	0x5C: func(data Data, _ []DTC) []byte { return []byte{temperature(data.OilTempC)} },
+	0x5E: func(data Data, _ []DTC) []byte { return word(data.FuelRateLH * 20) },
+	0xA4: transmissionGear,
+}
+
+// monitorStatus is PID 01: check engine light and number of stored codes, then the
+// continuous monitors (misfire, fuel system, components) supported and complete
+func monitorStatus(_ Data, codes []DTC) []byte {
+	status := byte(math.Min(float64(len(codes)), 0x7F))
+	if len(codes) > 0 {
+		status |= 0x80
+	}
+	return []byte{status, 0x07, 0x00, 0x00}
+}
+
+// transmissionGear is PID A4: the actual gear in the high nibble and the gear ratio in thousandths
+func transmissionGear(data Data, _ []DTC) []byte {
+	ratio := word(data.GearRatio * 1000)
+	return []byte{0x02, byte(data.Gear&0x0F) << 4, ratio[0], ratio[1]}
+}
+
+// percent encodes a 0.0 to 1.0 value as 0 to 255
+func percent(value float64) byte {
+	return byte(math.Round(clamp(value, 0, 1) * 255))
+}
+
+// temperature encodes °C with the -40 offset of OBD-II
+func temperature(celsius float64) byte {
+	return byte(clamp(math.Round(celsius)+40, 0, 255))
+}
+
+// word encodes a value as two big endian bytes
+func word(value float64) []byte {
+	v := uint16(clamp(math.Round(value), 0, math.MaxUint16))
+	return []byte{byte(v >> 8), byte(v)}
+}
+
+func clamp(value, low, high float64) float64 {
+	return math.Max(low, math.Min(high, value))
+}
+
+// supportedPIDs returns the bitmap of PID base (0x00, 0x20, ...): one bit per PID from base+1 to base+0x20,
+// the last bit tells whether the next bitmap is supported
+func supportedPIDs(base byte) ([]byte, bool) {
+	var bits uint32
+	for pid := range currentDataPIDs {
+		if pid > base && int(pid) <= int(base)+0x20 {
+			bits |= 1 << (0x20 - uint(pid-base))
+		}
+		if int(pid) > int(base)+0x20 && base < 0xE0 {
+			bits |= 1 // The next bitmap lists it
+		}
+	}
+	if bits == 0 && base != 0 {
+		return nil, false
+	}
+	return []byte{byte(bits >> 24), byte(bits >> 16), byte(bits >> 8), byte(bits)}, true
+}
+
+// ECU answers OBD-II requests from the values of a source and the injected faults
+type ECU struct {
+	source Source
+	faults *Faults
+}
+
+func NewECU(source Source, faults *Faults) *ECU {
+	return &ECU{source: source, faults: faults}
+}
+
+// Request answers one OBD-II request, e.g. {0x01, 0x0C} for the RPM
+// Returns false when the ECU does not answer: unknown service or no supported PID.
+func (e *ECU) Request(request []byte) ([]byte, bool) {
+	if len(request) == 0 {
+		return nil, false
+	}
+	mode := request[0]
+	response := []byte{mode + positiveResponse}
+
+	switch mode {
+	case ModeCurrentData:
+		pids := request[1:]
+		if len(pids) == 0 || len(pids) > maxPIDsPerRequest {
+			return nil, false
+		}
+		data, ok := e.source()
+		if !ok {
+			return nil, false
+		}
+		codes := e.faults.Codes()
+		answered := false
+		for _, pid := range pids {
+			var value []byte
+			if pid%0x20 == 0 {
+				value, ok = supportedPIDs(pid)
+			} else if encode, found := currentDataPIDs[pid]; found {
+				value, ok = encode(data, codes), true
+			} else {
+				ok = false
+			}
+			if ok {
+				response = append(append(response, pid), value...)
+				answered = true
+			}
+		}
+		return response, answered
+
+	case ModeStoredDTCs, ModePendingDTCs:
+		if len(request) != 1 {
+			return nil, false
+		}
+		// Faults are confirmed as soon as they are injected, none stays pending
+		var codes []DTC
+		if mode == ModeStoredDTCs {
+			codes = e.faults.Codes()
+		}
+		response = append(response, byte(len(codes)))
+		for _, code := range codes {
+			response = append(response, byte(code>>8), byte(code))
+		}
+		return response, true
+
+	case ModeClearDTCs:
+		if len(request) != 1 {
+			return nil, false
+		}
+		e.faults.Clear()
+		return response, true
+	}
+	return nil, false
+}
@@ -0,0 +1,202 @@
+package obd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	elmVersion     = "ELM327 v1.5"
+	elmDescription = "OBDII to RS232 Interpreter"
+	elmProtocol    = "ISO 15765-4 (CAN 11/500)"
+	elmProtocolNum = "6"
+	elmVoltage     = "14.1V" // Battery with the alternator charging
+
+	ecuResponseID    = "7E8" // CAN ID of the engine ECU responses
+	canFrameDataSize = 7     // Data bytes of a CAN frame after the ISO-TP single frame byte
+	canFirstDataSize = 6     // Data bytes of an ISO-TP first frame
+	maxCommandLength = 64    // Longer lines overflow the input buffer of the adapter
+
+	elmPrompt  = ">"
+	elmNoData  = "NO DATA"
+	elmUnknown = "?"
+)
+
+// elmOKCommands are the AT commands accepted without effect: timing, headers sent, CAN
+// filters and memory settings do not matter to the emulated bus
+var elmOKCommands = []string{"AT0", "AT1", "AT2", "ST", "SH", "CAF", "CFC", "CRA", "CM", "CF", "M0", "M1",
+	"PC", "R0", "R1", "V0", "V1", "AL", "NL", "IB", "KW", "SW", "WM"}
+
+// Session is the state of one ELM327 connection: the AT settings and the last command,
+// repeated on an empty line
+type Session struct {
+	ecu *ECU
+
+	echo      bool
+	linefeeds bool
+	spaces    bool
+	headers   bool
+	searching bool // The next OBD request looks for the protocol first, as with ATSP0
+	last      string
+}
+
+// NewSession starts a session with the settings of an adapter just powered on
+func NewSession(ecu *ECU) *Session {
+	s := &Session{ecu: ecu}
+	s.reset()
+	return s
+}
+
+func (s *Session) reset() {
+	s.echo = true
+	s.linefeeds = false
+	s.spaces = true
+	s.headers = false
+	s.searching = true
+}
+
+// Execute runs one command line, without its carriage return, and returns the output
+// of the adapter up to and including the prompt
+func (s *Session) Execute(line string) string {
+	if strings.TrimSpace(line) == "" {
+		line = s.last
+	}
+	command := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(line), " ", ""))
+	s.last = line
+
+	var output []string
+	if s.echo {
+		output = append(output, line)
+	}
+	if strings.HasPrefix(command, "AT") {
+		output = append(output, s.at(command[2:])...)
+	} else {
+		output = append(output, s.obd(command)...)
+	}
+	return s.print(output)
+}
+
+// print ends the output lines with carriage returns, and line feeds after ATL1, followed by an empty line and the prompt
+func (s *Session) print(lines []string) string {
+	eol := "\r"
+	if s.linefeeds {
+		eol = "\r\n"
+	}
+	return strings.Join(lines, eol) + eol + eol + elmPrompt
+}
+
+// at runs an AT command, given without its AT prefix
+func (s *Session) at(command string) []string {
+	switch command {
+	case "Z", "WS":
+		s.reset()
+		return []string{"", elmVersion}
+	case "D":
+		s.reset()
+		return []string{"OK"}
+	case "I":
+		return []string{elmVersion}
+	case "@1":
+		return []string{elmDescription}
+	case "RV":
+		return []string{elmVoltage}
+	case "DP":
+		return []string{"AUTO, " + elmProtocol}
+	case "DPN":
+		return []string{"A" + elmProtocolNum}
+	case "E0", "E1":
+		s.echo = command == "E1"
+		return []string{"OK"}
+	case "L0", "L1":
+		s.linefeeds = command == "L1"
+		return []string{"OK"}
+	case "S0", "S1":
+		s.spaces = command == "S1"
+		return []string{"OK"}
+	case "H0", "H1":
+		s.headers = command == "H1"
+		return []string{"OK"}
+	}
+
+	// Any protocol is accepted, the ECU always answers on CAN
+	if strings.HasPrefix(command, "SP") || strings.HasPrefix(command, "TP") {
+		s.searching = strings.HasSuffix(command, "0") || strings.HasPrefix(command[2:], "A")
+		return []string{"OK"}
+	}
+	for _, prefix := range elmOKCommands {
+		if strings.HasPrefix(command, prefix) {
+			return []string{"OK"}
+		}
+	}
+	return []string{elmUnknown}
+}
+
+// obd sends a request in hex to the ECU, e.g. "010C", with an optional last digit
+// giving the number of responses to wait for
+func (s *Session) obd(command string) []string {
+	if len(command)%2 == 1 {
+		command = command[:len(command)-1]
+	}
+	request, err := hex.DecodeString(command)
+	if err != nil || len(request) == 0 {
+		return []string{elmUnknown}
+	}
+
+	var output []string
+	if s.searching {
+		output = append(output, "SEARCHING...")
+		s.searching = false
+	}
+	response, ok := s.ecu.Request(request)
+	if !ok {
+		return append(output, elmNoData)
+	}
+	return append(output, s.frames(response)...)
+}
+
+// frames formats a response as the adapter prints its CAN frames: a single frame, or an
+// ISO-TP first frame with its consecutive frames. Without headers the adapter prints the
+// length and numbers the frames instead of the protocol bytes.
+func (s *Session) frames(response []byte) []string {
+	if len(response) <= canFrameDataSize {
+		if s.headers {
+			return []string{s.line(ecuResponseID, append([]byte{byte(len(response))}, response...))}
+		}
+		return []string{s.line("", response)}
+	}
+
+	var lines []string
+	first := response[:canFirstDataSize]
+	if s.headers {
+		lines = append(lines, s.line(ecuResponseID, append([]byte{0x10 | byte(len(response)>>8), byte(len(response))}, first...)))
+	} else {
+		lines = append(lines, fmt.Sprintf("%03X", len(response)), s.line("0:", first))
+	}
+	for i, start := 1, canFirstDataSize; start < len(response); i, start = i+1, start+canFrameDataSize {
+		frame := make([]byte, canFrameDataSize) // Padded with zeros
+		copy(frame, response[start:])
+		if s.headers {
+			lines = append(lines, s.line(ecuResponseID, append([]byte{0x20 | byte(i%16)}, frame...)))
+		} else {
+			lines = append(lines, s.line(fmt.Sprintf("%X:", i%16), frame))
+		}
+	}
+	return lines
+}
+
+// line prints bytes in hex after a prefix, separated by spaces unless ATS0
+func (s *Session) line(prefix string, data []byte) string {
+	fields := make([]string, 0, len(data)+1)
+	if prefix != "" {
+		fields = append(fields, prefix)
+	}
+	for _, b := range data {
+		fields = append(fields, fmt.Sprintf("%02X", b))
+	}
+	separator := " "
+	if !s.spaces {
+		separator = ""
+	}
+	return strings.Join(fields, separator)
+}
@@ -0,0 +1,167 @@
+package obd
+
+import (
+	"bufio"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDTC(t *testing.T) {
+	tests := []struct {
+		code string
+		want DTC
+	}{
+		{"P0301", 0x0301},
+		{"p0420", 0x0420},
+		{"C1234", 0x5234},
+		{"B0A1F", 0x8A1F},
+		{"U3FFF", 0xFFFF},
+	}
+	for _, tt := range tests {
+		got, err := ParseDTC(tt.code)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDTC(%q) = %04X, %v, want %04X", tt.code, uint16(got), err, uint16(tt.want))
+			continue
+		}
+		if strings.ToUpper(tt.code) != got.String() {
+			t.Errorf("DTC(%04X).String() = %s, want %s", uint16(got), got, strings.ToUpper(tt.code))
+		}
+	}
+
+	for _, code := range []string{"", "P030", "X0301", "P4301", "P03G1"} {
+		if _, err := ParseDTC(code); err == nil {
+			t.Errorf("ParseDTC(%q): expected an error", code)
+		}
+	}
+	if codes, err := ParseDTCs("P0301, P0420,"); err != nil || !reflect.DeepEqual(codes, []DTC{0x0301, 0x0420}) {
+		t.Errorf("ParseDTCs = %v, %v", codes, err)
+	}
+}
+
+func testECU(faults *Faults) *ECU {
+	return NewECU(func() (Data, bool) {
+		return Data{RPM: 1726, SpeedKMH: 50.4, CoolantTempC: 90, Throttle: 0.5, EngineLoad: 1, FuelRateLH: 12.5,
+			Gear: 3, GearRatio: 1.52, RunTimeS: 300}, true
+	}, faults)
+}
+
+func TestECU(t *testing.T) {
+	faults := NewFaults()
+	ecu := testECU(faults)
+	tests := []struct {
+		request []byte
+		want    []byte
+	}{
+		{[]byte{0x01, 0x0C}, []byte{0x41, 0x0C, 0x1A, 0xF8}},
+		{[]byte{0x01, 0x0D, 0x05, 0x11}, []byte{0x41, 0x0D, 50, 0x05, 130, 0x11, 128}},
+		{[]byte{0x01, 0x04, 0x5E}, []byte{0x41, 0x04, 255, 0x5E, 0x00, 250}},
+		{[]byte{0x01, 0xA4}, []byte{0x41, 0xA4, 0x02, 0x30, 0x05, 0xF0}},
+		{[]byte{0x01, 0x01}, []byte{0x41, 0x01, 0x00, 0x07, 0x00, 0x00}},
+		// PIDs 01, 04, 05, 0C, 0D, 11, 1F and the next bitmap
+		{[]byte{0x01, 0x00}, []byte{0x41, 0x00, 0x98, 0x18, 0x80, 0x03}},
+		{[]byte{0x01, 0xA0}, []byte{0x41, 0xA0, 0x10, 0x00, 0x00, 0x00}},
+		{[]byte{0x03}, []byte{0x43, 0x00}},
+	}
+	for _, tt := range tests {
+		if got, ok := ecu.Request(tt.request); !ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Request(% X) = % X, %t, want % X", tt.request, got, ok, tt.want)
+		}
+	}
+	for _, request := range [][]byte{{0x01, 0x0B}, {0x01, 0xC0}, {0x09, 0x02}, {0x01}} {
+		if got, ok := ecu.Request(request); ok {
+			t.Errorf("Request(% X) = % X, expected no answer", request, got)
+		}
+	}
+
+	faults.Inject(0x0301, 0x0420, 0x0301)
+	if got, _ := ecu.Request([]byte{0x03}); !reflect.DeepEqual(got, []byte{0x43, 0x02, 0x03, 0x01, 0x04, 0x20}) {
+		t.Errorf("stored codes = % X", got)
+	}
+	if got, _ := ecu.Request([]byte{0x01, 0x01}); got[2] != 0x82 {
+		t.Errorf("monitor status = % X, want the check engine light and 2 codes", got)
+	}
+	if got, ok := ecu.Request([]byte{0x04}); !ok || !reflect.DeepEqual(got, []byte{0x44}) || len(faults.Codes()) != 0 {
+		t.Errorf("clearing codes = % X, %t, left %v", got, ok, faults.Strings())
+	}
+}
+
+func TestSession(t *testing.T) {
+	faults := NewFaults()
+	faults.Inject(0x0301, 0x0420, 0x0171)
+	session := NewSession(testECU(faults))
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"ATZ", "ATZ\r\rELM327 v1.5\r\r>"},
+		{"ATE0", "ATE0\rOK\r\r>"},
+		{"ATSP0", "OK\r\r>"},
+		{"010C", "SEARCHING...\r41 0C 1A F8\r\r>"},
+		{"", "41 0C 1A F8\r\r>"},
+		{"01 0d 1", "41 0D 32\r\r>"},
+		{"010B", "NO DATA\r\r>"},
+		{"ATXYZ", "?\r\r>"},
+		{"03", "008\r0: 43 03 03 01 04 20\r1: 01 71 00 00 00 00 00\r\r>"},
+		{"ATH1", "OK\r\r>"},
+		{"010C", "7E8 04 41 0C 1A F8\r\r>"},
+		{"03", "7E8 10 08 43 03 03 01 04 20\r7E8 21 01 71 00 00 00 00 00\r\r>"},
+		{"ATS0", "OK\r\r>"},
+		{"ATL1", "OK\r\n\r\n>"},
+		{"0105", "7E803410582\r\n\r\n>"},
+		{"ATDPN", "A6\r\n\r\n>"},
+	}
+	for _, tt := range tests {
+		if got := session.Execute(tt.command); got != tt.want {
+			t.Errorf("Execute(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+// TestServer talks to the adapter over TCP like a dongle would
+func TestServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	server := NewServer(testECU(NewFaults()))
+	done := make(chan error)
+	go func() { done <- server.Serve(listener) }()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	send := func(command string) string {
+		t.Helper()
+		if _, err := conn.Write([]byte(command)); err != nil {
+			t.Fatalf("sending %q: %v", command, err)
+		}
+		output, err := reader.ReadString('>')
+		if err != nil {
+			t.Fatalf("reading the answer to %q: %v", command, err)
+		}
+		return output
+	}
+
+	if got := send("ATE0\r\n"); got != "ATE0\rOK\r\r>" {
+		t.Errorf("ATE0 = %q", got)
+	}
+	if got := send(strings.Repeat("0", 100) + "\r"); got != "?\r\r>" {
+		t.Errorf("overflow = %q", got)
+	}
+	if got := send("010D\r"); got != "SEARCHING...\r41 0D 32\r\r>" {
+		t.Errorf("010D = %q", got)
+	}
+
+	if err := server.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Serve: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package obd
+
+import (
+	"bufio"
+	"errors"
+	"log"
+	"net"
+	"sync"
+)
+
+// Server emulates an ELM327 adapter over TCP, each connection is a separate session
+// talking to the same ECU
+type Server struct {
+	ecu *ECU
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+}
+
+func NewServer(ecu *ECU) *Server {
+	return &Server{ecu: ecu, conns: make(map[net.Conn]struct{})}
+}
+
+// Serve accepts connections until Close, returns nil once closed
+func (s *Server) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return listener.Close()
+	}
+	s.listener = listener
+	s.mu.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+// Close stops accepting connections and hangs up the open ones
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Close()
+}
+
+// handle reads commands terminated by a carriage return, ignoring line feeds, until the client hangs up
+func (s *Server) handle(conn net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	session := NewSession(s.ecu)
+	reader := bufio.NewReader(conn)
+	var line []byte
+	overflow := false
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return
+		}
+		switch b {
+		case '\n':
+		case '\r':
+			var output string
+			if overflow {
+				output = session.print([]string{elmUnknown})
+			} else {
+				output = session.Execute(string(line))
+			}
+			if _, err := conn.Write([]byte(output)); err != nil {
+				log.Printf("Error answering OBD-II client %s: %v", conn.RemoteAddr(), err)
+				return
+			}
+			line = line[:0]
+			overflow = false
+		default:
+			if len(line) < maxCommandLength {
+				line = append(line, b)
+			} else {
+				overflow = true
+			}
+		}
+	}
+}
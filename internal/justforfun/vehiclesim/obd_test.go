@@ -0,0 +1,95 @@
+package vehiclesim
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"go-playground/internal/justforfun/vehiclesim/obd"
+	"go-playground/internal/justforfun/vehiclesim/route"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestOBDServer reads the engine speed of the simulation through the adapter, and the trouble codes injected with the API
+func TestOBDServer(t *testing.T) {
+	vehicle, err := NewVehicle("vehicle-001", DefaultVehicleSpec(), 7, route.Default(), defaultStart, 0, time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("NewVehicle: %v", err)
+	}
+	controls := NewControlledDriver(NewScriptedDriver(NormalProfile()))
+	live := NewLiveSimulation(vehicle, controls, 0.1, nil)
+	live.Pause()
+	faults := obd.NewFaults()
+	api := httptest.NewServer(NewControlServer(live, controls, faults).Handler())
+	defer api.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	server := NewOBDServer(live, faults)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	send := func(command string) string {
+		t.Helper()
+		if _, err := fmt.Fprintf(conn, "%s\r", command); err != nil {
+			t.Fatalf("sending %q: %v", command, err)
+		}
+		output, err := reader.ReadString('>')
+		if err != nil {
+			t.Fatalf("reading the answer to %q: %v", command, err)
+		}
+		return strings.TrimSpace(strings.TrimSuffix(output, ">"))
+	}
+
+	send("ATE0")
+	send("ATSP6")
+	if got := send("010C"); got != "NO DATA" {
+		t.Errorf("RPM before the first step = %q, want NO DATA", got)
+	}
+
+	snapshot, err := live.Step(50)
+	if err != nil {
+		t.Fatalf("Step: %v", err)
+	}
+	rpm := uint16(snapshot.Engine.RPM*4 + 0.5)
+	if got, want := send("010C"), fmt.Sprintf("41 0C %02X %02X", rpm>>8, rpm&0xFF); got != want {
+		t.Errorf("RPM = %q, want %q for %.0f rpm", got, want, snapshot.Engine.RPM)
+	}
+
+	response, err := http.Post(api.URL+"/api/faults", "application/json", strings.NewReader(`{"codes": ["P0301", "P0420"]}`))
+	if err != nil || response.StatusCode != http.StatusOK {
+		t.Fatalf("injecting faults: %v %v", response, err)
+	}
+	response.Body.Close()
+	if response, err := http.Post(api.URL+"/api/faults", "application/json", strings.NewReader(`{"codes": ["P9999"]}`)); err != nil || response.StatusCode != http.StatusBadRequest {
+		t.Errorf("injecting an invalid code: %v %v", response, err)
+	}
+	if got := send("03"); got != "43 02 03 01 04 20" {
+		t.Errorf("stored codes = %q", got)
+	}
+	if got := send("04"); got != "44" {
+		t.Errorf("clearing codes = %q", got)
+	}
+
+	response, err = http.Get(api.URL + "/api/faults")
+	if err != nil {
+		t.Fatalf("GET /api/faults: %v", err)
+	}
+	defer response.Body.Close()
+	var list faultList
+	if err := json.NewDecoder(response.Body).Decode(&list); err != nil || len(list.Codes) != 0 {
+		t.Errorf("faults after mode 04 = %+v, %v, want none", list, err)
+	}
+}
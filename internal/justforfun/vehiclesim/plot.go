@@ -6,10 +6,49 @@ import (
 	"github.com/influxdata/influxdb-client-go/v2/api/write"
 	"go-playground/internal/justforfun/vehiclesim/engine"
 	"go-playground/internal/justforfun/vehiclesim/influx"
+	"go-playground/internal/justforfun/vehiclesim/plot"
+	"go-playground/internal/justforfun/vehiclesim/units"
 	"log"
+	"path/filepath"
 	"time"
 )
 
+// torqueCurveThrottles are the accelerator positions of the torque curves
+var torqueCurveThrottles = []float64{0.25, 0.5, 0.75, 1.0}
+
+// PlotFormats are the image formats written by the plots
+var PlotFormats = []string{"svg", "png"}
+
+// DefaultRunChannels are plotted when no channel is asked for
+var DefaultRunChannels = []string{"vehicle_speed_kmh", "rpm", "current_gear", "accel_position"}
+
+// torqueCurve is the engine curve at one accelerator position
+type torqueCurve struct {
+	Throttle float64
+	RPM      []float64
+	Torque   []float64
+	PowerKW  []float64
+}
+
+// engineTorqueCurves samples the curve of the engine every 100 RPM from idle to the max RPM, per throttle position
+func engineTorqueCurves(motor *engine.Engine) []torqueCurve {
+	var curves []torqueCurve
+	for _, position := range torqueCurveThrottles {
+		motor.SetAcceleratorPos(position)
+
+		curve := torqueCurve{Throttle: position}
+		for rpm := 800.0; rpm <= motor.GetMaxSpeed().RPM(); rpm += 100 {
+			speed := units.RPM.Of(rpm)
+			torque := motor.CurveTorque(speed)
+			curve.RPM = append(curve.RPM, rpm)
+			curve.Torque = append(curve.Torque, torque.NewtonMeters())
+			curve.PowerKW = append(curve.PowerKW, units.PowerOf(torque, speed).Kilowatts())
+		}
+		curves = append(curves, curve)
+	}
+	return curves
+}
+
 func PlotEngineTorqueCurve() {
 	fmt.Println("Plotting engine torque curve")
 	config := influx.ConfigInfluxDB{
@@ -23,31 +62,21 @@ func PlotEngineTorqueCurve() {
 	writeAPI := client.WriteAPIBlocking(config.Org, config.Bucket)
 
 	// Generate torque curves for different throttle positions
-	acceleratorPositions := []float64{0.25, 0.5, 0.75, 1.0}
-
-	motor := engine.NewEngine()
-
-	for _, position := range acceleratorPositions {
-		motor.SetAcceleratorPos(position)
-
-		// Generate points throughout the RPM range
-		for rpm := 800.0; rpm <= motor.MaxRPM; rpm += 100 {
-			motor.Rpm = rpm
-			motor.UpdateTorque()
-
-			engineData := motor.GetData()
+	curves := engineTorqueCurves(engine.NewEngine())
 
+	for _, curve := range curves {
+		for i, rpm := range curve.RPM {
 			// Create a point for InfluxDB
 			point := write.NewPoint(
 				"torque_curve",
 				map[string]string{
 					"simulation":     "engine1",
-					"accel_position": fmt.Sprintf("%.2f", position),
+					"accel_position": fmt.Sprintf("%.2f", curve.Throttle),
 				},
 				map[string]interface{}{
-					"rpm":      engineData.RPM,
-					"torque":   engineData.Torque,
-					"power_kw": engineData.PowerKW, // Power in kW
+					"rpm":      rpm,
+					"torque":   curve.Torque[i],
+					"power_kw": curve.PowerKW[i], // Power in kW
 				},
 				time.Now(),
 			)
@@ -57,4 +86,86 @@ func PlotEngineTorqueCurve() {
 			}
 		}
 	}
+
+	// The same curves as images, to look at them without Grafana
+	files, err := saveTorqueCurvePlots(curves, outputDir(), PlotFormats)
+	if err != nil {
+		log.Printf("Error plotting engine torque curve: %v", err)
+		return
+	}
+	for _, file := range files {
+		fmt.Printf("Torque curve plotted in %s\n", file)
+	}
+}
+
+// torqueCurveFigure returns the torque chart and the power chart, in kW and HP, per throttle position
+func torqueCurveFigure(curves []torqueCurve) plot.Figure {
+	torqueChart := plot.Chart{
+		Title:  "Engine torque",
+		XLabel: "Engine speed (rpm)",
+		YLabel: "Torque (Nm)",
+	}
+	powerChart := plot.Chart{
+		Title:     "Engine power",
+		XLabel:    "Engine speed (rpm)",
+		YLabel:    "Power (kW)",
+		Secondary: &plot.SecondaryAxis{Label: "Power (HP)", Scale: units.Kilowatts.Of(1).Horsepower()},
+	}
+	for _, curve := range curves {
+		name := fmt.Sprintf("Throttle %.0f %%", curve.Throttle*100)
+		torqueChart.Series = append(torqueChart.Series, plot.Series{Name: name, X: curve.RPM, Y: curve.Torque})
+		powerChart.Series = append(powerChart.Series, plot.Series{Name: name, X: curve.RPM, Y: curve.PowerKW})
+	}
+	return plot.Figure{Charts: []plot.Chart{torqueChart, powerChart}}
+}
+
+// SaveTorqueCurvePlots renders the torque and power curves of a new engine in dir, one file per format
+func SaveTorqueCurvePlots(dir string, formats []string) ([]string, error) {
+	return saveTorqueCurvePlots(engineTorqueCurves(engine.NewEngine()), dir, formats)
+}
+
+func saveTorqueCurvePlots(curves []torqueCurve, dir string, formats []string) ([]string, error) {
+	figure := torqueCurveFigure(curves)
+
+	var files []string
+	for _, format := range formats {
+		path := filepath.Join(dir, "engine-torque-curve."+format)
+		if err := figure.Save(path); err != nil {
+			return files, err
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// RunFigure plots the channels of a run versus time, one chart per channel
+func RunFigure(title string, snapshots []Snapshot, channelNames []string) (plot.Figure, error) {
+	if len(snapshots) == 0 {
+		return plot.Figure{}, fmt.Errorf("error plotting %s: the run has no snapshot", title)
+	}
+
+	start := snapshots[0].Time
+	times := make([]float64, len(snapshots))
+	for i, snapshot := range snapshots {
+		times[i] = snapshot.Time.Sub(start).Seconds()
+	}
+
+	var figure plot.Figure
+	for _, name := range channelNames {
+		channel, err := ChannelByName(name)
+		if err != nil {
+			return plot.Figure{}, err
+		}
+		values := make([]float64, len(snapshots))
+		for i, snapshot := range snapshots {
+			values[i] = channel.Value(snapshot)
+		}
+		figure.Charts = append(figure.Charts, plot.Chart{
+			Title:  fmt.Sprintf("%s - %s", title, channel.Name),
+			XLabel: "Time (s)",
+			YLabel: fmt.Sprintf("%s (%s)", channel.Name, channel.Unit),
+			Series: []plot.Series{{Name: channel.Name, X: times, Y: values}},
+		})
+	}
+	return figure, nil
 }
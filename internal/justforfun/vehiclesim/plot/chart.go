@@ -0,0 +1,312 @@
+package plot
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultWidth       = 900
+	defaultPanelHeight = 360
+	marginLeft         = 80
+	marginRight        = 30
+	marginRightAxis    = 80 // Right margin when the chart has a secondary axis
+	marginTop          = 40
+	marginBottom       = 50
+	maxTicks           = 8
+)
+
+// palette colors the series in order
+var palette = []color.RGBA{
+	{R: 0x1f, G: 0x77, B: 0xb4, A: 0xff},
+	{R: 0xd6, G: 0x27, B: 0x28, A: 0xff},
+	{R: 0x2c, G: 0xa0, B: 0x2c, A: 0xff},
+	{R: 0xff, G: 0x7f, B: 0x0e, A: 0xff},
+	{R: 0x94, G: 0x67, B: 0xbd, A: 0xff},
+	{R: 0x8c, G: 0x56, B: 0x4b, A: 0xff},
+	{R: 0x17, G: 0xbe, B: 0xcf, A: 0xff},
+	{R: 0x7f, G: 0x7f, B: 0x7f, A: 0xff},
+}
+
+var (
+	white     = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	black     = color.RGBA{A: 0xff}
+	gridColor = color.RGBA{R: 0xdd, G: 0xdd, B: 0xdd, A: 0xff}
+)
+
+// Series is one line of a chart, points with a NaN coordinate break the line
+type Series struct {
+	Name string
+	X    []float64
+	Y    []float64
+}
+
+// SecondaryAxis labels the right side of a chart with the left values multiplied by Scale
+// e.g. power in kW on the left and in HP on the right
+type SecondaryAxis struct {
+	Label string
+	Scale float64
+}
+
+// Chart is a line chart of one or more series sharing the same axes
+type Chart struct {
+	Title     string
+	XLabel    string
+	YLabel    string
+	Secondary *SecondaryAxis
+	Series    []Series
+}
+
+// Figure stacks charts vertically, all of the same size
+type Figure struct {
+	Width       int // Pixels, 900 when 0
+	PanelHeight int // Pixels of each chart, 360 when 0
+	Charts      []Chart
+}
+
+// canvas is where the figure is drawn, in pixels from the top left corner
+type canvas interface {
+	rect(x, y, w, h float64, c color.RGBA)
+	line(x1, y1, x2, y2 float64, c color.RGBA, width float64)
+	polyline(xs, ys []float64, c color.RGBA, width float64)
+	// text draws s centered vertically on y, anchored at x by its start, middle or end, vertical text goes upwards
+	text(x, y float64, s string, anchor string, vertical bool, c color.RGBA)
+}
+
+func (f Figure) size() (int, int) {
+	width, panelHeight := f.Width, f.PanelHeight
+	if width == 0 {
+		width = defaultWidth
+	}
+	if panelHeight == 0 {
+		panelHeight = defaultPanelHeight
+	}
+	return width, panelHeight * len(f.Charts)
+}
+
+func (f Figure) draw(c canvas) {
+	width, height := f.size()
+	c.rect(0, 0, float64(width), float64(height), white)
+
+	panelHeight := height / max(len(f.Charts), 1)
+	for i, chart := range f.Charts {
+		chart.draw(c, 0, float64(i*panelHeight), float64(width), float64(panelHeight))
+	}
+}
+
+// Save writes the figure as SVG or PNG depending on the extension of path, creating the directory if needed
+func (f Figure) Save(path string) error {
+	if len(f.Charts) == 0 {
+		return fmt.Errorf("error saving %s: the figure has no chart", path)
+	}
+
+	var write func(io.Writer) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".svg":
+		write = f.WriteSVG
+	case ".png":
+		write = f.WritePNG
+	default:
+		return fmt.Errorf("error saving %s: unknown image format, expected .svg or .png", path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating plot directory: %v", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating plot: %v", err)
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return fmt.Errorf("error writing plot %s: %v", path, err)
+	}
+	return file.Close()
+}
+
+// Save writes the chart alone, see Figure.Save
+func (ch Chart) Save(path string) error {
+	return Figure{Charts: []Chart{ch}}.Save(path)
+}
+
+// bounds returns the range of the finite values of every series
+func (ch Chart) bounds() (xMin, xMax, yMin, yMax float64) {
+	xMin, yMin = math.Inf(1), math.Inf(1)
+	xMax, yMax = math.Inf(-1), math.Inf(-1)
+	for _, series := range ch.Series {
+		for i := range series.X {
+			x, y := series.X[i], series.Y[i]
+			if !isFinite(x) || !isFinite(y) {
+				continue
+			}
+			xMin, xMax = math.Min(xMin, x), math.Max(xMax, x)
+			yMin, yMax = math.Min(yMin, y), math.Max(yMax, y)
+		}
+	}
+	if math.IsInf(xMin, 1) {
+		return 0, 1, 0, 1
+	}
+	return xMin, xMax, yMin, yMax
+}
+
+func (ch Chart) draw(c canvas, x0, y0, width, height float64) {
+	right := float64(marginRight)
+	if ch.Secondary != nil {
+		right = marginRightAxis
+	}
+	left, top := x0+marginLeft, y0+marginTop
+	plotW, plotH := width-marginLeft-right, height-marginTop-marginBottom
+	bottom := top + plotH
+
+	xMin, xMax, yMin, yMax := ch.bounds()
+	xTicks := niceTicks(xMin, xMax)
+	yTicks := niceTicks(yMin, yMax)
+	// The x axis ends at the data, the y axis at the ticks so the lines never touch the frame
+	yMin, yMax = yTicks[0], yTicks[len(yTicks)-1]
+	if xMin == xMax {
+		xMin, xMax = xTicks[0], xTicks[len(xTicks)-1]
+	}
+
+	toX := func(x float64) float64 { return left + (x-xMin)/(xMax-xMin)*plotW }
+	toY := func(y float64) float64 { return bottom - (y-yMin)/(yMax-yMin)*plotH }
+
+	c.text(x0+width/2, y0+marginTop/2, ch.Title, "middle", false, black)
+
+	for _, tick := range xTicks {
+		if tick < xMin || tick > xMax {
+			continue
+		}
+		c.line(toX(tick), top, toX(tick), bottom, gridColor, 1)
+		c.text(toX(tick), bottom+12, formatTick(tick, xTicks), "middle", false, black)
+	}
+	for _, tick := range yTicks {
+		c.line(left, toY(tick), left+plotW, toY(tick), gridColor, 1)
+		c.text(left-6, toY(tick), formatTick(tick, yTicks), "end", false, black)
+	}
+	c.text(left+plotW/2, bottom+34, ch.XLabel, "middle", false, black)
+	c.text(x0+18, top+plotH/2, ch.YLabel, "middle", true, black)
+
+	if ch.Secondary != nil && ch.Secondary.Scale != 0 {
+		scale := ch.Secondary.Scale
+		secondaryTicks := niceTicks(yMin*scale, yMax*scale)
+		for _, tick := range secondaryTicks {
+			y := toY(tick / scale)
+			if y < top-0.5 || y > bottom+0.5 {
+				continue
+			}
+			c.line(left+plotW, y, left+plotW+4, y, black, 1)
+			c.text(left+plotW+6, y, formatTick(tick, secondaryTicks), "start", false, black)
+		}
+		c.text(x0+width-14, top+plotH/2, ch.Secondary.Label, "middle", true, black)
+	}
+
+	for i, series := range ch.Series {
+		seriesColor := palette[i%len(palette)]
+		var xs, ys []float64
+		flush := func() {
+			if len(xs) > 1 {
+				c.polyline(xs, ys, seriesColor, 2)
+			}
+			xs, ys = nil, nil
+		}
+		for j := range series.X {
+			if !isFinite(series.X[j]) || !isFinite(series.Y[j]) {
+				flush()
+				continue
+			}
+			xs = append(xs, toX(series.X[j]))
+			ys = append(ys, toY(series.Y[j]))
+		}
+		flush()
+	}
+
+	// Frame drawn over the lines that reach the edges
+	c.line(left, top, left+plotW, top, black, 1)
+	c.line(left, bottom, left+plotW, bottom, black, 1)
+	c.line(left, top, left, bottom, black, 1)
+	c.line(left+plotW, top, left+plotW, bottom, black, 1)
+
+	// Legend in the top right corner, only needed when there is more than one line
+	if len(ch.Series) > 1 {
+		c.rect(left+plotW-156, top+4, 152, float64(len(ch.Series))*16+4, white)
+		for i, series := range ch.Series {
+			y := top + 14 + float64(i)*16
+			c.line(left+plotW-150, y, left+plotW-130, y, palette[i%len(palette)], 3)
+			c.text(left+plotW-124, y, series.Name, "start", false, black)
+		}
+	}
+}
+
+// niceTicks returns evenly spaced round values covering [lo, hi], such as 0, 50, 100, 150
+func niceTicks(lo, hi float64) []float64 {
+	if lo == hi {
+		lo, hi = lo-1, hi+1
+	}
+	step := niceNumber((hi - lo) / (maxTicks - 1))
+	start := math.Floor(lo/step) * step
+	end := math.Ceil(hi/step) * step
+
+	var ticks []float64
+	for i := 0; ; i++ {
+		tick := start + float64(i)*step
+		if tick > end+step/2 {
+			break
+		}
+		// Rounds away the accumulated error, e.g. 0.30000000000000004, adding 0 turns -0 into 0
+		scale := math.Pow(10, float64(stepDecimals(step)))
+		ticks = append(ticks, math.Round(tick*scale)/scale+0)
+	}
+	return ticks
+}
+
+// niceNumber rounds up to 1, 2, 2.5 or 5 times a power of ten
+func niceNumber(value float64) float64 {
+	exponent := math.Floor(math.Log10(value))
+	fraction := value / math.Pow(10, exponent)
+	var nice float64
+	switch {
+	case fraction <= 1:
+		nice = 1
+	case fraction <= 2:
+		nice = 2
+	case fraction <= 2.5:
+		nice = 2.5
+	case fraction <= 5:
+		nice = 5
+	default:
+		nice = 10
+	}
+	return nice * math.Pow(10, exponent)
+}
+
+// formatTick prints a tick with the decimals its step needs
+func formatTick(tick float64, ticks []float64) string {
+	decimals := 0
+	if len(ticks) > 1 {
+		decimals = stepDecimals(ticks[1] - ticks[0])
+	}
+	return strconv.FormatFloat(tick, 'f', decimals, 64)
+}
+
+// stepDecimals returns the decimals needed to print the multiples of a nice step, 1 for 0.5, 2 for 0.25
+func stepDecimals(step float64) int {
+	decimals := max(0, int(math.Ceil(-math.Log10(step)-1e-9)))
+	for decimals < 15 {
+		scaled := step * math.Pow(10, float64(decimals))
+		if math.Abs(scaled-math.Round(scaled)) < 1e-9*scaled {
+			break
+		}
+		decimals++
+	}
+	return decimals
+}
+
+func isFinite(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
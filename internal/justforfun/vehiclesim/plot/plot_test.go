@@ -0,0 +1,112 @@
+package plot
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/png"
+	"io"
+	"math"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNiceTicks(t *testing.T) {
+	tests := []struct {
+		lo, hi float64
+		want   []float64
+	}{
+		{0, 390, []float64{0, 100, 200, 300, 400}},
+		{800, 8500, []float64{0, 2000, 4000, 6000, 8000, 10000}},
+		{-0.3, 0.7, []float64{-0.4, -0.2, 0, 0.2, 0.4, 0.6, 0.8}},
+		{3, 3, []float64{2, 2.5, 3, 3.5, 4}},
+	}
+	for _, tt := range tests {
+		if got := niceTicks(tt.lo, tt.hi); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("niceTicks(%g, %g) = %v, want %v", tt.lo, tt.hi, got, tt.want)
+		}
+	}
+
+	if got := formatTick(2.5, []float64{0, 2.5, 5}); got != "2.5" {
+		t.Errorf("formatTick(2.5) = %q, want 2.5", got)
+	}
+}
+
+func testFigure() Figure {
+	x := []float64{0, 1, 2, math.NaN(), 4, 5}
+	return Figure{
+		Width:       400,
+		PanelHeight: 200,
+		Charts: []Chart{
+			{Title: "Torque & power", XLabel: "rpm", YLabel: "Nm", Series: []Series{
+				{Name: "a", X: x, Y: []float64{1, 2, 3, 4, 5, 6}},
+				{Name: "b", X: x, Y: []float64{6, 5, 4, 3, 2, 1}},
+			}},
+			{Title: "Power", YLabel: "kW", Secondary: &SecondaryAxis{Label: "HP", Scale: 1.341}, Series: []Series{
+				{Name: "c", X: []float64{0, 1}, Y: []float64{0, 100}},
+			}},
+		},
+	}
+}
+
+// TestWriteSVG checks the document is valid XML and the NaN point splits the line in two
+func TestWriteSVG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testFigure().WriteSVG(&buf); err != nil {
+		t.Fatalf("WriteSVG: %v", err)
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(buf.Bytes()))
+	for {
+		if _, err := decoder.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("invalid SVG: %v\n%s", err, buf.String())
+		}
+	}
+
+	svg := buf.String()
+	if got := strings.Count(svg, "<polyline"); got != 5 {
+		t.Errorf("got %d polylines, want 5", got)
+	}
+	for _, want := range []string{`width="400" height="400"`, "Torque &amp; power", ">HP<"} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG misses %s", want)
+		}
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testFigure().WritePNG(&buf); err != nil {
+		t.Fatalf("WritePNG: %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("decoding PNG: %v", err)
+	}
+	if size := img.Bounds().Size(); size.X != 400 || size.Y != 400 {
+		t.Errorf("PNG size = %v, want 400x400", size)
+	}
+
+	// The second point of the first series, (1, 2) on axes from 0 to 5 and from 1 to 6, in the first color of the palette
+	plotW, plotH := 400.0-marginLeft-marginRight, 200.0-marginTop-marginBottom
+	x, y := int(marginLeft+plotW/5), int(marginTop+plotH*4/5)
+	if got := img.At(x, y); got != palette[0] {
+		t.Errorf("pixel at (%d, %d) = %v, want %v", x, y, got, palette[0])
+	}
+}
+
+func TestSave(t *testing.T) {
+	dir := t.TempDir()
+	if err := testFigure().Save(filepath.Join(dir, "plots", "figure.png")); err != nil {
+		t.Errorf("Save png: %v", err)
+	}
+	if err := testFigure().Save(filepath.Join(dir, "figure.jpg")); err == nil {
+		t.Error("Save jpg: expected an unknown format error")
+	}
+	if err := (Figure{}).Save(filepath.Join(dir, "empty.svg")); err == nil {
+		t.Error("Save of an empty figure: expected an error")
+	}
+}
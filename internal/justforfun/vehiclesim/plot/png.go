@@ -0,0 +1,151 @@
+package plot
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"unicode"
+)
+
+const (
+	glyphWidth   = 5
+	glyphHeight  = 7
+	glyphAdvance = glyphWidth + 1
+)
+
+// font is a 5x7 bitmap font, one byte per row with the leftmost pixel in bit 4
+// Lowercase letters are drawn in uppercase, unknown characters as blanks
+var font = map[rune][glyphHeight]byte{
+	'0': {0x0E, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0E},
+	'1': {0x04, 0x0C, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'2': {0x0E, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1F},
+	'3': {0x1F, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0E},
+	'4': {0x02, 0x06, 0x0A, 0x12, 0x1F, 0x02, 0x02},
+	'5': {0x1F, 0x10, 0x1E, 0x01, 0x01, 0x11, 0x0E},
+	'6': {0x06, 0x08, 0x10, 0x1E, 0x11, 0x11, 0x0E},
+	'7': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
+	'8': {0x0E, 0x11, 0x11, 0x0E, 0x11, 0x11, 0x0E},
+	'9': {0x0E, 0x11, 0x11, 0x0F, 0x01, 0x02, 0x0C},
+	'A': {0x0E, 0x11, 0x11, 0x1F, 0x11, 0x11, 0x11},
+	'B': {0x1E, 0x11, 0x11, 0x1E, 0x11, 0x11, 0x1E},
+	'C': {0x0E, 0x11, 0x10, 0x10, 0x10, 0x11, 0x0E},
+	'D': {0x1C, 0x12, 0x11, 0x11, 0x11, 0x12, 0x1C},
+	'E': {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x1F},
+	'F': {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x10},
+	'G': {0x0E, 0x11, 0x10, 0x17, 0x11, 0x11, 0x0F},
+	'H': {0x11, 0x11, 0x11, 0x1F, 0x11, 0x11, 0x11},
+	'I': {0x0E, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'J': {0x07, 0x02, 0x02, 0x02, 0x02, 0x12, 0x0C},
+	'K': {0x11, 0x12, 0x14, 0x18, 0x14, 0x12, 0x11},
+	'L': {0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x1F},
+	'M': {0x11, 0x1B, 0x15, 0x15, 0x11, 0x11, 0x11},
+	'N': {0x11, 0x11, 0x19, 0x15, 0x13, 0x11, 0x11},
+	'O': {0x0E, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'P': {0x1E, 0x11, 0x11, 0x1E, 0x10, 0x10, 0x10},
+	'Q': {0x0E, 0x11, 0x11, 0x11, 0x15, 0x12, 0x0D},
+	'R': {0x1E, 0x11, 0x11, 0x1E, 0x14, 0x12, 0x11},
+	'S': {0x0F, 0x10, 0x10, 0x0E, 0x01, 0x01, 0x1E},
+	'T': {0x1F, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04},
+	'U': {0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'V': {0x11, 0x11, 0x11, 0x11, 0x11, 0x0A, 0x04},
+	'W': {0x11, 0x11, 0x11, 0x15, 0x15, 0x15, 0x0A},
+	'X': {0x11, 0x11, 0x0A, 0x04, 0x0A, 0x11, 0x11},
+	'Y': {0x11, 0x11, 0x11, 0x0A, 0x04, 0x04, 0x04},
+	'Z': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x10, 0x1F},
+	'.': {0x00, 0x00, 0x00, 0x00, 0x00, 0x0C, 0x0C},
+	',': {0x00, 0x00, 0x00, 0x00, 0x0C, 0x04, 0x08},
+	'-': {0x00, 0x00, 0x00, 0x1F, 0x00, 0x00, 0x00},
+	'+': {0x00, 0x04, 0x04, 0x1F, 0x04, 0x04, 0x00},
+	'=': {0x00, 0x00, 0x1F, 0x00, 0x1F, 0x00, 0x00},
+	'(': {0x02, 0x04, 0x08, 0x08, 0x08, 0x04, 0x02},
+	')': {0x08, 0x04, 0x02, 0x02, 0x02, 0x04, 0x08},
+	'[': {0x0E, 0x08, 0x08, 0x08, 0x08, 0x08, 0x0E},
+	']': {0x0E, 0x02, 0x02, 0x02, 0x02, 0x02, 0x0E},
+	'/': {0x00, 0x01, 0x02, 0x04, 0x08, 0x10, 0x00},
+	'%': {0x18, 0x19, 0x02, 0x04, 0x08, 0x13, 0x03},
+	':': {0x00, 0x0C, 0x0C, 0x00, 0x0C, 0x0C, 0x00},
+	'_': {0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x1F},
+	'°': {0x0C, 0x12, 0x12, 0x0C, 0x00, 0x00, 0x00},
+	'²': {0x0C, 0x02, 0x04, 0x08, 0x0E, 0x00, 0x00},
+}
+
+// rasterCanvas draws on an image, lines without antialiasing
+type rasterCanvas struct {
+	img *image.RGBA
+}
+
+func (r *rasterCanvas) rect(x, y, w, h float64, c color.RGBA) {
+	for py := int(math.Round(y)); py < int(math.Round(y+h)); py++ {
+		for px := int(math.Round(x)); px < int(math.Round(x+w)); px++ {
+			r.img.SetRGBA(px, py, c)
+		}
+	}
+}
+
+// line steps along the longest axis, drawing a square brush of the given width at every pixel
+func (r *rasterCanvas) line(x1, y1, x2, y2 float64, c color.RGBA, width float64) {
+	steps := int(math.Ceil(math.Max(math.Abs(x2-x1), math.Abs(y2-y1))))
+	brush := max(1, int(math.Round(width)))
+	offset := (brush - 1) / 2
+	for i := 0; i <= steps; i++ {
+		t := 0.0
+		if steps > 0 {
+			t = float64(i) / float64(steps)
+		}
+		px := int(math.Round(x1+(x2-x1)*t)) - offset
+		py := int(math.Round(y1+(y2-y1)*t)) - offset
+		for dy := 0; dy < brush; dy++ {
+			for dx := 0; dx < brush; dx++ {
+				r.img.SetRGBA(px+dx, py+dy, c)
+			}
+		}
+	}
+}
+
+func (r *rasterCanvas) polyline(xs, ys []float64, c color.RGBA, width float64) {
+	for i := 1; i < len(xs); i++ {
+		r.line(xs[i-1], ys[i-1], xs[i], ys[i], c, width)
+	}
+}
+
+func (r *rasterCanvas) text(x, y float64, text string, anchor string, vertical bool, c color.RGBA) {
+	length := float64(len([]rune(text))*glyphAdvance - 1)
+	start := 0.0
+	switch anchor {
+	case "middle":
+		start = -length / 2
+	case "end":
+		start = -length
+	}
+
+	// (u, v) are the coordinates along and across the text, rotated for vertical text
+	plot := func(u, v int) {
+		if vertical {
+			r.img.SetRGBA(int(x)+v, int(y)-u, c)
+		} else {
+			r.img.SetRGBA(int(x)+u, int(y)+v, c)
+		}
+	}
+
+	u0 := int(math.Round(start))
+	for i, char := range []rune(text) {
+		glyph := font[unicode.ToUpper(char)]
+		for row := 0; row < glyphHeight; row++ {
+			for col := 0; col < glyphWidth; col++ {
+				if glyph[row]&(1<<(glyphWidth-1-col)) != 0 {
+					plot(u0+i*glyphAdvance+col, row-glyphHeight/2)
+				}
+			}
+		}
+	}
+}
+
+// WritePNG writes the figure as a PNG image
+func (f Figure) WritePNG(w io.Writer) error {
+	width, height := f.size()
+	canvas := &rasterCanvas{img: image.NewRGBA(image.Rect(0, 0, width, height))}
+	f.draw(canvas)
+	return png.Encode(w, canvas.img)
+}
@@ -0,0 +1,74 @@
+package plot
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+)
+
+// svgCanvas writes every drawing call as an SVG element
+type svgCanvas struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (s *svgCanvas) printf(format string, args ...any) {
+	if s.err == nil {
+		_, s.err = fmt.Fprintf(s.w, format, args...)
+	}
+}
+
+func (s *svgCanvas) rect(x, y, w, h float64, c color.RGBA) {
+	s.printf("<rect x=\"%.1f\" y=\"%.1f\" width=\"%.1f\" height=\"%.1f\" fill=\"%s\"/>\n", x, y, w, h, svgColor(c))
+}
+
+func (s *svgCanvas) line(x1, y1, x2, y2 float64, c color.RGBA, width float64) {
+	s.printf("<line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\" stroke=\"%s\" stroke-width=\"%g\"/>\n",
+		x1, y1, x2, y2, svgColor(c), width)
+}
+
+func (s *svgCanvas) polyline(xs, ys []float64, c color.RGBA, width float64) {
+	points := make([]string, len(xs))
+	for i := range xs {
+		points[i] = fmt.Sprintf("%.1f,%.1f", xs[i], ys[i])
+	}
+	s.printf("<polyline points=\"%s\" fill=\"none\" stroke=\"%s\" stroke-width=\"%g\" stroke-linejoin=\"round\"/>\n",
+		strings.Join(points, " "), svgColor(c), width)
+}
+
+func (s *svgCanvas) text(x, y float64, text string, anchor string, vertical bool, c color.RGBA) {
+	if text == "" {
+		return
+	}
+	var escaped strings.Builder
+	_ = xml.EscapeText(&escaped, []byte(text))
+
+	transform := ""
+	if vertical {
+		transform = fmt.Sprintf(" transform=\"rotate(-90 %.1f %.1f)\"", x, y)
+	}
+	s.printf("<text x=\"%.1f\" y=\"%.1f\" text-anchor=\"%s\" dominant-baseline=\"middle\" fill=\"%s\"%s>%s</text>\n",
+		x, y, anchor, svgColor(c), transform, escaped.String())
+}
+
+func svgColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// WriteSVG writes the figure as an SVG document
+func (f Figure) WriteSVG(w io.Writer) error {
+	width, height := f.size()
+	canvas := &svgCanvas{w: bufio.NewWriter(w)}
+	canvas.printf("<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" font-family=\"sans-serif\" font-size=\"12\">\n",
+		width, height, width, height)
+	f.draw(canvas)
+	canvas.printf("</svg>\n")
+
+	if canvas.err != nil {
+		return canvas.err
+	}
+	return canvas.w.Flush()
+}
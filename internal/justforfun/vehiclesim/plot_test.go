@@ -0,0 +1,110 @@
+package vehiclesim
+
+import (
+	"go-playground/internal/justforfun/vehiclesim/engine"
+	"go-playground/internal/justforfun/vehiclesim/route"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestTorqueCurves checks the sampled power matches the torque and peaks after it, full throttle above part throttle
+func TestTorqueCurves(t *testing.T) {
+	curves := engineTorqueCurves(engine.NewEngine())
+	if len(curves) != len(torqueCurveThrottles) {
+		t.Fatalf("got %d curves, want %d", len(curves), len(torqueCurveThrottles))
+	}
+
+	peak := func(values []float64) int {
+		best := 0
+		for i, value := range values {
+			if value > values[best] {
+				best = i
+			}
+		}
+		return best
+	}
+	for i, curve := range curves {
+		for j, rpm := range curve.RPM {
+			if want := curve.Torque[j] * rpm * 2 * math.Pi / 60 / 1000; math.Abs(curve.PowerKW[j]-want) > 1e-3 {
+				t.Fatalf("power at %.0f rpm = %.3f kW, want %.3f", rpm, curve.PowerKW[j], want)
+			}
+		}
+		if peak(curve.PowerKW) <= peak(curve.Torque) {
+			t.Errorf("throttle %.2f: power peaks at %.0f rpm, before the torque at %.0f rpm",
+				curve.Throttle, curve.RPM[peak(curve.PowerKW)], curve.RPM[peak(curve.Torque)])
+		}
+		if i > 0 && curve.Torque[peak(curve.Torque)] <= curves[i-1].Torque[peak(curves[i-1].Torque)] {
+			t.Errorf("throttle %.2f does not give more torque than %.2f", curve.Throttle, curves[i-1].Throttle)
+		}
+	}
+
+	files, err := SaveTorqueCurvePlots(t.TempDir(), PlotFormats)
+	if err != nil {
+		t.Fatalf("SaveTorqueCurvePlots: %v", err)
+	}
+	for _, file := range files {
+		if info, err := os.Stat(file); err != nil || info.Size() == 0 {
+			t.Errorf("plot %s not written: %v", file, err)
+		}
+	}
+}
+
+// TestPlotRecording plots a recorded run and checks every channel gets a chart of the whole replay
+func TestPlotRecording(t *testing.T) {
+	vehicle, err := NewVehicle("vehicle-001", DefaultVehicleSpec(), 7, route.Default(), defaultStart, 0, time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("NewVehicle: %v", err)
+	}
+
+	dir := t.TempDir()
+	recordingPath := filepath.Join(dir, "run.jsonl")
+	file, err := os.Create(recordingPath)
+	if err != nil {
+		t.Fatalf("creating recording: %v", err)
+	}
+	recorder := NewInputRecorder(NewScriptedDriver(NormalProfile()), file)
+	for i := 0; i < 300; i++ {
+		recorder.Drive(vehicle, 0.1)
+		vehicle.Step(0.1)
+	}
+	if err := recorder.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	file.Close()
+
+	recording, err := LoadRecording(recordingPath)
+	if err != nil {
+		t.Fatalf("LoadRecording: %v", err)
+	}
+	var snapshots []Snapshot
+	if err := recording.Replay(5, func(snapshot Snapshot, last bool) { snapshots = append(snapshots, snapshot) }); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	figure, err := RunFigure("run", snapshots, DefaultRunChannels)
+	if err != nil {
+		t.Fatalf("RunFigure: %v", err)
+	}
+	if len(figure.Charts) != len(DefaultRunChannels) {
+		t.Fatalf("got %d charts, want %d", len(figure.Charts), len(DefaultRunChannels))
+	}
+	times := figure.Charts[0].Series[0].X
+	if last := recording.Commands[len(recording.Commands)-1].T + 5; math.Abs(times[len(times)-1]-last) > 0.15 {
+		t.Errorf("run plotted up to %.1f s, want %.1f s", times[len(times)-1], last)
+	}
+
+	outputs := []string{filepath.Join(dir, "run.svg"), filepath.Join(dir, "run.png")}
+	if err := PlotRecording(recordingPath, 5, DefaultRunChannels, outputs); err != nil {
+		t.Fatalf("PlotRecording: %v", err)
+	}
+	for _, output := range outputs {
+		if _, err := os.Stat(output); err != nil {
+			t.Errorf("plot %s not written: %v", output, err)
+		}
+	}
+	if err := PlotRecording(recordingPath, 5, []string{"warp_factor"}, outputs); err == nil {
+		t.Error("unknown channel: expected an error")
+	}
+}
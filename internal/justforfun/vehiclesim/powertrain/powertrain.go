@@ -38,7 +38,7 @@ func (pc *PowertrainController) Update(clutchPosition float64, deltaTime float64
 	pc.engine.Update(clutchPosition, deltaTime)
 
 	// Propagar datos del motor a la caja de cambios
-	pc.gearbox.Update(pc.engine.GetRPM(), pc.engine.GetTorque(), deltaTime)
+	pc.gearbox.Update(pc.engine.GetSpeed(), pc.engine.GetTorque(), deltaTime)
 }
 
 // GetEngineData retorna datos telemétricos del motor
@@ -0,0 +1,352 @@
+package vehiclesim
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"go-playground/internal/justforfun/vehiclesim/cruise"
+	"go-playground/internal/justforfun/vehiclesim/engine"
+	"go-playground/internal/justforfun/vehiclesim/units"
+	"io"
+	"os"
+)
+
+// recordingVersion is bumped whenever the recording layout changes
+const recordingVersion = 2
+
+// Inputs a driver can command, the Input of a Command
+const (
+	InputThrottle      = "throttle"       // Accelerator position (0.0 to 1.0)
+	InputClutch        = "clutch"         // Clutch position (0.0 = pressed, 1.0 = released)
+	InputGear          = "gear"           // Selected gear, 0 = neutral
+	InputBrake         = "brake"          // Brake pedal position (0.0 to 1.0)
+	InputSteering      = "steering"       // Steering wheel angle in degrees, steering by hand
+	InputFollowRoad    = "follow_road"    // Hand the steering back to the road following
+	InputLaunchControl = "launch_control" // 1 arms the launch control, 0 disarms it
+	InputRevMatch      = "rev_match"      // Target RPM of a downshift blip
+	InputCruise        = "cruise"         // Cruise control state (see cruise.State), Value is the set speed
+)
+
+// Command is one driver input with the simulated time at which it was given
+type Command struct {
+	T     float64      `json:"t"` // Vehicle elapsed seconds when the driver gave the command
+	Input string       `json:"input"`
+	Value float64      `json:"value"`
+	State cruise.State `json:"state,omitempty"` // Only for InputCruise
+}
+
+// RecordingHeader is the first line of a recording, the vehicle the commands were given to
+type RecordingHeader struct {
+	Version    int
+	Checkpoint Checkpoint
+}
+
+// controls are the inputs under the command of the driver
+type controls struct {
+	throttle       float64
+	clutch         float64
+	gear           int
+	brake          float64
+	steering       float64
+	manualSteering bool
+	launch         engine.LaunchState
+	revMatchRPM    float64
+	cruise         cruise.State
+	cruiseSetKMH   float64
+}
+
+func readControls(v *Vehicle) controls {
+	cruiseData := v.CruiseControl.GetData()
+	return controls{
+		throttle:       v.Engine.GetAcceleratorPos(),
+		clutch:         v.Gearbox.ClutchPosition,
+		gear:           v.Gearbox.GetCurrentGear(),
+		brake:          v.brakePedal,
+		steering:       v.Steering.GetData().SteeringWheelAngle,
+		manualSteering: v.manualSteering,
+		launch:         v.Engine.GetLaunchControl().State(),
+		revMatchRPM:    v.Engine.GetRevMatchSpeed().RPM(),
+		cruise:         v.CruiseControl.GetState(),
+		cruiseSetKMH:   cruiseData.SetSpeedKMH,
+	}
+}
+
+// commands returns the commands that turn the before controls into the after controls
+func (after controls) commands(before controls, t float64) []Command {
+	var commands []Command
+	add := func(changed bool, command Command) {
+		if changed {
+			command.T = t
+			commands = append(commands, command)
+		}
+	}
+
+	add(after.throttle != before.throttle, Command{Input: InputThrottle, Value: after.throttle})
+	add(after.clutch != before.clutch, Command{Input: InputClutch, Value: after.clutch})
+	add(after.gear != before.gear, Command{Input: InputGear, Value: float64(after.gear)})
+	add(after.brake != before.brake, Command{Input: InputBrake, Value: after.brake})
+
+	if after.manualSteering {
+		add(!before.manualSteering || after.steering != before.steering, Command{Input: InputSteering, Value: after.steering})
+	} else {
+		add(before.manualSteering, Command{Input: InputFollowRoad})
+	}
+
+	// The engine moves the launch control through its phases, the driver only arms or disarms it
+	switch {
+	case after.launch == engine.LaunchArmed:
+		add(before.launch != engine.LaunchArmed, Command{Input: InputLaunchControl, Value: 1})
+	case after.launch == engine.LaunchOff:
+		add(before.launch != engine.LaunchOff, Command{Input: InputLaunchControl, Value: 0})
+	}
+
+	// The engine ends the blip by itself
+	if after.revMatchRPM > 0 {
+		add(after.revMatchRPM != before.revMatchRPM, Command{Input: InputRevMatch, Value: after.revMatchRPM})
+	}
+
+	add(after.cruise != before.cruise || after.cruiseSetKMH != before.cruiseSetKMH,
+		Command{Input: InputCruise, Value: after.cruiseSetKMH, State: after.cruise})
+
+	return commands
+}
+
+// apply gives the command to the vehicle
+func (c Command) apply(v *Vehicle) error {
+	switch c.Input {
+	case InputThrottle:
+		v.Engine.SetAcceleratorPos(c.Value)
+	case InputClutch:
+		v.Gearbox.SetClutch(c.Value)
+	case InputGear:
+		if !v.Gearbox.SetGear(int(c.Value)) {
+			return fmt.Errorf("no gear %d, the gearbox has %d", int(c.Value), v.Gearbox.GetMaxGear())
+		}
+	case InputBrake:
+		v.SetBrakePedal(c.Value)
+	case InputSteering:
+		v.Steer(c.Value)
+	case InputFollowRoad:
+		v.FollowRoad()
+	case InputLaunchControl:
+		if c.Value > 0 {
+			v.Engine.GetLaunchControl().Arm()
+		} else {
+			v.Engine.GetLaunchControl().Disarm()
+		}
+	case InputRevMatch:
+		v.Engine.StartRevMatch(units.RPM.Of(c.Value))
+	case InputCruise:
+		switch c.State {
+		case cruise.Engaged:
+			v.CruiseControl.SetSpeed(c.Value)
+		case cruise.Standby:
+			v.CruiseControl.Cancel()
+		default:
+			v.CruiseControl.SwitchOff()
+		}
+	default:
+		return fmt.Errorf("unknown input %q", c.Input)
+	}
+	return nil
+}
+
+// InputRecorder drives with another driver and writes every input it changes as one JSON line
+// The first Drive writes the checkpoint of the vehicle, which holds the state of every input
+type InputRecorder struct {
+	driver  Driver
+	writer  *bufio.Writer
+	encoder *json.Encoder
+	started bool
+	err     error
+}
+
+// NewInputRecorder records the commands of driver to w
+func NewInputRecorder(driver Driver, w io.Writer) *InputRecorder {
+	writer := bufio.NewWriter(w)
+	return &InputRecorder{
+		driver:  driver,
+		writer:  writer,
+		encoder: json.NewEncoder(writer),
+	}
+}
+
+// Drive implements Driver
+func (r *InputRecorder) Drive(v *Vehicle, deltaTime float64) {
+	before := readControls(v)
+	if !r.started {
+		r.started = true
+		r.write(RecordingHeader{Version: recordingVersion, Checkpoint: v.Checkpoint()})
+	}
+
+	r.driver.Drive(v, deltaTime)
+
+	for _, command := range readControls(v).commands(before, v.Elapsed()) {
+		r.write(command)
+	}
+}
+
+func (r *InputRecorder) write(line any) {
+	if r.err == nil {
+		r.err = r.encoder.Encode(line)
+	}
+}
+
+// Flush writes the buffered commands and returns the first error of the recording
+func (r *InputRecorder) Flush() error {
+	if r.err != nil {
+		return fmt.Errorf("error recording inputs: %v", r.err)
+	}
+	if err := r.writer.Flush(); err != nil {
+		return fmt.Errorf("error recording inputs: %v", err)
+	}
+	return nil
+}
+
+// Recording is a recorded run, the vehicle at the start and the commands of its driver
+type Recording struct {
+	Header   RecordingHeader
+	Commands []Command
+}
+
+// ReadRecording parses a recording written by InputRecorder
+func ReadRecording(r io.Reader) (Recording, error) {
+	var recording Recording
+	decoder := json.NewDecoder(r)
+	if err := decoder.Decode(&recording.Header); err != nil {
+		return recording, fmt.Errorf("error reading recording header: %v", err)
+	}
+	if recording.Header.Version != recordingVersion {
+		return recording, fmt.Errorf("recording version %d is not supported, expected %d", recording.Header.Version, recordingVersion)
+	}
+
+	for {
+		var command Command
+		err := decoder.Decode(&command)
+		if err == io.EOF {
+			return recording, nil
+		}
+		if err != nil {
+			return recording, fmt.Errorf("error reading command %d: %v", len(recording.Commands)+1, err)
+		}
+		recording.Commands = append(recording.Commands, command)
+	}
+}
+
+// LoadRecording reads a recording file
+func LoadRecording(path string) (Recording, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Recording{}, fmt.Errorf("error opening recording: %v", err)
+	}
+	defer file.Close()
+
+	return ReadRecording(file)
+}
+
+// Vehicle restores the vehicle as it was when the recording started
+func (r Recording) Vehicle() (*Vehicle, error) {
+	return RestoreVehicle(r.Header.Checkpoint)
+}
+
+// ReplayDriver gives the recorded commands back at their simulated time
+type ReplayDriver struct {
+	commands []Command
+	next     int
+}
+
+// NewReplayDriver replays the given commands, they must be sorted by time
+func NewReplayDriver(commands []Command) *ReplayDriver {
+	return &ReplayDriver{commands: commands}
+}
+
+// Drive implements Driver
+// Every command recorded up to the current simulated time is applied, an unknown input stops the replay
+func (d *ReplayDriver) Drive(v *Vehicle, deltaTime float64) {
+	for d.next < len(d.commands) && d.commands[d.next].T <= v.Elapsed() {
+		if err := d.commands[d.next].apply(v); err != nil {
+			fmt.Printf("Stopping replay at %.1f s: %v\n", v.Elapsed(), err)
+			d.next = len(d.commands)
+			return
+		}
+		d.next++
+	}
+}
+
+// Done reports whether every command has been given
+func (d *ReplayDriver) Done() bool {
+	return d.next == len(d.commands)
+}
+
+// RerunRecording replays a recording headless, as fast as possible, printing the status every 10 simulated seconds
+// The run goes on for afterS seconds once the last command has been given
+func RerunRecording(path string, afterS float64) error {
+	recording, err := LoadRecording(path)
+	if err != nil {
+		return err
+	}
+
+	const stepsPerReport = 100
+	step := 0
+	return recording.Replay(afterS, func(snapshot Snapshot, last bool) {
+		step++
+		for _, shiftEvent := range snapshot.Shifts {
+			fmt.Print(shiftEvent.String())
+		}
+		if step%stepsPerReport == 0 || last {
+			printSimulationStatus(snapshot)
+		}
+	})
+}
+
+// PlotRecording replays a recording like RerunRecording and plots the given channels versus time in every output file
+func PlotRecording(path string, afterS float64, channelNames []string, outputs []string) error {
+	recording, err := LoadRecording(path)
+	if err != nil {
+		return err
+	}
+
+	var snapshots []Snapshot
+	err = recording.Replay(afterS, func(snapshot Snapshot, last bool) {
+		snapshots = append(snapshots, snapshot)
+	})
+	if err != nil {
+		return err
+	}
+
+	figure, err := RunFigure(recording.Header.Checkpoint.VehicleID, snapshots, channelNames)
+	if err != nil {
+		return err
+	}
+	for _, output := range outputs {
+		if err := figure.Save(output); err != nil {
+			return err
+		}
+		fmt.Printf("Run plotted in %s\n", output)
+	}
+	return nil
+}
+
+// Replay restores the recorded vehicle and steps it under the recorded commands in 0.1 s steps, as fast as possible,
+// until afterS seconds after the last command. onStep receives every snapshot, last is true for the final one.
+func (r Recording) Replay(afterS float64, onStep func(snapshot Snapshot, last bool)) error {
+	vehicle, err := r.Vehicle()
+	if err != nil {
+		return err
+	}
+
+	end := vehicle.Elapsed() + afterS
+	if n := len(r.Commands); n > 0 {
+		end = r.Commands[n-1].T + afterS
+	}
+	fmt.Printf("Replaying %d inputs of %s from %.1f s to %.1f s\n", len(r.Commands), vehicle.ID, vehicle.Elapsed(), end)
+
+	driver := NewReplayDriver(r.Commands)
+	const stepSize = 0.1
+	for vehicle.Elapsed() < end {
+		driver.Drive(vehicle, stepSize)
+		snapshot := vehicle.Step(stepSize)
+		onStep(snapshot, vehicle.Elapsed() >= end)
+	}
+	return nil
+}
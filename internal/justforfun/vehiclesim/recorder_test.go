@@ -0,0 +1,77 @@
+package vehiclesim
+
+import (
+	"bytes"
+	"go-playground/internal/justforfun/vehiclesim/route"
+	"testing"
+	"time"
+)
+
+// manualDriver drives with the normal profile, then brakes and steers by hand for a few seconds
+type manualDriver struct {
+	scripted *ScriptedDriver
+}
+
+func (d *manualDriver) Drive(v *Vehicle, deltaTime float64) {
+	d.scripted.Drive(v, deltaTime)
+
+	switch t := v.Elapsed(); {
+	case t >= 70 && t < 72:
+		v.SetBrakePedal(0.4)
+	case t >= 72 && t < 75:
+		v.SetBrakePedal(0)
+		v.Steer(30 * (t - 72))
+	case t >= 75:
+		v.FollowRoad()
+	}
+}
+
+// TestRecordReplay records a run and checks replaying its inputs on the same vehicle gives the same telemetry
+func TestRecordReplay(t *testing.T) {
+	vehicle, err := NewVehicle("vehicle-001", DefaultVehicleSpec(), 7, route.Default(), defaultStart, 0, time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("NewVehicle: %v", err)
+	}
+
+	var file bytes.Buffer
+	recorder := NewInputRecorder(&manualDriver{scripted: NewScriptedDriver(NormalProfile())}, &file)
+	var want []Snapshot
+	for i := 0; i < 1200; i++ {
+		recorder.Drive(vehicle, 0.1)
+		want = append(want, vehicle.Step(0.1))
+	}
+	if err := recorder.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	recording, err := ReadRecording(&file)
+	if err != nil {
+		t.Fatalf("ReadRecording: %v", err)
+	}
+	inputs := map[string]bool{}
+	for _, command := range recording.Commands {
+		inputs[command.Input] = true
+	}
+	for _, input := range []string{InputThrottle, InputClutch, InputGear, InputBrake, InputSteering, InputFollowRoad, InputLaunchControl, InputCruise} {
+		if !inputs[input] {
+			t.Errorf("no %s command recorded", input)
+		}
+	}
+
+	replayed, err := recording.Vehicle()
+	if err != nil {
+		t.Fatalf("restoring the recorded vehicle: %v", err)
+	}
+	driver := NewReplayDriver(recording.Commands)
+	for i, snapshot := range want {
+		driver.Drive(replayed, 0.1)
+		got := replayed.Step(0.1)
+		if got.Engine != snapshot.Engine || got.Wheels != snapshot.Wheels || got.Steering != snapshot.Steering || got.CruiseControl != snapshot.CruiseControl {
+			t.Fatalf("step %d diverged:\ngot  %s%s%s\nwant %s%s%s", i, got.Engine.String(), got.Wheels.String(), got.CruiseControl.String(),
+				snapshot.Engine.String(), snapshot.Wheels.String(), snapshot.CruiseControl.String())
+		}
+	}
+	if !driver.Done() {
+		t.Error("commands left after the replay")
+	}
+}
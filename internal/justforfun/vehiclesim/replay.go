@@ -0,0 +1,172 @@
+package vehiclesim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// ReplayConfig sets how a telemetry file is sent to the sinks again
+type ReplayConfig struct {
+	TimeScale float64 // Recorded seconds per wall clock second (0 = as fast as possible)
+	Now       bool    // Shift the timestamps so that the run starts now and follows the time scale, needs a TimeScale
+
+	// Channels keeps only these channels in the sinks that can leave fields out (InfluxDB and
+	// Sparkplug B), empty for all. Kafka records, CAN frames and the VEHICLESIM_TELEMETRY_FILE
+	// recording have a fixed layout and keep every value.
+	Channels []string
+}
+
+// ReplayStats summarizes a replay
+type ReplayStats struct {
+	Snapshots   int64         // Snapshots handed to the sink
+	WriteErrors int64         // Snapshots the sink failed to write
+	WallTime    time.Duration // Real time spent
+}
+
+// String implements the String interface for human-readable formatting
+func (s ReplayStats) String() string {
+	return fmt.Sprintf("Replay [Snapshots: %d, WriteErrors: %d, WallTime: %s]\n",
+		s.Snapshots,
+		s.WriteErrors,
+		s.WallTime.Round(time.Millisecond))
+}
+
+// ReplayTelemetry sends a telemetry file recorded with VEHICLESIM_TELEMETRY_FILE to the sinks of the
+// simulation, without running the physics, until the end of the file or Ctrl+C
+func ReplayTelemetry(path string, config ReplayConfig) error {
+	if err := config.validate(); err != nil {
+		return err
+	}
+	if _, err := replayChannels(config.Channels); err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening telemetry file: %v", err)
+	}
+	defer file.Close()
+	reader, err := NewTelemetryReader(file, TelemetryFileFormat(path))
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", path, err)
+	}
+
+	// Creating the output file would truncate the file being replayed
+	if output := os.Getenv("VEHICLESIM_TELEMETRY_FILE"); output != "" && samePath(output, path) {
+		return fmt.Errorf("VEHICLESIM_TELEMETRY_FILE is the replayed file %s", path)
+	}
+	sink, err := loadSinks()
+	if err != nil {
+		return err
+	}
+	defer sink.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("Replaying %s\n", path)
+	stats, err := RunReplay(ctx, reader, config, sink)
+	fmt.Print(stats.String())
+	return err
+}
+
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// RunReplay writes every snapshot of the reader to the sink, paced by the recorded timestamps
+func RunReplay(ctx context.Context, reader *TelemetryReader, config ReplayConfig, sink TelemetrySink) (stats ReplayStats, err error) {
+	if err := config.validate(); err != nil {
+		return stats, err
+	}
+	selected, err := replayChannels(config.Channels)
+	if err != nil {
+		return stats, err
+	}
+
+	started := time.Now()
+	defer func() { stats.WallTime = time.Since(started) }()
+
+	var first time.Time
+	for {
+		snapshot, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return stats, nil
+		}
+		if err != nil {
+			return stats, fmt.Errorf("error reading telemetry: %v", err)
+		}
+
+		// Recorded time since the first snapshot, on the wall clock
+		if first.IsZero() {
+			first = snapshot.Time
+		}
+		offset := snapshot.Time.Sub(first)
+		if config.TimeScale > 0 {
+			offset = time.Duration(float64(offset) / config.TimeScale)
+			if wait := time.Until(started.Add(offset)); wait > 0 {
+				select {
+				case <-ctx.Done():
+					return stats, nil
+				case <-time.After(wait):
+				}
+			}
+		}
+		if ctx.Err() != nil {
+			return stats, nil
+		}
+
+		if config.Now {
+			snapshot.Time = started.Add(offset)
+		}
+		if selected != nil {
+			fields := []TelemetryField{}
+			for _, field := range snapshot.schemaFields() {
+				if selected[field.Channel] {
+					fields = append(fields, field)
+				}
+			}
+			snapshot.fields = fields
+		}
+
+		if err := sink.Write(snapshot); err != nil {
+			stats.WriteErrors++
+		}
+		stats.Snapshots++
+	}
+}
+
+// validate rejects the time scales that cannot pace a replay
+func (c ReplayConfig) validate() error {
+	if c.TimeScale < 0 {
+		return fmt.Errorf("invalid time scale %g, it cannot be negative", c.TimeScale)
+	}
+	// As fast as possible the shifted timestamps would run ahead of the wall clock
+	if c.Now && c.TimeScale == 0 {
+		return fmt.Errorf("replaying with the timestamps shifted to now needs a time scale above 0")
+	}
+	return nil
+}
+
+// replayChannels checks the channel names against TelemetrySchema, nil keeps every channel
+func replayChannels(names []string) (map[string]bool, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	selected := make(map[string]bool, len(names))
+	for _, name := range names {
+		if schemaField(name) == nil {
+			return nil, fmt.Errorf("unknown channel %q, see vehiclesim schema", name)
+		}
+		selected[name] = true
+	}
+	return selected, nil
+}
@@ -0,0 +1,58 @@
+package vehiclesim
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+// TestRunReplay sends a recorded run to a sink with the timestamps shifted and the channels filtered
+func TestRunReplay(t *testing.T) {
+	var file bytes.Buffer
+	recorded := recordTelemetry(t, TelemetryJSONL, &file)
+	reader, err := NewTelemetryReader(&file, TelemetryJSONL)
+	if err != nil {
+		t.Fatalf("NewTelemetryReader: %v", err)
+	}
+
+	// 5 recorded seconds at 50 times the speed
+	sink := newMemorySink()
+	before := time.Now()
+	config := ReplayConfig{TimeScale: 50, Now: true, Channels: []string{"rpm", "current_gear"}}
+	stats, err := RunReplay(context.Background(), reader, config, sink)
+	if err != nil {
+		t.Fatalf("RunReplay: %v", err)
+	}
+	if stats.Snapshots != int64(len(recorded)) || stats.WriteErrors != 0 || sink.count != len(recorded) {
+		t.Fatalf("%s with %d snapshots in the sink, want %d snapshots", stats.String(), sink.count, len(recorded))
+	}
+	recordedTime := recorded[len(recorded)-1].Time.Sub(recorded[0].Time)
+	if stats.WallTime < recordedTime/50 || stats.WallTime > recordedTime {
+		t.Errorf("replayed %s of telemetry in %s, want about %s", recordedTime, stats.WallTime, recordedTime/50)
+	}
+
+	last := sink.last["vehicle-001"]
+	if last.Time.Before(before) || last.Time.After(time.Now()) {
+		t.Errorf("last snapshot at %s, want between %s and now", last.Time, before)
+	}
+	if last.Engine.RPM != recorded[len(recorded)-1].Engine.RPM {
+		t.Errorf("last snapshot at %.1f rpm, want %.1f rpm", last.Engine.RPM, recorded[len(recorded)-1].Engine.RPM)
+	}
+	if points := snapshotPoints(last); len(points) != 2 {
+		t.Errorf("%d InfluxDB points, want the engine and gearbox measurements", len(points))
+	}
+	if metrics := SparkplugMetrics(last); len(metrics) != 2 {
+		t.Errorf("%d Sparkplug B metrics, want rpm and current_gear", len(metrics))
+	}
+
+	for _, config := range []ReplayConfig{
+		{Channels: []string{"warp_speed"}},
+		{TimeScale: -1},
+		{TimeScale: 0, Now: true},
+	} {
+		if _, err := RunReplay(context.Background(), reader, config, sink); err == nil {
+			t.Errorf("RunReplay with %+v: expected an error", config)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package route
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// closedElevationToleranceM is the elevation difference between the end and the start of a closed route
+const closedElevationToleranceM = 0.5
+
+//go:embed default_route.csv
+var defaultRouteCSV string
+
+// Point is a sample of the route at a given distance from the start
+type Point struct {
+	DistanceM  float64 // Distance from the start in meters
+	ElevationM float64 // Elevation in meters
+	Curvature  float64 // 1/radius in 1/m, positive turns left, 0 is straight
+	Mu         float64 // Friction coefficient of the surface
+}
+
+// Segment is the state of the road at the current position
+type Segment struct {
+	DistanceM  float64 // Position along the route in meters
+	ElevationM float64
+	Grade      float64 // Radians, positive = uphill
+	Curvature  float64
+	Mu         float64
+}
+
+// Route is a road described by points indexed by distance
+type Route struct {
+	Name   string
+	Points []Point
+	Loop   bool // Start again from the beginning once the end is reached
+}
+
+// Default returns the route bundled with the simulator
+func Default() *Route {
+	r, err := Parse("default", strings.NewReader(defaultRouteCSV))
+	if err != nil {
+		panic(fmt.Sprintf("invalid bundled route: %v", err))
+	}
+	r.Loop = true
+	return r
+}
+
+// Straight returns a flat and dry straight of the given length, the test track of the performance runs
+func Straight(lengthM float64) *Route {
+	return &Route{
+		Name: "straight",
+		Points: []Point{
+			{DistanceM: 0, ElevationM: 0, Curvature: 0, Mu: 1},
+			{DistanceM: lengthM, ElevationM: 0, Curvature: 0, Mu: 1},
+		},
+	}
+}
+
+// LoadFile reads a route from a CSV file
+// Format: distance_m,elevation_m,curvature,mu with a header line
+func LoadFile(path string) (*Route, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening route file: %v", err)
+	}
+	defer file.Close()
+
+	return Parse(path, file)
+}
+
+// Parse reads a route in CSV format
+func Parse(name string, reader io.Reader) (*Route, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.Comment = '#'
+	csvReader.TrimLeadingSpace = true
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error reading route %s: %v", name, err)
+	}
+	if len(records) < 3 {
+		return nil, fmt.Errorf("route %s needs a header and at least two points", name)
+	}
+
+	points := make([]Point, 0, len(records)-1)
+	for i, record := range records[1:] {
+		if len(record) != 4 {
+			return nil, fmt.Errorf("route %s line %d: expected 4 columns, got %d", name, i+2, len(record))
+		}
+
+		values := make([]float64, 4)
+		for j, field := range record {
+			value, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("route %s line %d: invalid number %q", name, i+2, field)
+			}
+			values[j] = value
+		}
+		if values[3] <= 0 {
+			return nil, fmt.Errorf("route %s line %d: friction coefficient %v has to be positive", name, i+2, values[3])
+		}
+
+		points = append(points, Point{
+			DistanceM:  values[0],
+			ElevationM: values[1],
+			Curvature:  values[2],
+			Mu:         values[3],
+		})
+	}
+
+	sort.Slice(points, func(i, j int) bool { return points[i].DistanceM < points[j].DistanceM })
+	for i := 1; i < len(points); i++ {
+		if points[i].DistanceM == points[i-1].DistanceM {
+			return nil, fmt.Errorf("route %s: duplicated distance %.1f m", name, points[i].DistanceM)
+		}
+	}
+
+	return &Route{
+		Name:   name,
+		Points: points,
+	}, nil
+}
+
+// Closed reports whether the end of the route meets its start, so that it can loop without a step
+// in the elevation
+func (r *Route) Closed() bool {
+	first, last := r.Points[0], r.Points[len(r.Points)-1]
+	return math.Abs(last.ElevationM-first.ElevationM) <= closedElevationToleranceM
+}
+
+// Length returns the total length of the route in meters
+func (r *Route) Length() float64 {
+	return r.Points[len(r.Points)-1].DistanceM - r.Points[0].DistanceM
+}
+
+// At returns the road segment at the given distance from the start
+// Values are linearly interpolated between points. Past the end the last point is held on a
+// flat road, unless the route loops.
+func (r *Route) At(distanceM float64) Segment {
+	start := r.Points[0].DistanceM
+	position := distanceM
+	if r.Loop && r.Length() > 0 {
+		position = start + math.Mod(distanceM-start, r.Length())
+	}
+
+	// Index of the first point after the position
+	next := sort.Search(len(r.Points), func(i int) bool { return r.Points[i].DistanceM > position })
+	switch {
+	case next == 0:
+		next = 1
+	case next == len(r.Points):
+		next = len(r.Points) - 1
+	}
+	from := r.Points[next-1]
+	to := r.Points[next]
+
+	span := to.DistanceM - from.DistanceM
+	fraction := math.Max(0, math.Min(1, (position-from.DistanceM)/span))
+
+	grade := math.Atan2(to.ElevationM-from.ElevationM, span)
+	if position < start || position > r.Points[len(r.Points)-1].DistanceM {
+		grade = 0
+	}
+
+	return Segment{
+		DistanceM:  distanceM,
+		ElevationM: from.ElevationM + (to.ElevationM-from.ElevationM)*fraction,
+		Grade:      grade,
+		Curvature:  from.Curvature + (to.Curvature-from.Curvature)*fraction,
+		Mu:         from.Mu + (to.Mu-from.Mu)*fraction,
+	}
+}
@@ -0,0 +1,86 @@
+package route
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+const testRoute = `distance_m,elevation_m,curvature,mu
+0,100,0,1.0
+100,110,0.01,0.8
+200,110,0,0.6
+`
+
+// TestRouteInterpolation checks grade, curvature and grip along a small route
+func TestRouteInterpolation(t *testing.T) {
+	r, err := Parse("test", strings.NewReader(testRoute))
+	if err != nil {
+		t.Fatalf("unexpected error parsing route: %v", err)
+	}
+
+	if r.Length() != 200 {
+		t.Errorf("expected length 200 m, got %.1f", r.Length())
+	}
+
+	segment := r.At(50)
+	if math.Abs(segment.ElevationM-105) > 1e-9 {
+		t.Errorf("expected elevation 105 m at 50 m, got %.2f", segment.ElevationM)
+	}
+	if math.Abs(segment.Grade-math.Atan(0.1)) > 1e-9 {
+		t.Errorf("expected 10%% grade at 50 m, got %.4f rad", segment.Grade)
+	}
+	if math.Abs(segment.Curvature-0.005) > 1e-9 || math.Abs(segment.Mu-0.9) > 1e-9 {
+		t.Errorf("unexpected curvature %.4f or mu %.2f at 50 m", segment.Curvature, segment.Mu)
+	}
+
+	// Past the end the last point is held unless the route loops
+	if segment := r.At(500); segment.Mu != 0.6 || segment.Grade != 0 {
+		t.Errorf("expected last segment past the end, got %+v", segment)
+	}
+	climb, err := Parse("climb", strings.NewReader("distance_m,elevation_m,curvature,mu\n0,100,0,1\n100,110,0,1\n"))
+	if err != nil {
+		t.Fatalf("unexpected error parsing route: %v", err)
+	}
+	if segment := climb.At(150); segment.Grade != 0 || segment.ElevationM != 110 {
+		t.Errorf("expected a flat road at 110 m past the end of a climb, got %+v", segment)
+	}
+	r.Loop = true
+	if segment := r.At(250); math.Abs(segment.ElevationM-105) > 1e-9 {
+		t.Errorf("expected looped elevation 105 m at 250 m, got %.2f", segment.ElevationM)
+	}
+}
+
+// TestRouteParseErrors checks that malformed files are rejected
+func TestRouteParseErrors(t *testing.T) {
+	invalidRoutes := map[string]string{
+		"no points":      "distance_m,elevation_m,curvature,mu\n",
+		"bad number":     "distance_m,elevation_m,curvature,mu\n0,100,0,1\n10,abc,0,1\n",
+		"duplicated":     "distance_m,elevation_m,curvature,mu\n0,100,0,1\n0,101,0,1\n",
+		"missing column": "distance_m,elevation_m,curvature,mu\n0,100,0\n10,100,0\n",
+		"no grip":        "distance_m,elevation_m,curvature,mu\n0,100,0,1\n10,100,0,0\n",
+		"negative grip":  "distance_m,elevation_m,curvature,mu\n0,100,0,-0.5\n10,100,0,1\n",
+	}
+
+	for name, content := range invalidRoutes {
+		if _, err := Parse(name, strings.NewReader(content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// TestDefaultRoute checks the bundled route is valid
+func TestDefaultRoute(t *testing.T) {
+	r := Default()
+	if !r.Loop || !r.Closed() || r.Length() <= 0 {
+		t.Errorf("expected a closed looping route with positive length, got loop=%t closed=%t length=%.0f", r.Loop, r.Closed(), r.Length())
+	}
+
+	climb, err := Parse("climb", strings.NewReader("distance_m,elevation_m,curvature,mu\n0,100,0,1\n1000,150,0,1\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if climb.Closed() {
+		t.Error("a route ending 50 m above its start is not closed")
+	}
+}
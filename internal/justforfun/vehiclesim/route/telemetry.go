@@ -0,0 +1,37 @@
+package route
+
+import (
+	"fmt"
+	"math"
+)
+
+type Telemetry struct {
+	DistanceM    float64 // Traveled distance in meters
+	ElevationM   float64
+	Grade        float64 // Radians
+	GradePercent float64 // 100 × tan(Grade)
+	Curvature    float64 // 1/m
+	Mu           float64
+}
+
+// GetData returns the telemetry of the segment
+func (s Segment) GetData() Telemetry {
+	return Telemetry{
+		DistanceM:    s.DistanceM,
+		ElevationM:   s.ElevationM,
+		Grade:        s.Grade,
+		GradePercent: 100 * math.Tan(s.Grade),
+		Curvature:    s.Curvature,
+		Mu:           s.Mu,
+	}
+}
+
+// String implements the String interface for human-readable formatting
+func (d Telemetry) String() string {
+	return fmt.Sprintf("Route [Distance: %.0f m, Elevation: %.1f m, Grade: %.1f %%, Curvature: %.4f 1/m, Mu: %.2f]\n",
+		d.DistanceM,
+		d.ElevationM,
+		100*math.Tan(d.Grade),
+		d.Curvature,
+		d.Mu)
+}
@@ -0,0 +1,349 @@
+package vehiclesim
+
+import (
+	"fmt"
+	"go-playground/internal/justforfun/vehiclesim/route"
+	"go-playground/internal/justforfun/vehiclesim/units"
+	"time"
+)
+
+const (
+	scenarioStepSize   = 0.1     // Simulated seconds per step, the thresholds are interpolated between steps
+	testTrackLengthM   = 20000.0 // Long enough for a top speed run
+	topSpeedWindowS    = 10.0    // The top speed run ends when the speed stops rising over this window
+	topSpeedMinGainKMH = 0.5     // Minimum speed gain over the window to keep accelerating
+	shiftRPMBelowLimit = 400.0   // The performance driver shifts this far below the rev limiter
+)
+
+var standingStartMinKMH = units.MetersPerSecond.Of(launchSpeedMS).KilometersPerHour()
+
+// ScenarioMark is a speed or distance reported by a performance scenario
+type ScenarioMark struct {
+	Label     string
+	SpeedKMH  float64 // Passed when the wheel speed reaches it
+	DistanceM float64 // Passed when the distance from the start reaches it
+}
+
+// Scenario is a standard performance test, driven flat out on a flat and dry straight
+type Scenario struct {
+	Name        string
+	StartKMH    float64 // Speed that starts the clock, 0 for a standing start
+	HoldGear    int     // Gear of an in-gear run, 0 shifts through every gear
+	HoldFromKMH float64 // Speed at which HoldGear is selected, below StartKMH
+	Marks       []ScenarioMark
+	TopSpeed    bool    // Run until the speed stops rising
+	TimeoutS    float64 // Simulated seconds before giving up
+}
+
+// ZeroTo100 is the standing start to 100 km/h
+func ZeroTo100() Scenario {
+	return Scenario{
+		Name: "0-100",
+		Marks: []ScenarioMark{
+			{Label: "50 km/h", SpeedKMH: 50},
+			{Label: "100 km/h", SpeedKMH: 100},
+		},
+		TimeoutS: 60,
+	}
+}
+
+// InGear80To120 is the overtaking acceleration from 80 to 120 km/h without changing gear
+func InGear80To120(gear int) Scenario {
+	return Scenario{
+		Name:        fmt.Sprintf("80-120 gear %d", gear),
+		StartKMH:    80,
+		HoldGear:    gear,
+		HoldFromKMH: 70,
+		Marks: []ScenarioMark{
+			{Label: "100 km/h", SpeedKMH: 100},
+			{Label: "120 km/h", SpeedKMH: 120},
+		},
+		TimeoutS: 90,
+	}
+}
+
+// QuarterMile is the standing start over 402 m, the 60 ft and 1/8 mile splits are reported too
+func QuarterMile() Scenario {
+	return Scenario{
+		Name: "quarter-mile",
+		Marks: []ScenarioMark{
+			{Label: "60 ft", DistanceM: 18.288},
+			{Label: "1/8 mile", DistanceM: 201.168},
+			{Label: "1/4 mile", DistanceM: 402.336},
+		},
+		TimeoutS: 60,
+	}
+}
+
+// TopSpeed accelerates from a standing start until the speed stops rising
+func TopSpeed() Scenario {
+	return Scenario{
+		Name:     "top-speed",
+		Marks:    []ScenarioMark{{Label: "100 km/h", SpeedKMH: 100}},
+		TopSpeed: true,
+		TimeoutS: 300,
+	}
+}
+
+// ScenarioNames lists the scenarios known by ScenarioByName, in the order of a full test session
+var ScenarioNames = []string{"0-100", "80-120", "quarter-mile", "top-speed"}
+
+// ScenarioByName returns a standard scenario, the 80-120 run is done in 4th gear
+func ScenarioByName(name string) (Scenario, error) {
+	switch name {
+	case "0-100":
+		return ZeroTo100(), nil
+	case "80-120":
+		return InGear80To120(4), nil
+	case "quarter-mile":
+		return QuarterMile(), nil
+	case "top-speed":
+		return TopSpeed(), nil
+	default:
+		return Scenario{}, fmt.Errorf("unknown scenario %q, expected one of %v", name, ScenarioNames)
+	}
+}
+
+// Threshold is the state of the vehicle when the clock starts, stops or passes a mark
+type Threshold struct {
+	Label     string
+	TimeS     float64 // Seconds since the clock started
+	SpeedKMH  float64
+	DistanceM float64 // Meters since the clock started
+	Gear      int
+	RPM       float64
+}
+
+// String implements the String interface for human-readable formatting
+func (t Threshold) String() string {
+	return fmt.Sprintf("Threshold [%s: %.2f s, Speed: %.1f KMH, Distance: %.1f m, Gear: %d, RPM: %.0f]\n",
+		t.Label,
+		t.TimeS,
+		t.SpeedKMH,
+		t.DistanceM,
+		t.Gear,
+		t.RPM)
+}
+
+// ScenarioResult is the outcome of a performance scenario
+type ScenarioResult struct {
+	Scenario    string
+	Completed   bool
+	TimeS       float64 // Seconds from the start to the finish
+	Start       Threshold
+	Marks       []Threshold // One per mark passed, in order
+	Finish      Threshold
+	TopSpeedKMH float64
+}
+
+// String implements the String interface for human-readable formatting
+func (r ScenarioResult) String() string {
+	text := fmt.Sprintf("Scenario [%s, Completed: %t, Time: %.2f s, TopSpeed: %.1f KMH]\n",
+		r.Scenario,
+		r.Completed,
+		r.TimeS,
+		r.TopSpeedKMH)
+	text += r.Start.String()
+	for _, mark := range r.Marks {
+		text += mark.String()
+	}
+	// The top speed is not one of the marks
+	if r.Finish.Label != "" && (len(r.Marks) == 0 || r.Marks[len(r.Marks)-1] != r.Finish) {
+		text += r.Finish.String()
+	}
+	return text
+}
+
+// PerformanceDriver floors the throttle from a launch control start and shifts up at ShiftRPM
+// With HoldGear set it selects that gear at HoldFromKMH and stops shifting
+type PerformanceDriver struct {
+	ShiftRPM    float64
+	HoldGear    int
+	HoldFromKMH float64
+
+	pedals  timeline
+	shifter timeline
+	started bool
+	holding bool
+}
+
+// NewPerformanceDriver creates the driver of a scenario, shifting shiftRPM below the rev limiter
+func NewPerformanceDriver(scenario Scenario, spec VehicleSpec) *PerformanceDriver {
+	return &PerformanceDriver{
+		ShiftRPM:    spec.LimitRPM - shiftRPMBelowLimit,
+		HoldGear:    scenario.HoldGear,
+		HoldFromKMH: scenario.HoldFromKMH,
+	}
+}
+
+// Drive implements Driver
+func (d *PerformanceDriver) Drive(v *Vehicle, deltaTime float64) {
+	if !d.started {
+		d.started = true
+		d.queueLaunch(v)
+	}
+
+	d.pedals.advance(deltaTime)
+	if !d.pedals.idle() {
+		return
+	}
+
+	d.shifter.advance(deltaTime)
+	if !d.shifter.idle() {
+		return
+	}
+
+	gear := v.Gearbox.GetCurrentGear()
+	switch {
+	case d.HoldGear > 0 && !d.holding && v.Wheels.GetVehicleSpeed().KilometersPerHour() >= d.HoldFromKMH:
+		d.holding = true
+		if gear == d.HoldGear {
+			return
+		}
+		var revMatchRPM func() float64
+		if d.HoldGear < gear {
+			revMatchRPM = func() float64 { return v.SyncRPM(d.HoldGear) }
+		}
+		d.shifter.queueGearShift(v, func() bool { return v.Gearbox.SetGear(d.HoldGear) }, revMatchRPM)
+
+	case !d.holding && v.Engine.GetSpeed().RPM() > d.ShiftRPM && gear < 7:
+		d.shifter.queueGearShift(v, v.Gearbox.ShiftUp, nil)
+	}
+}
+
+// Ready reports whether the in-gear run can start, the hold gear is engaged and the pedal floored
+func (d *PerformanceDriver) Ready() bool {
+	return d.HoldGear == 0 || (d.holding && d.shifter.idle())
+}
+
+// queueLaunch holds the launch RPM for a second and dumps the clutch with the throttle floored
+func (d *PerformanceDriver) queueLaunch(v *Vehicle) {
+	d.pedals.add(func() {
+		v.Gearbox.SetClutch(0.0)
+		v.Gearbox.SetGear(1)
+		v.Engine.GetLaunchControl().Arm()
+		v.Engine.SetAcceleratorPos(1.0)
+	}, 1)
+
+	for clutch := 0.25; clutch <= 1.0; clutch += 0.25 {
+		position := clutch
+		d.pedals.add(func() { v.Gearbox.SetClutch(position) }, 0.05)
+	}
+}
+
+// RunScenario drives a new vehicle of the given spec through the scenario
+// The result holds the thresholds passed so far when the scenario does not finish in time
+func RunScenario(scenario Scenario, spec VehicleSpec, seed int64) (ScenarioResult, error) {
+	result := ScenarioResult{Scenario: scenario.Name}
+
+	vehicle, err := NewVehicle(scenario.Name, spec, seed, route.Straight(testTrackLengthM), defaultStart, 0, time.Time{})
+	if err != nil {
+		return result, err
+	}
+	driver := NewPerformanceDriver(scenario, spec)
+
+	startKMH := scenario.StartKMH
+	if startKMH <= 0 {
+		startKMH = standingStartMinKMH
+	}
+
+	var started bool
+	var prevTime, prevSpeed, prevDistance, startTime, startDistance float64
+	var windowSpeed, windowTime float64
+	nextMark := 0
+
+	// crossing interpolates the time and distance at which the speed or the distance reached target
+	crossing := func(label string, prev, current, target float64, snapshot Snapshot) Threshold {
+		fraction := 1.0
+		if current > prev {
+			fraction = (target - prev) / (current - prev)
+		}
+		now := prevTime + fraction*(vehicle.Elapsed()-prevTime)
+		distance := prevDistance + fraction*(snapshot.Wheels.DistanceM-prevDistance)
+		return Threshold{
+			Label:     label,
+			TimeS:     now - startTime,
+			SpeedKMH:  prevSpeed + fraction*(snapshot.Wheels.VehicleSpeedKMH-prevSpeed),
+			DistanceM: distance - startDistance,
+			Gear:      snapshot.Gearbox.CurrentGear,
+			RPM:       snapshot.Engine.RPM,
+		}
+	}
+
+	for vehicle.Elapsed() < scenario.TimeoutS {
+		driver.Drive(vehicle, scenarioStepSize)
+		snapshot := vehicle.Step(scenarioStepSize)
+		speed := snapshot.Wheels.VehicleSpeedKMH
+		distance := snapshot.Wheels.DistanceM
+
+		if !started && speed >= startKMH {
+			if !driver.Ready() {
+				return result, fmt.Errorf("%s: reached %.0f km/h before engaging gear %d", scenario.Name, speed, scenario.HoldGear)
+			}
+			started = true
+			result.Start = crossing("start", prevSpeed, speed, startKMH, snapshot)
+			startTime = result.Start.TimeS
+			startDistance = result.Start.DistanceM
+			result.Start.TimeS, result.Start.DistanceM = 0, 0
+			windowSpeed, windowTime = speed, vehicle.Elapsed()
+		}
+
+		if started {
+			for nextMark < len(scenario.Marks) {
+				mark := scenario.Marks[nextMark]
+				if mark.SpeedKMH > 0 && speed >= mark.SpeedKMH {
+					result.Marks = append(result.Marks, crossing(mark.Label, prevSpeed, speed, mark.SpeedKMH, snapshot))
+				} else if mark.DistanceM > 0 && distance-startDistance >= mark.DistanceM {
+					result.Marks = append(result.Marks, crossing(mark.Label, prevDistance-startDistance, distance-startDistance, mark.DistanceM, snapshot))
+				} else {
+					break
+				}
+				nextMark++
+			}
+
+			if speed > result.TopSpeedKMH {
+				result.TopSpeedKMH = speed
+				if scenario.TopSpeed {
+					result.Finish = crossing("top speed", speed, speed, speed, snapshot)
+				}
+			}
+
+			switch {
+			case !scenario.TopSpeed && nextMark == len(scenario.Marks):
+				result.Finish = result.Marks[len(result.Marks)-1]
+				result.Completed = true
+
+			case scenario.TopSpeed && vehicle.Elapsed()-windowTime >= topSpeedWindowS:
+				if speed-windowSpeed < topSpeedMinGainKMH {
+					result.Completed = true
+				}
+				windowSpeed, windowTime = speed, vehicle.Elapsed()
+			}
+			if result.Completed {
+				result.TimeS = result.Finish.TimeS
+				return result, nil
+			}
+		}
+
+		prevTime, prevSpeed, prevDistance = vehicle.Elapsed(), speed, distance
+	}
+
+	return result, fmt.Errorf("%s: did not finish in %.0f s", scenario.Name, scenario.TimeoutS)
+}
+
+// PerformanceSimulation runs the named scenarios one after the other and prints their thresholds
+func PerformanceSimulation(names []string, spec VehicleSpec, seed int64) error {
+	for _, name := range names {
+		scenario, err := ScenarioByName(name)
+		if err != nil {
+			return err
+		}
+
+		result, err := RunScenario(scenario, spec, seed)
+		fmt.Print(result.String())
+		if err != nil {
+			fmt.Printf("Scenario %s failed: %v\n", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package vehiclesim
+
+import (
+	"go-playground/internal/justforfun/vehiclesim/cruise"
+	"go-playground/internal/justforfun/vehiclesim/route"
+	"math"
+	"testing"
+	"time"
+)
+
+// TestPerformanceScenarios runs the standard tests on the default car and checks the figures stay plausible
+func TestPerformanceScenarios(t *testing.T) {
+	spec := DefaultVehicleSpec()
+
+	tests := []struct {
+		scenario     Scenario
+		minS, maxS   float64 // Bounds of the start to finish time
+		wantMarks    int
+		wantHoldGear int
+		minTopKMH    float64
+	}{
+		{scenario: ZeroTo100(), minS: 4, maxS: 15, wantMarks: 2},
+		{scenario: InGear80To120(4), minS: 2, maxS: 15, wantMarks: 2, wantHoldGear: 4},
+		{scenario: QuarterMile(), minS: 11, maxS: 22, wantMarks: 3},
+		{scenario: TopSpeed(), minS: 20, maxS: 200, wantMarks: 1, minTopKMH: 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scenario.Name, func(t *testing.T) {
+			result, err := RunScenario(tt.scenario, spec, 1)
+			if err != nil {
+				t.Fatalf("RunScenario: %v\n%s", err, result.String())
+			}
+			if !result.Completed || result.TimeS < tt.minS || result.TimeS > tt.maxS {
+				t.Errorf("got %.2f s, want between %.0f and %.0f s\n%s", result.TimeS, tt.minS, tt.maxS, result.String())
+			}
+			if result.TopSpeedKMH < tt.minTopKMH {
+				t.Errorf("got a top speed of %.1f km/h, want above %.0f", result.TopSpeedKMH, tt.minTopKMH)
+			}
+			if len(result.Marks) != tt.wantMarks {
+				t.Fatalf("got %d marks, want %d", len(result.Marks), tt.wantMarks)
+			}
+
+			previous := result.Start
+			for _, mark := range result.Marks {
+				if mark.TimeS <= previous.TimeS || mark.DistanceM <= previous.DistanceM {
+					t.Errorf("%s passed before %s", mark.Label, previous.Label)
+				}
+				if tt.wantHoldGear > 0 && mark.Gear != tt.wantHoldGear {
+					t.Errorf("%s in gear %d, want %d", mark.Label, mark.Gear, tt.wantHoldGear)
+				}
+				previous = mark
+			}
+		})
+	}
+}
+
+// TestTractionControlLaunch dumps the clutch at full throttle on a slippery road, traction control
+// has to cut the torque and keep the wheelspin far below the one of the car without it
+func TestTractionControlLaunch(t *testing.T) {
+	meanSlip := func(tractionControl bool) (float64, float64) {
+		spec := DefaultVehicleSpec()
+		spec.TractionControl = tractionControl
+		wet := route.Straight(testTrackLengthM)
+		for i := range wet.Points {
+			wet.Points[i].Mu = 0.3
+		}
+		vehicle, err := NewVehicle("vehicle-001", spec, 1, wet, defaultStart, 0, time.Unix(0, 0))
+		if err != nil {
+			t.Fatalf("NewVehicle: %v", err)
+		}
+		driver := NewPerformanceDriver(ZeroTo100(), spec)
+
+		var slip float64
+		minTorque := 1.0
+		for i := 0; i < 100; i++ {
+			driver.Drive(vehicle, 0.1)
+			snapshot := vehicle.Step(0.1)
+			if i >= 10 {
+				slip += math.Max(snapshot.Wheels.SlipL, snapshot.Wheels.SlipR) / 90
+			}
+			minTorque = math.Min(minTorque, snapshot.TractionControl.TorqueLimit)
+		}
+		return slip, minTorque
+	}
+
+	slipOff, _ := meanSlip(false)
+	slipOn, minTorque := meanSlip(true)
+	if slipOff < 0.5 || slipOn > 0.25 {
+		t.Errorf("mean slip %.2f with traction control and %.2f without, want below 0.25 and above 0.5", slipOn, slipOff)
+	}
+	if minTorque >= 1 {
+		t.Error("traction control never cut the engine torque")
+	}
+}
+
+// TestCruiseControlCancel checks the clutch, the brake pedal and a gear change each cancel the cruise control
+func TestCruiseControlCancel(t *testing.T) {
+	vehicle, err := NewVehicle("vehicle-001", DefaultVehicleSpec(), 1, route.Straight(testTrackLengthM), defaultStart, 0, time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("NewVehicle: %v", err)
+	}
+	driver := NewPerformanceDriver(ZeroTo100(), vehicle.Spec)
+	for i := 0; i < 100; i++ {
+		driver.Drive(vehicle, 0.1)
+		vehicle.Step(0.1)
+	}
+	vehicle.Engine.SetAcceleratorPos(0)
+	vehicle.Gearbox.SetClutch(1)
+
+	cancels := []struct {
+		name    string
+		press   func()
+		release func()
+	}{
+		{"clutch", func() { vehicle.Gearbox.SetClutch(0) }, func() { vehicle.Gearbox.SetClutch(1) }},
+		{"brake", func() { vehicle.SetBrakePedal(0.1) }, func() { vehicle.SetBrakePedal(0) }},
+		{"gear change", func() { vehicle.Gearbox.ShiftDown() }, func() {}},
+	}
+	for _, cancel := range cancels {
+		speedKMH := vehicle.Step(0.1).Wheels.VehicleSpeedKMH
+		if !vehicle.CruiseControl.Set() {
+			t.Fatalf("Set at %.0f km/h refused", speedKMH)
+		}
+		vehicle.Step(0.1)
+		if state := vehicle.CruiseControl.GetState(); state != cruise.Engaged {
+			t.Fatalf("before the %s: %s, want engaged", cancel.name, state)
+		}
+
+		cancel.press()
+		snapshot := vehicle.Step(0.1)
+		if snapshot.CruiseControl.State != "standby" || snapshot.CruiseControl.Throttle != 0 {
+			t.Errorf("%s: cruise control %s at %.2f throttle, want standby", cancel.name, snapshot.CruiseControl.State, snapshot.CruiseControl.Throttle)
+		}
+		cancel.release()
+	}
+}
@@ -0,0 +1,333 @@
+package vehiclesim
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-playground/internal/justforfun/vehiclesim/units"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// TelemetrySchemaVersion versions TelemetrySchema: the major number changes when a field is removed
+// or changes its name, type or unit, the minor number when fields are added
+const TelemetrySchemaVersion = "1.1"
+
+// FieldType is the type of a telemetry value
+type FieldType string
+
+const (
+	FloatField  FieldType = "float"   // float64
+	IntField    FieldType = "integer" // int64
+	BoolField   FieldType = "boolean"
+	StringField FieldType = "string"
+)
+
+// TelemetryField describes one value of the snapshot
+type TelemetryField struct {
+	Measurement string    // InfluxDB measurement grouping the field
+	Name        string    // Field name in the measurement
+	Channel     string    // Unique across the schema, the field name unless it is ambiguous or a channel had the name first
+	Source      string    // Path of the value in Snapshot, e.g. "Engine.RPM"
+	Scale       float64   // Converts the source value to Unit, 0 keeps it as is
+	Type        FieldType // Taken from the source field
+	Unit        string
+	Description string
+	Since       string // Schema version that added the field
+
+	index []int // Source resolved for reflect.Value.FieldByIndex
+}
+
+// TelemetrySchema lists every value written by the sinks, grouped by measurement
+var TelemetrySchema = mustResolveSchema(
+	measurement("engine",
+		field("rpm", "Engine.RPM", "rpm", "Crankshaft speed"),
+		field("torque", "Engine.Torque", "Nm", "Torque at the crankshaft"),
+		field("oil_temp", "Engine.OilTemp", "°C", "Oil temperature"),
+		field("coolant_temp", "Engine.CoolantTemp", "°C", "Coolant temperature"),
+		field("engine_load", "Engine.Load", "0-1", "Torque as a share of the full throttle torque at the current speed"),
+		field("accel_position", "Engine.AcceleratorPosition", "0-1", "Accelerator pedal position"),
+		field("engine_state", "Engine.EngineState", "", "normal, low_idle, rpm_limit, rev_match, launch_control or oilTemp_high"),
+		field("power_kw", "Engine.PowerKW", "kW", "Power at the crankshaft"),
+		field("power_hp", "Engine.PowerHP", "hp", "Power at the crankshaft"),
+		field("fuel_rate", "Engine.FuelRateLH", "L/h", "Fuel consumption"),
+	),
+	measurement("gearbox",
+		field("input_shaft", "Gearbox.InputShaft", "rpm", "Input shaft speed"),
+		field("output_shaft", "Gearbox.OutputShaft", "rpm", "Output shaft speed"),
+		field("current_gear", "Gearbox.CurrentGear", "", "Engaged gear, 0 in neutral"),
+		field("clutch_position", "Gearbox.ClutchPosition", "0-1", "0 when pressed, 1 when released"),
+		field("input_shaft_torque", "Gearbox.InputShaftTorque", "Nm", "Torque entering the gearbox"),
+		field("output_shaft_torque", "Gearbox.OutputShaftTorque", "Nm", "Torque leaving the gearbox"),
+	),
+	measurement("differential",
+		field("wheel_speed_left", "Differential.WheelSpeedL", "rpm", "Left output speed").channel("diff_wheel_speed_left"),
+		field("wheel_speed_right", "Differential.WheelSpeedR", "rpm", "Right output speed").channel("diff_wheel_speed_right"),
+		field("torque_left", "Differential.TorqueL", "Nm", "Torque sent to the left wheel"),
+		field("torque_right", "Differential.TorqueR", "Nm", "Torque sent to the right wheel"),
+		field("slip_ratio", "Differential.SlipRatio", "ratio", "Speed difference between the wheels, positive when turning left"),
+	),
+	measurement("vehicle_dynamic",
+		field("wheel_speed_left", "Wheels.WheelSpeedL", "rpm", "Left driven wheel speed"),
+		field("wheel_speed_right", "Wheels.WheelSpeedR", "rpm", "Right driven wheel speed"),
+		field("vehicle_speed_kmh", "Wheels.VehicleSpeedKMH", "km/h", "Speed from the driven wheels"),
+		field("ground_speed_kmh", "Body.SpeedMS", "km/h", "Speed over the ground").scaled(units.MetersPerSecond.Of(1).KilometersPerHour()),
+		field("acceleration", "Body.AccelerationMS", "m/s²", "Longitudinal acceleration"),
+		field("slip_left", "Wheels.SlipL", "ratio", "Longitudinal slip of the left wheel"),
+		field("slip_right", "Wheels.SlipR", "ratio", "Longitudinal slip of the right wheel"),
+		field("brake_torque_left", "Wheels.BrakeTorqueL", "Nm", "Brake torque on the left wheel"),
+		field("brake_torque_right", "Wheels.BrakeTorqueR", "Nm", "Brake torque on the right wheel"),
+		field("brake_torque", "Wheels.BrakeTorque", "Nm", "Brake torque on both wheels"),
+		field("tire_temp_left", "Wheels.TireL.TempC", "°C", "Left tire temperature"),
+		field("tire_temp_right", "Wheels.TireR.TempC", "°C", "Right tire temperature"),
+		field("tire_pressure_left", "Wheels.TireL.PressureKPa", "kPa", "Left tire pressure"),
+		field("tire_pressure_right", "Wheels.TireR.PressureKPa", "kPa", "Right tire pressure"),
+		field("tread_depth_left", "Wheels.TireL.TreadDepthMM", "mm", "Left tire tread depth"),
+		field("tread_depth_right", "Wheels.TireR.TreadDepthMM", "mm", "Right tire tread depth"),
+		field("tire_wear_left", "Wheels.TireL.Wear", "0-1", "Share of the usable tread worn on the left tire"),
+		field("tire_wear_right", "Wheels.TireR.Wear", "0-1", "Share of the usable tread worn on the right tire"),
+		field("over_speed_rating", "Wheels.OverSpeedRating", "", "The wheels turn faster than the speed rating of the tires"),
+	),
+	measurement("traction_control",
+		field("enabled", "TractionControl.Enabled", "", "Traction control switched on").channel("tcs_enabled"),
+		field("active", "TractionControl.Active", "", "Traction control intervening").channel("tcs_active"),
+		field("target_slip", "TractionControl.TargetSlip", "ratio", "Slip the traction control aims for"),
+		field("throttle_limit", "TractionControl.ThrottleLimit", "0-1", "Throttle override sent to the engine"),
+		field("torque_limit", "TractionControl.TorqueLimit", "0-1", "Share of the engine torque allowed").since("1.1"),
+		field("brake_torque_l", "TractionControl.BrakeTorqueL", "Nm", "Brake torque requested on the left wheel").channel("tcs_brake_torque_left"),
+		field("brake_torque_r", "TractionControl.BrakeTorqueR", "Nm", "Brake torque requested on the right wheel").channel("tcs_brake_torque_right"),
+	),
+	measurement("cruise_control",
+		field("state", "CruiseControl.State", "", "off, standby or engaged").channel("cruise_state"),
+		field("set_speed_kmh", "CruiseControl.SetSpeedKMH", "km/h", "Speed the cruise control holds"),
+		field("error_kmh", "CruiseControl.ErrorKMH", "km/h", "Set speed minus current speed").channel("cruise_error_kmh"),
+		field("integral", "CruiseControl.Integral", "km·s/h", "Integral term of the speed controller").channel("cruise_integral"),
+		field("throttle", "CruiseControl.Throttle", "0-1", "Throttle demand").channel("cruise_throttle"),
+		field("brake_torque", "CruiseControl.BrakeTorque", "Nm", "Brake demand per wheel").channel("cruise_brake_torque"),
+	),
+	measurement("route",
+		field("distance_m", "Route.DistanceM", "m", "Distance traveled along the route"),
+		field("elevation_m", "Route.ElevationM", "m", "Elevation of the road"),
+		field("grade", "Route.Grade", "rad", "Road grade, positive uphill").channel("grade_rad"),
+		field("grade_percent", "Route.GradePercent", "%", "Road grade, positive uphill").channel("grade"),
+		field("curvature", "Route.Curvature", "1/m", "Curvature of the road, positive to the left"),
+		field("mu", "Route.Mu", "", "Friction coefficient of the road surface"),
+	),
+	measurement("cornering",
+		field("steering_wheel_angle", "Steering.SteeringWheelAngle", "°", "Steering wheel angle, positive turns left"),
+		field("turn_radius_m", "Steering.TurnRadiusM", "m", "Turn radius, 0 when driving straight"),
+		field("yaw_rate", "Steering.YawRate", "rad/s", "Yaw rate"),
+		field("lateral_acceleration", "Steering.LateralAcceleration", "m/s²", "Lateral acceleration"),
+		field("slip_ratio", "Steering.SlipRatio", "ratio", "Wheel speed difference sent to the differential").channel("cornering_slip_ratio"),
+	),
+	measurement("gps",
+		field("latitude", "GPS.Latitude", "°", "Latitude in decimal degrees"),
+		field("longitude", "GPS.Longitude", "°", "Longitude in decimal degrees"),
+		field("heading", "GPS.HeadingDeg", "°", "Compass heading, 0 = north, 90 = east"),
+	),
+)
+
+// field describes a value of version 1.0 of the schema, named like its channel
+func field(name, source, unit, description string) TelemetryField {
+	return TelemetryField{Name: name, Channel: name, Source: source, Unit: unit, Description: description, Since: "1.0"}
+}
+
+// channel names the field where the name alone is ambiguous across measurements, or where
+// the name is taken by a channel with another source or unit
+func (f TelemetryField) channel(name string) TelemetryField {
+	f.Channel = name
+	return f
+}
+
+// since marks a field added by a later version of the schema
+func (f TelemetryField) since(version string) TelemetryField {
+	f.Since = version
+	return f
+}
+
+// scaled converts the source value to the unit of the field
+func (f TelemetryField) scaled(scale float64) TelemetryField {
+	f.Scale = scale
+	return f
+}
+
+func measurement(name string, fields ...TelemetryField) []TelemetryField {
+	for i := range fields {
+		fields[i].Measurement = name
+	}
+	return fields
+}
+
+// mustResolveSchema resolves the source of every field in Snapshot and checks that the channels are unique
+func mustResolveSchema(measurements ...[]TelemetryField) []TelemetryField {
+	var schema []TelemetryField
+	channels := make(map[string]bool)
+	for _, fields := range measurements {
+		for _, f := range fields {
+			if err := f.resolve(); err != nil {
+				panic(fmt.Sprintf("invalid telemetry field %s.%s: %v", f.Measurement, f.Name, err))
+			}
+			if channels[f.Channel] {
+				panic(fmt.Sprintf("telemetry channel %s is defined twice", f.Channel))
+			}
+			channels[f.Channel] = true
+			schema = append(schema, f)
+		}
+	}
+	return schema
+}
+
+func (f *TelemetryField) resolve() error {
+	t := reflect.TypeOf(Snapshot{})
+	for _, name := range strings.Split(f.Source, ".") {
+		if t.Kind() != reflect.Struct {
+			return fmt.Errorf("%s is not a struct in %s", t, f.Source)
+		}
+		structField, ok := t.FieldByName(name)
+		if !ok || !structField.IsExported() {
+			return fmt.Errorf("no field %s in %s", name, t)
+		}
+		f.index = append(f.index, structField.Index...)
+		t = structField.Type
+	}
+
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		f.Type = FloatField
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f.Type = IntField
+	case reflect.Bool:
+		f.Type = BoolField
+	case reflect.String:
+		f.Type = StringField
+	default:
+		return fmt.Errorf("%s has the unsupported type %s", f.Source, t)
+	}
+	if f.Scale != 0 && f.Type != FloatField {
+		return fmt.Errorf("only float fields can be scaled")
+	}
+	return nil
+}
+
+// Value returns the value of the field in the snapshot: a float64, int64, bool or string
+func (f TelemetryField) Value(snapshot Snapshot) any {
+	value := reflect.ValueOf(snapshot).FieldByIndex(f.index)
+	switch f.Type {
+	case FloatField:
+		if f.Scale != 0 {
+			return value.Float() * f.Scale
+		}
+		return value.Float()
+	case IntField:
+		return value.Int()
+	case BoolField:
+		return value.Bool()
+	default:
+		return value.String()
+	}
+}
+
+// Parse reads a value of the field written as text, as in the CSV telemetry files
+func (f TelemetryField) Parse(text string) (any, error) {
+	switch f.Type {
+	case FloatField:
+		return strconv.ParseFloat(text, 64)
+	case IntField:
+		return strconv.ParseInt(text, 10, 64)
+	case BoolField:
+		return strconv.ParseBool(text)
+	default:
+		return text, nil
+	}
+}
+
+// SetValue stores a value returned by Value or Parse at the source of the field in the snapshot
+func (f TelemetryField) SetValue(snapshot *Snapshot, value any) error {
+	target := reflect.ValueOf(snapshot).Elem().FieldByIndex(f.index)
+	switch value := value.(type) {
+	case float64:
+		if f.Type != FloatField {
+			break
+		}
+		if f.Scale != 0 {
+			value /= f.Scale
+		}
+		target.SetFloat(value)
+		return nil
+	case int64:
+		if f.Type != IntField {
+			break
+		}
+		target.SetInt(value)
+		return nil
+	case bool:
+		if f.Type != BoolField {
+			break
+		}
+		target.SetBool(value)
+		return nil
+	case string:
+		if f.Type != StringField {
+			break
+		}
+		target.SetString(value)
+		return nil
+	}
+	return fmt.Errorf("invalid %s value %v for %s", f.Type, value, f.Channel)
+}
+
+// Float returns the value of the field as a number, booleans are 0 or 1 and strings 0
+func (f TelemetryField) Float(snapshot Snapshot) float64 {
+	switch value := f.Value(snapshot).(type) {
+	case float64:
+		return value
+	case int64:
+		return float64(value)
+	case bool:
+		if value {
+			return 1
+		}
+	}
+	return 0
+}
+
+// jsonSchemaTypes are the JSON Schema types of the field types
+var jsonSchemaTypes = map[FieldType]string{
+	FloatField:  "number",
+	IntField:    "integer",
+	BoolField:   "boolean",
+	StringField: "string",
+}
+
+// TelemetryJSONSchema describes the flat telemetry records (see EncodeTelemetryJSON) as a JSON Schema.
+// The schema ID carries TelemetrySchemaVersion, every property its unit, source measurement and version.
+func TelemetryJSONSchema() ([]byte, error) {
+	properties := map[string]any{
+		"vehicle_id": map[string]any{"type": "string", "description": "Vehicle that produced the record"},
+		"timestamp":  map[string]any{"type": "integer", "description": "Simulated time of the step", "x-unit": "ms since the epoch"},
+	}
+	required := []string{"vehicle_id", "timestamp"}
+	for _, f := range TelemetrySchema {
+		property := map[string]any{
+			"type":          jsonSchemaTypes[f.Type],
+			"description":   f.Description,
+			"x-measurement": f.Measurement,
+			"x-field":       f.Name,
+			"x-since":       f.Since,
+		}
+		if f.Unit != "" {
+			property["x-unit"] = f.Unit
+		}
+		properties[f.Channel] = property
+		required = append(required, f.Channel)
+	}
+
+	return json.MarshalIndent(map[string]any{
+		"$schema":    "https://json-schema.org/draft/2020-12/schema",
+		"$id":        "urn:vehiclesim:telemetry:" + TelemetrySchemaVersion,
+		"title":      "Vehicle telemetry",
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}, "", "  ")
+}
@@ -0,0 +1,150 @@
+package vehiclesim
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var updateSchema = flag.Bool("update-schema", false, "rewrite testdata/telemetry_schema.json")
+
+// notInSchema are the telemetry values left out of TelemetrySchema on purpose
+var notInSchema = map[string]string{
+	"Wheels.DistanceM":            "route distance_m",
+	"Wheels.TireInfo":             "static tire size, not telemetry",
+	"Wheels.TireL.RollingRadiusM": "follows the pressure and wear",
+	"Wheels.TireR.RollingRadiusM": "follows the pressure and wear",
+	"Wheels.TireL.GripFactor":     "follows the temperature, pressure and wear",
+	"Wheels.TireR.GripFactor":     "follows the temperature, pressure and wear",
+	"Body.Grade":                  "route grade",
+	"TractionControl.SlipL":       "slip_left",
+	"TractionControl.SlipR":       "slip_right",
+	"CruiseControl.SpeedKMH":      "vehicle_speed_kmh",
+	"Shifts":                      "events, written as shift points",
+	"VehicleID":                   "key of every record",
+	"Time":                        "timestamp of every record",
+}
+
+// TestTelemetrySchemaCoversTelemetry fails when a Telemetry struct gains a value that is neither
+// in TelemetrySchema nor in notInSchema
+func TestTelemetrySchemaCoversTelemetry(t *testing.T) {
+	inSchema := map[string]bool{}
+	for _, field := range TelemetrySchema {
+		inSchema[field.Source] = true
+	}
+
+	var walk func(path string, typ reflect.Type)
+	walk = func(path string, typ reflect.Type) {
+		if _, ok := notInSchema[path]; ok || inSchema[path] {
+			return
+		}
+		if typ.Kind() != reflect.Struct {
+			t.Errorf("%s (%s) is not in TelemetrySchema", path, typ)
+			return
+		}
+		for i := 0; i < typ.NumField(); i++ {
+			if f := typ.Field(i); f.IsExported() {
+				walk(strings.TrimPrefix(path+"."+f.Name, "."), f.Type)
+			}
+		}
+	}
+	walk("", reflect.TypeOf(Snapshot{}))
+
+	for path := range notInSchema {
+		if inSchema[path] {
+			t.Errorf("%s is in TelemetrySchema and in notInSchema", path)
+		}
+	}
+}
+
+// TestTelemetryJSONSchema compares the exported schema with testdata/telemetry_schema.json and requires
+// a new major version for breaking changes. Run with -update-schema to accept the changes.
+func TestTelemetryJSONSchema(t *testing.T) {
+	schema, err := TelemetryJSONSchema()
+	if err != nil {
+		t.Fatalf("TelemetryJSONSchema: %v", err)
+	}
+	golden := filepath.Join("testdata", "telemetry_schema.json")
+	if *updateSchema {
+		if err := os.WriteFile(golden, append(schema, '\n'), 0o644); err != nil {
+			t.Fatalf("updating %s: %v", golden, err)
+		}
+	}
+	previous, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading %s: %v", golden, err)
+	}
+
+	type jsonSchema struct {
+		ID         string `json:"$id"`
+		Properties map[string]struct {
+			Type string `json:"type"`
+			Unit string `json:"x-unit"`
+		} `json:"properties"`
+	}
+	var before, after jsonSchema
+	if err := json.Unmarshal(previous, &before); err != nil {
+		t.Fatalf("invalid %s: %v", golden, err)
+	}
+	if err := json.Unmarshal(schema, &after); err != nil {
+		t.Fatalf("invalid JSON Schema: %v", err)
+	}
+
+	major := func(id string) string {
+		return strings.Split(strings.TrimPrefix(id, "urn:vehiclesim:telemetry:"), ".")[0]
+	}
+	for name, property := range before.Properties {
+		current, ok := after.Properties[name]
+		if (!ok || current != property) && major(before.ID) == major(after.ID) {
+			t.Errorf("%s was removed or changed type or unit, a breaking change needs a new major TelemetrySchemaVersion", name)
+		}
+	}
+	if !bytes.Equal(bytes.TrimSpace(previous), schema) {
+		if before.ID == after.ID {
+			t.Errorf("the schema changed without a new TelemetrySchemaVersion")
+		}
+		t.Errorf("%s is outdated, run go test -run TestTelemetryJSONSchema -update-schema", golden)
+	}
+}
+
+func TestTelemetryField(t *testing.T) {
+	snapshot := Snapshot{}
+	snapshot.Body.SpeedMS = 10
+	snapshot.Gearbox.CurrentGear = 3
+	snapshot.TractionControl.Active = true
+	snapshot.CruiseControl.State = "engaged"
+	snapshot.Wheels.WheelSpeedL = 700
+	snapshot.Differential.WheelSpeedL = 710
+	snapshot.Wheels.BrakeTorque = 900
+	snapshot.Route.Grade = 0.05
+	snapshot.Route.GradePercent = 5.004
+
+	// The channels keep the sources and units they had before the schema
+	want := map[string]any{
+		"ground_speed_kmh":      36.0,
+		"current_gear":          int64(3),
+		"tcs_active":            true,
+		"cruise_state":          "engaged",
+		"wheel_speed_left":      700.0,
+		"diff_wheel_speed_left": 710.0,
+		"brake_torque":          900.0,
+		"grade":                 5.004,
+		"grade_rad":             0.05,
+	}
+	for _, field := range TelemetrySchema {
+		if value, ok := want[field.Channel]; ok && field.Value(snapshot) != value {
+			t.Errorf("%s = %#v, want %#v", field.Channel, field.Value(snapshot), value)
+		}
+	}
+	if channel, err := ChannelByName("tcs_active"); err != nil || channel.Value(snapshot) != 1 {
+		t.Errorf("ChannelByName(tcs_active) = %+v, %v, want the value 1", channel, err)
+	}
+	if _, err := ChannelByName("cruise_state"); err == nil {
+		t.Error("ChannelByName of a string field: expected an error")
+	}
+}
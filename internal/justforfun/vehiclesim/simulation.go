@@ -3,289 +3,620 @@ package vehiclesim
 import (
 	"context"
 	"fmt"
-	"github.com/influxdata/influxdb-client-go/v2/api"
 	"github.com/influxdata/influxdb-client-go/v2/api/write"
+	"go-playground/internal/justforfun/vehiclesim/can"
 	"go-playground/internal/justforfun/vehiclesim/differential"
 	"go-playground/internal/justforfun/vehiclesim/engine"
 	"go-playground/internal/justforfun/vehiclesim/gearbox"
+	"go-playground/internal/justforfun/vehiclesim/gps"
 	"go-playground/internal/justforfun/vehiclesim/influx"
+	"go-playground/internal/justforfun/vehiclesim/obd"
+	"go-playground/internal/justforfun/vehiclesim/route"
+	"go-playground/internal/justforfun/vehiclesim/units"
 	"go-playground/internal/justforfun/vehiclesim/wheels"
+	"go-playground/pkg/datetimeutils"
 	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 )
 
+// autoRevMatch enables the automatic throttle blip on downshifts
+const autoRevMatch = true
+
+// singleVehicleID tags the telemetry of VehicleSimulation
+const singleVehicleID = "vehicle-001"
+
+// defaultStart is where the GPS trajectory starts when VEHICLESIM_START_LAT/LON are not set (Circuit de Barcelona-Catalunya)
+var defaultStart = gps.Coordinate{Latitude: 41.5700, Longitude: 2.2611}
+
 func VehicleSimulation() {
 	fmt.Println("Starting vehicle simulation")
 
-	config := influx.ConfigInfluxDB{
-		Org:    "docs",
-		Bucket: "vehicle-simulation",
+	sink, err := loadSinks()
+	if err != nil {
+		panic(fmt.Sprintf("Error starting telemetry output: %v", err))
+	}
+	defer sink.Close()
+
+	theRoute, err := loadRoute()
+	if err != nil {
+		panic(fmt.Sprintf("Error loading route: %v", err))
+	}
+	fmt.Printf("Driving route %s (%.0f m)\n", theRoute.Name, theRoute.Length())
+
+	vehicle, driver, err := loadVehicle(theRoute)
+	if err != nil {
+		panic(fmt.Sprintf("Error initializing vehicle: %v", err))
 	}
 
-	client := influx.NewInfluxDBClient(config)
-	defer client.Close()
+	// The API takes the car from the driver when it sends controls
+	controlled := NewControlledDriver(driver)
+	driver = controlled
 
-	writeAPI := client.WriteAPIBlocking(config.Org, config.Bucket)
+	// VEHICLESIM_RECORD_INPUTS saves the driver inputs, with the vehicle they start from, to reproduce the run
+	var recorder *InputRecorder
+	if recordFile := os.Getenv("VEHICLESIM_RECORD_INPUTS"); recordFile != "" {
+		file, err := os.Create(recordFile)
+		if err != nil {
+			panic(fmt.Sprintf("Error creating input recording: %v", err))
+		}
+		defer file.Close()
 
-	// Engine initialization and initial state
-	theGearbox := gearbox.NewManualGearbox()
-	theEngine := engine.NewEngine()
-	theBasicDifferential := differential.NewBasicDifferential(differential.TypeRDiffRatio)
+		recorder = NewInputRecorder(driver, file)
+		driver = recorder
+		fmt.Printf("Recording driver inputs in %s\n", recordFile)
+	}
 
-	wheelManager, err := wheels.NewWheelManager("245/40R19")
+	checkpointEvery, err := loadCheckpointInterval()
 	if err != nil {
-		panic(fmt.Sprintf("Error initializing wheels: %v", err))
+		panic(fmt.Sprintf("Error reading checkpoint interval: %v", err))
+	}
+	nextCheckpoint := vehicle.Elapsed() + checkpointEvery
+
+	// The dashboard replaces the status printout when stdin is a terminal, VEHICLESIM_TUI=0 keeps the printout
+	showDashboard := os.Getenv("VEHICLESIM_TUI") != "0" && StdinIsTerminal()
+
+	live := NewLiveSimulation(vehicle, driver, 0.1, func(snapshot Snapshot) {
+		if err := sink.Write(snapshot); err != nil {
+			log.Printf("Error writting datas: %v", err)
+		}
+
+		if !showDashboard {
+			for _, shiftEvent := range snapshot.Shifts {
+				fmt.Print(shiftEvent.String())
+			}
+			printSimulationStatus(snapshot)
+		}
+
+		if checkpointEvery > 0 && vehicle.Elapsed() >= nextCheckpoint {
+			saveVehicleCheckpoint(vehicle)
+			nextCheckpoint += checkpointEvery
+		}
+	})
+
+	// Ctrl+C ends the run and exports the trajectory
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// VEHICLESIM_OBD_FAULTS (e.g. "P0301,P0420") are the trouble codes stored from the start,
+	// the control API injects and clears them while running
+	faults := obd.NewFaults()
+	if list := os.Getenv("VEHICLESIM_OBD_FAULTS"); list != "" {
+		codes, err := obd.ParseDTCs(list)
+		if err != nil {
+			panic(fmt.Sprintf("Error reading VEHICLESIM_OBD_FAULTS: %v", err))
+		}
+		faults.Inject(codes...)
 	}
 
-	initializeEngineState(theEngine)
-	// Castear a ManualGearbox para inicialización
-	if manualGB, ok := theGearbox.(*gearbox.ManualGearbox); ok {
-		initializeGearboxState(manualGB)
+	// VEHICLESIM_HTTP_ADDR (e.g. ":8080") serves the control API of the run
+	if addr := os.Getenv("VEHICLESIM_HTTP_ADDR"); addr != "" {
+		server := &http.Server{Addr: addr, Handler: NewControlServer(live, controlled, faults).Handler()}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("Error serving control API: %v", err)
+			}
+		}()
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := server.Shutdown(shutdownCtx); err != nil {
+				log.Printf("Error stopping control API: %v", err)
+			}
+		}()
+		fmt.Printf("Control API listening on %s\n", addr)
 	}
 
-	// Simulation Setup
-	ticker := time.NewTicker(100 * time.Millisecond)
-	defer ticker.Stop()
+	// VEHICLESIM_OBD_ADDR (e.g. ":35000") emulates an ELM327 Wi-Fi adapter plugged into the car
+	if addr := os.Getenv("VEHICLESIM_OBD_ADDR"); addr != "" {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			panic(fmt.Sprintf("Error starting OBD-II adapter: %v", err))
+		}
+		obdServer := NewOBDServer(live, faults)
+		go func() {
+			if err := obdServer.Serve(listener); err != nil {
+				log.Printf("Error serving OBD-II adapter: %v", err)
+			}
+		}()
+		defer obdServer.Close()
+		fmt.Printf("ELM327 adapter listening on %s\n", listener.Addr())
+	}
 
-	// Channel to coordinate the start of goroutines
-	ready := make(chan struct{})
+	fmt.Println("Starting simulation...")
+	if showDashboard {
+		runDashboard(ctx, live, controlled, vehicle.Spec.LimitRPM)
+	} else {
+		live.Run(ctx)
+	}
 
-	// Accelerator pedal simulation
-	go func() {
-		<-ready // Wait for the start signal
-		simulateEngine(theEngine)
-	}()
+	fmt.Println("Stopping vehicle simulation")
+	saveTrajectory(vehicle.Tracker)
+	if checkpointEvery > 0 {
+		saveVehicleCheckpoint(vehicle)
+	}
+	if recorder != nil {
+		if err := recorder.Flush(); err != nil {
+			log.Printf("Error saving input recording: %v", err)
+		}
+	}
+}
 
-	// Gear shift simulation
+// runDashboard steps the simulation under the terminal dashboard until ctx is done or the dashboard is quit
+// Log lines go to the dashboard meanwhile, printed they would scroll it away
+func runDashboard(ctx context.Context, live *LiveSimulation, controlled *ControlledDriver, maxRPM float64) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
 	go func() {
-		<-ready // Wait for the start signal
-		// Castear a ManualGearbox para acceso a métodos específicos
-		if manualGB, ok := theGearbox.(*gearbox.ManualGearbox); ok {
-			simulateGearShifts(theEngine, manualGB)
-		}
+		defer close(done)
+		live.Run(ctx)
 	}()
 
-	// Signal that the simulation can begin
-	fmt.Println("Starting simulation...")
-	close(ready)
+	// Raw mode only lasts while the dashboard is drawn, the messages before and after it print as usual
+	restoreTerminal, err := MakeTerminalRaw()
+	if err != nil {
+		log.Printf("Running without the dashboard: %v", err)
+		<-done
+		cancel()
+		return
+	}
 
-	// Simulation main loop
-	for range ticker.C {
-		// Obtener posición del clutch de la transmisión para actualizar el motor
-		gearboxDataForClutch := gearbox.GetManualGearboxData(theGearbox)
-		clutchPos := gearboxDataForClutch.ClutchPosition
+	dashboard := NewDashboard(live, controlled, maxRPM)
+	log.SetOutput(dashboard)
 
-		// Actualizar motor con posición del clutch (afecta ralentización)
-		theEngine.Update(clutchPos, 0.1)
+	err = dashboard.Run(ctx, os.Stdin, os.Stdout)
+	restoreTerminal()
+	log.SetOutput(os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error running dashboard: %v\n", err)
+	}
+	cancel()
+	<-done
+}
+
+// loadVehicle returns the vehicle and the driver of the simulation:
+// the recording set in VEHICLESIM_REPLAY_INPUTS with its recorded driver, the checkpoint set in VEHICLESIM_RESUME,
+// or a new vehicle parked at the start. Replayed and resumed vehicles keep their own route and simulated clock.
+func loadVehicle(theRoute *route.Route) (*Vehicle, Driver, error) {
+	if recordFile := os.Getenv("VEHICLESIM_REPLAY_INPUTS"); recordFile != "" {
+		recording, err := LoadRecording(recordFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		fmt.Printf("Replaying %d inputs of %s from %s\n", len(recording.Commands), recording.Header.Checkpoint.VehicleID, recordFile)
+		vehicle, err := recording.Vehicle()
+		return vehicle, NewReplayDriver(recording.Commands), err
+	}
 
-		// Obtener datos del motor actualizado
-		engineData := theEngine.GetData()
-		engineRPM := theEngine.GetRPM()
-		engineTorque := theEngine.GetTorque()
+	driver := NewScriptedDriver(NormalProfile())
 
-		// Actualizar transmisión con datos del motor como parámetros
-		theGearbox.Update(engineRPM, engineTorque, 0.1)
+	if checkpointFile := os.Getenv("VEHICLESIM_RESUME"); checkpointFile != "" {
+		checkpoint, err := LoadCheckpoint(checkpointFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		fmt.Printf("Resuming %s at %.1f s from %s\n", checkpoint.VehicleID, checkpoint.Elapsed, checkpointFile)
+		vehicle, err := RestoreVehicle(checkpoint)
+		return vehicle, driver, err
+	}
 
-		// Actualizar diferencial
-		theBasicDifferential.Update(theGearbox.GetOutputShaft(), theGearbox.GetOutputTorque(), 0.0)
+	start, heading := loadStartPosition()
+	vehicle, err := NewVehicle(singleVehicleID, DefaultVehicleSpec(), time.Now().UnixNano(), theRoute, start, heading, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	initializeEngineState(vehicle.Engine)
+	initializeGearboxState(vehicle.Gearbox)
+	return vehicle, driver, nil
+}
 
-		// Obtener datos para telemetría
-		gearboxData := gearbox.GetManualGearboxData(theGearbox)
-		differentialData := theBasicDifferential.GetData()
+// loadSinks connects to InfluxDB and starts the outputs configured in the environment, which
+// VehicleSimulation and ReplayTelemetry write the telemetry to
+func loadSinks() (MultiSink, error) {
+	sink := MultiSink{NewInfluxSink(influx.ConfigInfluxDB{
+		Org:    "docs",
+		Bucket: "vehicle-simulation",
+	})}
+	fail := func(output string, err error) (MultiSink, error) {
+		sink.Close()
+		return nil, fmt.Errorf("error starting %s output: %v", output, err)
+	}
 
-		wheelManager.WheelPair.Update(differentialData.WheelSpeedL, differentialData.WheelSpeedR)
+	canSink, err := loadCANSink()
+	if err != nil {
+		return fail("CAN", err)
+	}
+	if canSink != nil {
+		sink = append(sink, canSink)
+	}
+	sparkplugSink, err := loadSparkplugSink()
+	if err != nil {
+		return fail("Sparkplug B", err)
+	}
+	if sparkplugSink != nil {
+		sink = append(sink, sparkplugSink)
+	}
+	kafkaSink, err := loadKafkaSink()
+	if err != nil {
+		return fail("Kafka", err)
+	}
+	if kafkaSink != nil {
+		sink = append(sink, kafkaSink)
+	}
+	fileSink, err := loadTelemetryFileSink()
+	if err != nil {
+		return fail("telemetry file", err)
+	}
+	if fileSink != nil {
+		sink = append(sink, fileSink)
+	}
+	return sink, nil
+}
 
-		wheelsData := wheelManager.WheelPair.GetData()
+// loadCANSink encodes the telemetry with the DBC file set in VEHICLESIM_CAN_DBC, the bundled one when empty.
+// The frames are logged in candump format to VEHICLESIM_CAN_LOG and sent on VEHICLESIM_CAN_INTERFACE
+// (e.g. "vcan0"). Returns nil when neither is set.
+func loadCANSink() (*CANSink, error) {
+	logFile := os.Getenv("VEHICLESIM_CAN_LOG")
+	iface := os.Getenv("VEHICLESIM_CAN_INTERFACE")
+	if logFile == "" && iface == "" {
+		return nil, nil
+	}
 
-		enginePoint := createEnginePoint(engineData)
-		gearboxPoint := createGearboxPoint(gearboxData)
-		differentialPoint := createDifferentialPoint(differentialData)
-		vehicleDynamicPoint := createVehicleDynamicPoint(wheelsData)
+	db, err := can.LoadDBC(os.Getenv("VEHICLESIM_CAN_DBC"))
+	if err != nil {
+		return nil, err
+	}
 
-		if err := writePoints(writeAPI, enginePoint, gearboxPoint, differentialPoint, vehicleDynamicPoint); err != nil {
-			log.Printf("Error writting datas: %v", err)
+	var bus *can.SocketCAN
+	if iface != "" {
+		if bus, err = can.OpenSocketCAN(iface); err != nil {
+			return nil, err
 		}
+		fmt.Printf("Sending CAN frames on %s\n", iface)
+	} else {
+		iface = "vcan0" // Name of the interface in the log, for canplayer
+	}
 
-		printSimulationStatus(engineData, gearboxData, differentialData, wheelsData)
+	var canLog *can.LogWriter
+	if logFile != "" {
+		file, err := os.Create(logFile)
+		if err != nil {
+			if bus != nil {
+				bus.Close()
+			}
+			return nil, fmt.Errorf("error creating CAN log: %v", err)
+		}
+		canLog = can.NewLogWriter(file, iface)
+		fmt.Printf("Logging CAN frames in %s\n", logFile)
+	}
 
+	sink, unmapped, err := NewCANSink(db, canLog, bus)
+	if err != nil {
+		if canLog != nil {
+			canLog.Close()
+		}
+		if bus != nil {
+			bus.Close()
+		}
+		return nil, err
 	}
+	if len(unmapped) > 0 {
+		log.Printf("CAN signals without a simulation channel, sent as zeros: %s", strings.Join(unmapped, ", "))
+	}
+	return sink, nil
 }
 
-func initializeEngineState(motor *engine.Engine) {
-	// Initial state of the engine
-	motor.SetAcceleratorPos(0.0) // Accelerator depressed
+// loadSparkplugSink publishes the telemetry as Sparkplug B to the MQTT broker of VEHICLESIM_MQTT_BROKER
+// (e.g. "tcp://localhost:1883"), as the edge node VEHICLESIM_SPARKPLUG_NODE of VEHICLESIM_SPARKPLUG_GROUP.
+// Returns nil when no broker is set.
+func loadSparkplugSink() (*SparkplugSink, error) {
+	broker := os.Getenv("VEHICLESIM_MQTT_BROKER")
+	if broker == "" {
+		return nil, nil
+	}
+	config := SparkplugConfig{Broker: broker, Group: "vehiclesim", EdgeNode: "simulator"}
+	if group := os.Getenv("VEHICLESIM_SPARKPLUG_GROUP"); group != "" {
+		config.Group = group
+	}
+	if node := os.Getenv("VEHICLESIM_SPARKPLUG_NODE"); node != "" {
+		config.EdgeNode = node
+	}
+	config.ClientID = "vehiclesim-" + config.EdgeNode
 
-	// Wait for the engine to idle
-	fmt.Println("Initializing engine at idle...")
-	time.Sleep(2 * time.Second)
+	sink, err := NewSparkplugSink(config)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Publishing Sparkplug B telemetry to %s as %s/%s\n", broker, config.Group, config.EdgeNode)
+	return sink, nil
+}
 
-	fmt.Printf("Initial state established:\n")
-	fmt.Printf("- Engine idling\n")
+// loadKafkaSink produces the telemetry to the brokers of VEHICLESIM_KAFKA_BROKERS (e.g. "localhost:19092",
+// comma separated) in the topic VEHICLESIM_KAFKA_TOPIC. VEHICLESIM_KAFKA_ENCODING is json or avro,
+// VEHICLESIM_KAFKA_SCHEMA_ID the Schema Registry ID of the Avro schema and VEHICLESIM_KAFKA_LINGER
+// (e.g. "50ms") how long records wait to be batched. Returns nil when no broker is set.
+func loadKafkaSink() (*KafkaSink, error) {
+	brokers := os.Getenv("VEHICLESIM_KAFKA_BROKERS")
+	if brokers == "" {
+		return nil, nil
+	}
+	config := KafkaConfig{
+		Brokers:  strings.Split(brokers, ","),
+		Topic:    "vehicle-telemetry",
+		Encoding: os.Getenv("VEHICLESIM_KAFKA_ENCODING"),
+		// Records are dropped and reported rather than piling up while the cluster is down
+		DeliveryTimeout: 30 * time.Second,
+	}
+	if topic := os.Getenv("VEHICLESIM_KAFKA_TOPIC"); topic != "" {
+		config.Topic = topic
+	}
+	if value := os.Getenv("VEHICLESIM_KAFKA_SCHEMA_ID"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid VEHICLESIM_KAFKA_SCHEMA_ID %q: %v", value, err)
+		}
+		config.SchemaID = uint32(id)
+	}
+	if value := os.Getenv("VEHICLESIM_KAFKA_LINGER"); value != "" {
+		linger, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid VEHICLESIM_KAFKA_LINGER %q: %v", value, err)
+		}
+		config.Linger = linger
+	}
 
+	sink, err := NewKafkaSink(config)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Producing telemetry to %s on %s\n", config.Topic, brokers)
+	return sink, nil
 }
 
-func initializeGearboxState(gearbox *gearbox.ManualGearbox) {
-	// Initial state of the gearbox
-	gearbox.SetClutch(0.0) // Clutch pressed
-	gearbox.SetGear(0)     // Neutral
+// loadTelemetryFileSink records the telemetry in VEHICLESIM_TELEMETRY_FILE, as CSV when it ends in .csv
+// and JSONL otherwise, for vehiclesim replay. Returns nil when no file is set.
+func loadTelemetryFileSink() (*TelemetryFileSink, error) {
+	path := os.Getenv("VEHICLESIM_TELEMETRY_FILE")
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	sink, err := NewTelemetryFileSink(file, TelemetryFileFormat(path))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	fmt.Printf("Recording telemetry in %s\n", path)
+	return sink, nil
+}
 
-	// Wait for the engine to idle
-	fmt.Println("Initializing gearbox...")
-	time.Sleep(2 * time.Second)
+// loadCheckpointInterval reads VEHICLESIM_CHECKPOINT_EVERY (e.g. "30s" of simulated time), 0 disables checkpoints
+func loadCheckpointInterval() (float64, error) {
+	value := os.Getenv("VEHICLESIM_CHECKPOINT_EVERY")
+	if value == "" {
+		return 0, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid VEHICLESIM_CHECKPOINT_EVERY %q: %v", value, err)
+	}
+	return interval.Seconds(), nil
+}
 
-	// Prepare first gear
-	gearbox.SetGear(1)     // Engage first gear
-	gearbox.SetClutch(1.0) // Release clutch gradually
+// saveVehicleCheckpoint writes the state of the vehicle in the output directory, named after its simulated time
+func saveVehicleCheckpoint(vehicle *Vehicle) {
+	path := filepath.Join(outputDir(), fmt.Sprintf("%s-checkpoint-%07.1fs.json", vehicle.ID, vehicle.Elapsed()))
+	if err := SaveCheckpoint(path, vehicle.Checkpoint()); err != nil {
+		log.Printf("Error saving checkpoint: %v", err)
+		return
+	}
+	fmt.Printf("Checkpoint saved in %s\n", path)
+}
 
-	fmt.Printf("- First gear engaged\n")
-	fmt.Printf("- Clutch ready\n")
+// loadRoute loads the route file set in VEHICLESIM_ROUTE, or the bundled route when empty
+func loadRoute() (*route.Route, error) {
+	routeFile := os.Getenv("VEHICLESIM_ROUTE")
+	if routeFile == "" {
+		return route.Default(), nil
+	}
+
+	theRoute, err := route.LoadFile(routeFile)
+	if err != nil {
+		return nil, err
+	}
+	// Only a route that ends where it starts can loop, an open one ends on a flat road
+	theRoute.Loop = theRoute.Closed()
+	return theRoute, nil
 }
 
-func simulateEngine(motor *engine.Engine) {
-	// Short initial pause for stabilization
-	time.Sleep(1 * time.Second)
+// loadStartPosition reads the GPS start from VEHICLESIM_START_LAT, VEHICLESIM_START_LON and
+// VEHICLESIM_START_HEADING (compass degrees), falling back to defaultStart heading north
+func loadStartPosition() (gps.Coordinate, float64) {
+	start := defaultStart
+	heading := 0.0
 
-	for {
-		// Smoother gradual acceleration
-		for pos := 0.0; pos <= 1.0; pos += 0.05 {
-			motor.SetAcceleratorPos(pos)
-			time.Sleep(500 * time.Millisecond)
+	envValues := map[string]*float64{
+		"VEHICLESIM_START_LAT":     &start.Latitude,
+		"VEHICLESIM_START_LON":     &start.Longitude,
+		"VEHICLESIM_START_HEADING": &heading,
+	}
+	for name, target := range envValues {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
 		}
-		// Hold full throttle briefly
-		time.Sleep(2 * time.Second)
-
-		// Gradual deceleration
-		for pos := 1.0; pos >= 0.0; pos -= 0.05 {
-			motor.SetAcceleratorPos(pos)
-			time.Sleep(500 * time.Millisecond)
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			log.Printf("Ignoring invalid %s=%q: %v", name, value, err)
+			continue
 		}
-		// Idle pause
-		time.Sleep(2 * time.Second)
+		*target = parsed
 	}
-}
 
-func simulateGearShifts(motor *engine.Engine, manualGB *gearbox.ManualGearbox) {
-	// Short initial pause for stabilization
-	time.Sleep(2 * time.Second)
+	return start, heading
+}
 
-	for {
-		engineData := motor.GetData()
-		gearboxData := gearbox.GetManualGearboxData(manualGB)
+// saveTrajectory exports the run as GPX and GeoJSON in VEHICLESIM_OUTPUT_DIR (datalake by default)
+func saveTrajectory(tracker *gps.Tracker) {
+	outputDir := outputDir()
 
-		switch {
-		case engineData.RPM > 4000 && gearboxData.CurrentGear < 7:
-			performGearShift(motor, manualGB, manualGB.ShiftUp)
+	timeStamp, err := datetimeutils.CreateFileTimeStamp(datetimeutils.Now())
+	if err != nil {
+		log.Printf("Error creating run timestamp: %v", err)
+		return
+	}
 
-		case engineData.RPM < 2000 && gearboxData.CurrentGear > 1:
-			performGearShift(motor, manualGB, manualGB.ShiftDown)
-		}
+	runName := fmt.Sprintf("vehiclesim-run-%s", timeStamp)
+	if err := gps.SaveRun(outputDir, runName, tracker.GetPoints()); err != nil {
+		log.Printf("Error saving trajectory: %v", err)
+		return
+	}
+	fmt.Printf("Trajectory saved in %s/%s.gpx and .geojson\n", outputDir, runName)
+}
 
-		time.Sleep(500 * time.Millisecond)
+// outputDir returns VEHICLESIM_OUTPUT_DIR, datalake by default
+func outputDir() string {
+	if dir := os.Getenv("VEHICLESIM_OUTPUT_DIR"); dir != "" {
+		return dir
 	}
+	return "datalake"
 }
 
-func performGearShift(motor *engine.Engine, manualGB *gearbox.ManualGearbox, shiftGear func() bool) {
-	// Save the current throttle position
-	currentAccel := motor.GetData().AcceleratorPosition
+func initializeEngineState(motor *engine.Engine) {
+	// Initial state of the engine
+	motor.SetAcceleratorPos(0.0) // Accelerator depressed
+
+	// Wait for the engine to idle
+	fmt.Println("Initializing engine at idle...")
+	time.Sleep(2 * time.Second)
+
+	fmt.Printf("Initial state established:\n")
+	fmt.Printf("- Engine idling\n")
 
-	// Gear shift sequence
-	motor.SetAcceleratorPos(0.3) // Reduce acceleration
-	time.Sleep(100 * time.Millisecond)
+}
 
-	manualGB.SetClutch(0.0) // Press clutch
-	time.Sleep(200 * time.Millisecond)
+func initializeGearboxState(gearbox *gearbox.ManualGearbox) {
+	// Initial state of the gearbox
+	gearbox.SetClutch(0.0) // Clutch pressed
+	gearbox.SetGear(0)     // Neutral
 
-	shiftGear() // Change gear
-	time.Sleep(200 * time.Millisecond)
+	// Wait for the engine to idle
+	fmt.Println("Initializing gearbox...")
+	time.Sleep(2 * time.Second)
 
-	// Release clutch gradually
-	for clutch := 0.0; clutch <= 1.0; clutch += 0.2 {
-		manualGB.SetClutch(clutch)
-		time.Sleep(50 * time.Millisecond)
-	}
+	// Prepare first gear, the clutch stays pressed until the launch
+	gearbox.SetGear(1) // Engage first gear
 
-	// Restore throttle gradually
-	motor.SetAcceleratorPos(currentAccel)
+	fmt.Printf("- First gear engaged\n")
+	fmt.Printf("- Clutch ready\n")
 }
 
-func createEnginePoint(engineData engine.Telemetry) *write.Point {
-	return write.NewPoint(
-		"engine",
-		map[string]string{
-			"simulation": "engine1",
-		},
-		map[string]interface{}{
-			"rpm":            engineData.RPM,
-			"torque":         engineData.Torque,
-			"oil_temp":       engineData.OilTemp,
-			"accel_position": engineData.AcceleratorPosition,
-			"engine_state":   engineData.EngineState,
-			"power_kw":       engineData.PowerKW,
-			"power_hp":       engineData.PowerHP,
-		},
-		time.Now(),
-	)
+// syncEngineRPM returns the engine RPM that matches the current ground speed in the given gear
+func syncEngineRPM(wheelPair *wheels.WheelPair, diff *differential.Differential, manualGB *gearbox.ManualGearbox, gear int) float64 {
+	return units.Geared(wheelPair.GetRollingSpeed(), diff.GetGearRatio()*manualGB.GetGearRatio(gear)).RPM()
 }
 
-func createGearboxPoint(gearboxData gearbox.Telemetry) *write.Point {
+func createShiftPoint(vehicleID string, shiftEvent gearbox.ShiftEvent, timestamp time.Time) *write.Point {
 	return write.NewPoint(
-		"gearbox",
+		"shift",
 		map[string]string{
 			"simulation": "gearbox1",
+			"vehicle_id": vehicleID,
+			"direction":  shiftEvent.Direction(),
+			"from_gear":  fmt.Sprintf("%d", shiftEvent.FromGear),
+			"to_gear":    fmt.Sprintf("%d", shiftEvent.ToGear),
 		},
 		map[string]interface{}{
-			"input_shaft":         gearboxData.InputShaft,
-			"output_shaft":        gearboxData.OutputShaft,
-			"current_gear":        gearboxData.CurrentGear,
-			"clutch_position":     gearboxData.ClutchPosition,
-			"input_shaft_torque":  gearboxData.InputShaftTorque,
-			"output_shaft_torque": gearboxData.OutputShaftTorque,
-			//"wheel_rpm":       gearboxData.WheelRPM,
-			//"wheel_torque":    gearboxData.WheelTorque,
+			"duration":            shiftEvent.Duration,
+			"torque_interruption": shiftEvent.TorqueInterruption,
+			"peak_jerk":           shiftEvent.PeakJerk,
+			"clutch_energy":       shiftEvent.ClutchEnergy,
+			"rpm_mismatch":        shiftEvent.RPMMismatch,
 		},
-		time.Now(),
+		timestamp,
 	)
 }
 
-func createDifferentialPoint(differentialData differential.Telemetry) *write.Point {
-	return write.NewPoint(
-		"differential",
-		map[string]string{
-			"simulation": "basic_differential",
-		},
-		map[string]interface{}{
-			"wheel_speed_left":  differentialData.WheelSpeedL,
-			"wheel_speed_right": differentialData.WheelSpeedR,
-		},
-		time.Now(),
-	)
+// pointTags returns the tags shared by every point: the component and the vehicle that produced it
+func pointTags(simulation string, vehicleID string) map[string]string {
+	return map[string]string{
+		"simulation": simulation,
+		"vehicle_id": vehicleID,
+	}
 }
 
-func createVehicleDynamicPoint(wheelData wheels.Telemetry) *write.Point {
-	return write.NewPoint(
-		"vehicle_dynamic",
-		map[string]string{
-			"simulation": "vehicle_dynamic",
-		},
-		map[string]interface{}{
-			"vehicle_speed_kmh": wheelData.VehicleSpeed.KMH,
-		},
-		time.Now(),
-	)
+// influxSimulations is the simulation tag of the points of each measurement of TelemetrySchema
+var influxSimulations = map[string]string{
+	"engine":           "engine1",
+	"gearbox":          "gearbox1",
+	"differential":     "basic_differential",
+	"vehicle_dynamic":  "vehicle_dynamic",
+	"traction_control": "tcs",
+	"cruise_control":   "cruise",
+	"route":            "vehicle_dynamic",
+	"cornering":        "vehicle_dynamic",
+	"gps":              "vehicle_dynamic",
 }
 
-func writePoints(writeAPI api.WriteAPIBlocking, points ...*write.Point) error {
-	for _, point := range points {
-		if err := writeAPI.WritePoint(context.Background(), point); err != nil {
-			return fmt.Errorf("error writing point: %v", err)
+// snapshotPoints converts the telemetry of one step into InfluxDB points, one per measurement of TelemetrySchema
+func snapshotPoints(snapshot Snapshot) []*write.Point {
+	id, ts := snapshot.VehicleID, snapshot.Time
+	var points []*write.Point
+	schema := snapshot.schemaFields()
+	fields := make(map[string]interface{})
+	for i, field := range schema {
+		fields[field.Name] = field.Value(snapshot)
+		if i == len(schema)-1 || schema[i+1].Measurement != field.Measurement {
+			points = append(points, write.NewPoint(field.Measurement, pointTags(influxSimulations[field.Measurement], id), fields, ts))
+			fields = make(map[string]interface{})
 		}
 	}
-	return nil
+	for _, shiftEvent := range snapshot.Shifts {
+		points = append(points, createShiftPoint(id, shiftEvent, ts))
+	}
+	return points
 }
 
-func printSimulationStatus(engineData engine.Telemetry, gearboxData gearbox.Telemetry, differentialData differential.Telemetry, wheelsData wheels.Telemetry) {
-	fmt.Printf(engineData.String())
-	fmt.Printf(gearboxData.String())
-	fmt.Printf(differentialData.String())
-	fmt.Printf(wheelsData.String())
+func printSimulationStatus(snapshot Snapshot) {
+	fmt.Print(snapshot.Engine.String())
+	fmt.Print(snapshot.Gearbox.String())
+	fmt.Print(snapshot.Differential.String())
+	fmt.Print(snapshot.Wheels.String())
+	fmt.Print(snapshot.Body.String())
+	fmt.Print(snapshot.TractionControl.String())
+	fmt.Print(snapshot.CruiseControl.String())
+	fmt.Print(snapshot.Route.String())
+	fmt.Print(snapshot.Steering.String())
+	fmt.Print(snapshot.GPS.String())
 }
@@ -0,0 +1,68 @@
+package vehiclesim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/api"
+	"go-playground/internal/justforfun/vehiclesim/influx"
+)
+
+// TelemetrySink receives the snapshot of every simulation step
+// Implementations must be safe for concurrent use, the fleet writes from several workers
+type TelemetrySink interface {
+	Write(snapshot Snapshot) error
+	Close() error
+}
+
+// MultiSink writes every snapshot to all its sinks
+type MultiSink []TelemetrySink
+
+// Write writes to every sink, even after one of them fails
+func (m MultiSink) Write(snapshot Snapshot) error {
+	var errs []error
+	for _, sink := range m {
+		errs = append(errs, sink.Write(snapshot))
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes every sink
+func (m MultiSink) Close() error {
+	var errs []error
+	for _, sink := range m {
+		errs = append(errs, sink.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// InfluxSink writes snapshots to InfluxDB, one request per snapshot
+type InfluxSink struct {
+	client   influxdb2.Client
+	writeAPI api.WriteAPIBlocking
+}
+
+// NewInfluxSink connects to the bucket of the given configuration
+func NewInfluxSink(config influx.ConfigInfluxDB) *InfluxSink {
+	client := influx.NewInfluxDBClient(config)
+	return &InfluxSink{
+		client: client,
+		// The blocking write API can be used concurrently
+		writeAPI: client.WriteAPIBlocking(config.Org, config.Bucket),
+	}
+}
+
+// Write sends every point of the snapshot in a single request
+func (s *InfluxSink) Write(snapshot Snapshot) error {
+	if err := s.writeAPI.WritePoint(context.Background(), snapshotPoints(snapshot)...); err != nil {
+		return fmt.Errorf("error writing points of %s: %v", snapshot.VehicleID, err)
+	}
+	return nil
+}
+
+// Close releases the InfluxDB client
+func (s *InfluxSink) Close() error {
+	s.client.Close()
+	return nil
+}
@@ -0,0 +1,194 @@
+package sparkplug
+
+import (
+	"sync"
+	"time"
+)
+
+// Metrics of the edge node itself
+const (
+	BDSeqMetric   = "bdSeq"                // Birth/death sequence number, pairs an NBIRTH with its NDEATH
+	RebirthMetric = "Node Control/Rebirth" // Set to true in an NCMD to ask for the births again
+)
+
+// Message is a payload to publish on its topic
+type Message struct {
+	Topic   Topic
+	Payload *Payload
+}
+
+// EdgeNode keeps the session state of a Sparkplug B edge node and its devices: the message
+// sequence number, the birth/death sequence number and the metric aliases. It builds the
+// messages, the MQTT client must publish them in the order they are built. Safe for concurrent use.
+type EdgeNode struct {
+	Group string
+	ID    string
+
+	mu        sync.Mutex
+	bdSeq     uint64
+	seq       uint64
+	nextAlias uint64
+	devices   map[string]*device
+	order     []string // Devices in birth order
+}
+
+// device holds the metrics reported by a device, by name
+type device struct {
+	aliases map[string]uint64
+	last    map[string]Metric
+	order   []string // Metric names in birth order
+}
+
+// NewEdgeNode returns the edge node ID of the group, bdSeq is the birth/death sequence number
+// of the session, the same in the MQTT will and the births
+func NewEdgeNode(group, id string, bdSeq uint64) (*EdgeNode, error) {
+	for _, level := range []string{group, id} {
+		if err := ValidateID(level); err != nil {
+			return nil, err
+		}
+	}
+	return &EdgeNode{Group: group, ID: id, bdSeq: bdSeq % 256, devices: make(map[string]*device)}, nil
+}
+
+// Death is the NDEATH of the session, to register as the MQTT will before connecting
+// and to publish when disconnecting cleanly
+func (n *EdgeNode) Death() Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.death()
+}
+
+// NextSession moves on to the next birth/death sequence number and returns its NDEATH
+// Call it before every connection attempt, the broker keeps the will of the last one
+func (n *EdgeNode) NextSession() Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.bdSeq = (n.bdSeq + 1) % 256
+	return n.death()
+}
+
+func (n *EdgeNode) death() Message {
+	return Message{
+		Topic: Topic{Group: n.Group, Type: NodeDeath, EdgeNode: n.ID},
+		Payload: &Payload{
+			Timestamp: milliseconds(time.Now()),
+			Metrics:   []Metric{IntMetric(BDSeqMetric, UInt64, int64(n.bdSeq))},
+		},
+	}
+}
+
+// Birth starts the sequence over with the NBIRTH, followed by the DBIRTH of every device
+// with its last values. Publish it after every (re)connection and on rebirth requests.
+func (n *EdgeNode) Birth(timestamp time.Time) []Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.seq = 0
+	messages := []Message{n.message(Topic{Type: NodeBirth}, timestamp, []Metric{
+		IntMetric(BDSeqMetric, UInt64, int64(n.bdSeq)),
+		BooleanMetric(RebirthMetric, false),
+	})}
+	for _, name := range n.order {
+		messages = append(messages, n.deviceBirth(name, timestamp))
+	}
+	return messages
+}
+
+// Data reports the metrics of a device: a DBIRTH with all of them the first time or when new
+// metrics show up, then a DDATA with those that changed, by alias. Returns false when nothing changed.
+func (n *EdgeNode) Data(name string, timestamp time.Time, metrics []Metric) (Message, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	d, ok := n.devices[name]
+	if !ok {
+		d = &device{aliases: make(map[string]uint64), last: make(map[string]Metric)}
+		n.devices[name] = d
+		n.order = append(n.order, name)
+	}
+
+	var changed []Metric
+	reborn := !ok
+	for _, metric := range metrics {
+		alias, known := d.aliases[metric.Name]
+		if !known {
+			n.nextAlias++
+			alias = n.nextAlias
+			d.aliases[metric.Name] = alias
+			d.order = append(d.order, metric.Name)
+			reborn = true
+		}
+		metric.Alias = alias
+		metric.Timestamp = milliseconds(timestamp)
+		if last, ok := d.last[metric.Name]; ok && last.Value == metric.Value && last.IsNull == metric.IsNull {
+			continue
+		}
+		d.last[metric.Name] = metric
+
+		metric.Name, metric.Unit = "", ""
+		changed = append(changed, metric)
+	}
+
+	if reborn {
+		return n.deviceBirth(name, timestamp), true
+	}
+	if len(changed) == 0 {
+		return Message{}, false
+	}
+	return n.message(Topic{Type: DeviceData, Device: name}, timestamp, changed), true
+}
+
+// DeviceDeath forgets the device and returns its DDEATH
+func (n *EdgeNode) DeviceDeath(name string, timestamp time.Time) Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	delete(n.devices, name)
+	for i, device := range n.order {
+		if device == name {
+			n.order = append(n.order[:i], n.order[i+1:]...)
+			break
+		}
+	}
+	return n.message(Topic{Type: DeviceDeath, Device: name}, timestamp, nil)
+}
+
+// Devices returns the devices born, in birth order
+func (n *EdgeNode) Devices() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.order...)
+}
+
+// IsRebirthRequest reports whether an NCMD payload asks for the births again
+func IsRebirthRequest(payload *Payload) bool {
+	for _, metric := range payload.Metrics {
+		if metric.Name == RebirthMetric && metric.Value == true {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *EdgeNode) deviceBirth(name string, timestamp time.Time) Message {
+	d := n.devices[name]
+	metrics := make([]Metric, len(d.order))
+	for i, metric := range d.order {
+		metrics[i] = d.last[metric]
+	}
+	return n.message(Topic{Type: DeviceBirth, Device: name}, timestamp, metrics)
+}
+
+// message builds a message of the node with the next sequence number, from 0 to 255
+func (n *EdgeNode) message(topic Topic, timestamp time.Time, metrics []Metric) Message {
+	topic.Group, topic.EdgeNode = n.Group, n.ID
+	seq := n.seq
+	n.seq = (n.seq + 1) % 256
+	return Message{Topic: topic, Payload: &Payload{Timestamp: milliseconds(timestamp), Metrics: metrics, Seq: &seq}}
+}
+
+func milliseconds(t time.Time) uint64 {
+	return uint64(t.UnixMilli())
+}
@@ -0,0 +1,301 @@
+package sparkplug
+
+import (
+	"fmt"
+	"math"
+)
+
+// DataType is the Sparkplug B type of a metric value
+type DataType uint32
+
+const (
+	Int8     DataType = 1
+	Int16    DataType = 2
+	Int32    DataType = 3
+	Int64    DataType = 4
+	UInt8    DataType = 5
+	UInt16   DataType = 6
+	UInt32   DataType = 7
+	UInt64   DataType = 8
+	Float    DataType = 9
+	Double   DataType = 10
+	Boolean  DataType = 11
+	String   DataType = 12
+	DateTime DataType = 13
+	Text     DataType = 14
+)
+
+// Fields of the Payload and Payload.Metric messages of sparkplug_b.proto
+const (
+	payloadTimestamp = 1
+	payloadMetrics   = 2
+	payloadSeq       = 3
+
+	metricName         = 1
+	metricAlias        = 2
+	metricTimestamp    = 3
+	metricDataType     = 4
+	metricIsNull       = 7
+	metricProperties   = 9
+	metricIntValue     = 10
+	metricLongValue    = 11
+	metricFloatValue   = 12
+	metricDoubleValue  = 13
+	metricBooleanValue = 14
+	metricStringValue  = 15
+
+	propertySetKeys     = 1
+	propertySetValues   = 2
+	propertyType        = 1
+	propertyStringValue = 8
+
+	// unitProperty is the property of the engineering unit of a metric
+	unitProperty = "engUnit"
+)
+
+// Payload is a Sparkplug B message body
+type Payload struct {
+	Timestamp uint64 // Milliseconds since the epoch
+	Metrics   []Metric
+	Seq       *uint64 // Sequence number of the edge node messages, not set in NDEATH
+}
+
+// Metric is one value of a payload. Value is an int64 for the integer types (UInt64 values
+// beyond math.MaxInt64 wrap), a float64 for Float and Double, a bool or a string.
+type Metric struct {
+	Name      string // Empty in data messages, the alias identifies the metric
+	Alias     uint64
+	Timestamp uint64 // Milliseconds since the epoch, 0 when not set
+	DataType  DataType
+	IsNull    bool
+	Value     any
+	Unit      string // engUnit property, sent in births
+}
+
+// DoubleMetric returns a Double metric
+func DoubleMetric(name string, value float64) Metric {
+	return Metric{Name: name, DataType: Double, Value: value}
+}
+
+// IntMetric returns an integer metric of the given type
+func IntMetric(name string, dataType DataType, value int64) Metric {
+	return Metric{Name: name, DataType: dataType, Value: value}
+}
+
+// BooleanMetric returns a Boolean metric
+func BooleanMetric(name string, value bool) Metric {
+	return Metric{Name: name, DataType: Boolean, Value: value}
+}
+
+// StringMetric returns a String metric
+func StringMetric(name string, value string) Metric {
+	return Metric{Name: name, DataType: String, Value: value}
+}
+
+// Marshal encodes the payload in the protobuf format of sparkplug_b.proto
+func (p *Payload) Marshal() ([]byte, error) {
+	var b []byte
+	if p.Timestamp != 0 {
+		b = appendVarintField(b, payloadTimestamp, p.Timestamp)
+	}
+	for _, metric := range p.Metrics {
+		encoded, err := metric.marshal()
+		if err != nil {
+			return nil, err
+		}
+		b = appendBytesField(b, payloadMetrics, encoded)
+	}
+	if p.Seq != nil {
+		b = appendVarintField(b, payloadSeq, *p.Seq)
+	}
+	return b, nil
+}
+
+func (m *Metric) marshal() ([]byte, error) {
+	var b []byte
+	if m.Name != "" {
+		b = appendBytesField(b, metricName, []byte(m.Name))
+	}
+	if m.Alias != 0 {
+		b = appendVarintField(b, metricAlias, m.Alias)
+	}
+	if m.Timestamp != 0 {
+		b = appendVarintField(b, metricTimestamp, m.Timestamp)
+	}
+	b = appendVarintField(b, metricDataType, uint64(m.DataType))
+	if m.Unit != "" {
+		var value []byte
+		value = appendVarintField(value, propertyType, uint64(String))
+		value = appendBytesField(value, propertyStringValue, []byte(m.Unit))
+		var properties []byte
+		properties = appendBytesField(properties, propertySetKeys, []byte(unitProperty))
+		properties = appendBytesField(properties, propertySetValues, value)
+		b = appendBytesField(b, metricProperties, properties)
+	}
+	if m.IsNull {
+		return appendVarintField(b, metricIsNull, 1), nil
+	}
+
+	invalid := fmt.Errorf("metric %q of type %d has a %T value", m.Name, m.DataType, m.Value)
+	switch m.DataType {
+	case Int8, Int16, Int32, UInt8, UInt16, UInt32:
+		value, ok := m.Value.(int64)
+		if !ok {
+			return nil, invalid
+		}
+		b = appendVarintField(b, metricIntValue, uint64(uint32(value)))
+	case Int64, UInt64, DateTime:
+		value, ok := m.Value.(int64)
+		if !ok {
+			return nil, invalid
+		}
+		b = appendVarintField(b, metricLongValue, uint64(value))
+	case Float:
+		value, ok := m.Value.(float64)
+		if !ok {
+			return nil, invalid
+		}
+		b = appendFixed32Field(b, metricFloatValue, math.Float32bits(float32(value)))
+	case Double:
+		value, ok := m.Value.(float64)
+		if !ok {
+			return nil, invalid
+		}
+		b = appendDoubleField(b, metricDoubleValue, value)
+	case Boolean:
+		value, ok := m.Value.(bool)
+		if !ok {
+			return nil, invalid
+		}
+		var bit uint64
+		if value {
+			bit = 1
+		}
+		b = appendVarintField(b, metricBooleanValue, bit)
+	case String, Text:
+		value, ok := m.Value.(string)
+		if !ok {
+			return nil, invalid
+		}
+		b = appendBytesField(b, metricStringValue, []byte(value))
+	default:
+		return nil, fmt.Errorf("metric %q has the unsupported type %d", m.Name, m.DataType)
+	}
+	return b, nil
+}
+
+// Unmarshal decodes a payload, metrics of unsupported types (datasets, templates, ...) are skipped
+func Unmarshal(b []byte) (*Payload, error) {
+	fields, err := readFields(b)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding payload: %v", err)
+	}
+
+	payload := &Payload{}
+	for _, f := range fields {
+		switch f.number {
+		case payloadTimestamp:
+			payload.Timestamp = f.value
+		case payloadSeq:
+			seq := f.value
+			payload.Seq = &seq
+		case payloadMetrics:
+			metric, err := unmarshalMetric(f.data)
+			if err != nil {
+				return nil, fmt.Errorf("error decoding metric: %v", err)
+			}
+			if metric.Value != nil || metric.IsNull {
+				payload.Metrics = append(payload.Metrics, metric)
+			}
+		}
+	}
+	return payload, nil
+}
+
+func unmarshalMetric(b []byte) (Metric, error) {
+	fields, err := readFields(b)
+	if err != nil {
+		return Metric{}, err
+	}
+
+	var metric Metric
+	for _, f := range fields {
+		switch f.number {
+		case metricName:
+			metric.Name = string(f.data)
+		case metricAlias:
+			metric.Alias = f.value
+		case metricTimestamp:
+			metric.Timestamp = f.value
+		case metricDataType:
+			metric.DataType = DataType(f.value)
+		case metricIsNull:
+			metric.IsNull = f.value != 0
+		case metricProperties:
+			if metric.Unit, err = unmarshalUnit(f.data); err != nil {
+				return Metric{}, err
+			}
+		}
+	}
+
+	for _, f := range fields {
+		switch f.number {
+		case metricIntValue:
+			switch metric.DataType {
+			case Int8:
+				metric.Value = int64(int8(f.value))
+			case Int16:
+				metric.Value = int64(int16(f.value))
+			case Int32:
+				metric.Value = int64(int32(f.value))
+			default:
+				metric.Value = int64(uint32(f.value))
+			}
+		case metricLongValue:
+			metric.Value = int64(f.value)
+		case metricFloatValue:
+			metric.Value = float64(math.Float32frombits(uint32(f.value)))
+		case metricDoubleValue:
+			metric.Value = math.Float64frombits(f.value)
+		case metricBooleanValue:
+			metric.Value = f.value != 0
+		case metricStringValue:
+			metric.Value = string(f.data)
+		}
+	}
+	return metric, nil
+}
+
+// unmarshalUnit finds the engUnit property in a property set
+func unmarshalUnit(b []byte) (string, error) {
+	fields, err := readFields(b)
+	if err != nil {
+		return "", err
+	}
+	var keys []string
+	var values [][]byte
+	for _, f := range fields {
+		switch f.number {
+		case propertySetKeys:
+			keys = append(keys, string(f.data))
+		case propertySetValues:
+			values = append(values, f.data)
+		}
+	}
+
+	for i, key := range keys {
+		if key != unitProperty || i >= len(values) {
+			continue
+		}
+		fields, err := readFields(values[i])
+		if err != nil {
+			return "", err
+		}
+		for _, f := range fields {
+			if f.number == propertyStringValue {
+				return string(f.data), nil
+			}
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,97 @@
+package sparkplug
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Protobuf wire types used by the Sparkplug B payload
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendKey(b []byte, field int, wireType int) []byte {
+	return appendVarint(b, uint64(field)<<3|uint64(wireType))
+}
+
+func appendVarintField(b []byte, field int, v uint64) []byte {
+	return appendVarint(appendKey(b, field, wireVarint), v)
+}
+
+func appendBytesField(b []byte, field int, v []byte) []byte {
+	b = appendVarint(appendKey(b, field, wireBytes), uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendFixed32Field(b []byte, field int, v uint32) []byte {
+	return binary.LittleEndian.AppendUint32(appendKey(b, field, wireFixed32), v)
+}
+
+func appendFixed64Field(b []byte, field int, v uint64) []byte {
+	return binary.LittleEndian.AppendUint64(appendKey(b, field, wireFixed64), v)
+}
+
+func appendDoubleField(b []byte, field int, v float64) []byte {
+	return appendFixed64Field(b, field, math.Float64bits(v))
+}
+
+// field is one decoded protobuf field, value holds varints and fixed numbers, data length delimited fields
+type field struct {
+	number   int
+	wireType int
+	value    uint64
+	data     []byte
+}
+
+// readFields splits a protobuf message into its fields
+func readFields(b []byte) ([]field, error) {
+	var fields []field
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid field key")
+		}
+		b = b[n:]
+
+		f := field{number: int(key >> 3), wireType: int(key & 7)}
+		switch f.wireType {
+		case wireVarint:
+			if f.value, n = binary.Uvarint(b); n <= 0 {
+				return nil, fmt.Errorf("invalid varint in field %d", f.number)
+			}
+			b = b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				return nil, fmt.Errorf("truncated field %d", f.number)
+			}
+			f.value, b = binary.LittleEndian.Uint64(b), b[8:]
+		case wireFixed32:
+			if len(b) < 4 {
+				return nil, fmt.Errorf("truncated field %d", f.number)
+			}
+			f.value, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		case wireBytes:
+			length, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < length {
+				return nil, fmt.Errorf("truncated field %d", f.number)
+			}
+			f.data, b = b[n:n+int(length)], b[n+int(length):]
+		default:
+			return nil, fmt.Errorf("unsupported wire type %d in field %d", f.wireType, f.number)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
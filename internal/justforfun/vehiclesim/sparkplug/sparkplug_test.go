@@ -0,0 +1,155 @@
+package sparkplug
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPayload(t *testing.T) {
+	seq := uint64(2)
+	payload := &Payload{Timestamp: 1, Metrics: []Metric{DoubleMetric("a", 1)}, Seq: &seq}
+	got, err := payload.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	// timestamp 1, metric {name "a", datatype Double, double_value 1.0}, seq 2
+	want := []byte{0x08, 0x01, 0x12, 0x0e, 0x0a, 0x01, 'a', 0x20, 0x0a, 0x69, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f, 0x18, 0x02}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Marshal = % x, want % x", got, want)
+	}
+
+	payload = &Payload{
+		Timestamp: 1700000000123,
+		Metrics: []Metric{
+			{Name: "Engine/RPM", Alias: 1, Timestamp: 1700000000123, DataType: Double, Value: 2500.5, Unit: "rpm"},
+			IntMetric("Gearbox/Gear", Int32, -1),
+			IntMetric("bdSeq", UInt64, 200),
+			IntMetric("Byte", UInt8, 255),
+			{Name: "Float", DataType: Float, Value: 0.5},
+			BooleanMetric("Node Control/Rebirth", true),
+			StringMetric("Engine/State", "Running"),
+			{Name: "Unknown", DataType: Double, IsNull: true},
+		},
+		Seq: &seq,
+	}
+	encoded, err := payload.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	decoded, err := Unmarshal(encoded)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, payload) {
+		t.Errorf("Unmarshal(Marshal(p)) = %+v, want %+v", decoded, payload)
+	}
+
+	if _, err := (&Payload{Metrics: []Metric{{Name: "x", DataType: Double, Value: 1}}}).Marshal(); err == nil {
+		t.Error("Marshal of an int Double: expected an error")
+	}
+	if _, err := Unmarshal([]byte{0x12, 0x05, 0x0a}); err == nil {
+		t.Error("Unmarshal of a truncated metric: expected an error")
+	}
+}
+
+func TestTopic(t *testing.T) {
+	topic := Topic{Group: "fleet", Type: DeviceData, EdgeNode: "simulator", Device: "vehicle-001"}
+	if topic.String() != "spBv1.0/fleet/DDATA/simulator/vehicle-001" {
+		t.Errorf("Topic = %s", topic)
+	}
+	if parsed, err := ParseTopic(topic.String()); err != nil || parsed != topic {
+		t.Errorf("ParseTopic = %+v, %v", parsed, err)
+	}
+	for _, invalid := range []string{"spBv1.0/fleet/NBIRTH", "spAv1.0/fleet/NBIRTH/node", "spBv1.0/a/DDATA/b/c/d"} {
+		if _, err := ParseTopic(invalid); err == nil {
+			t.Errorf("ParseTopic(%q): expected an error", invalid)
+		}
+	}
+	if _, err := NewEdgeNode("fleet", "node/1", 0); err == nil {
+		t.Error("NewEdgeNode with a / in the ID: expected an error")
+	}
+}
+
+func TestEdgeNode(t *testing.T) {
+	node, err := NewEdgeNode("fleet", "simulator", 300)
+	if err != nil {
+		t.Fatalf("NewEdgeNode: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+
+	death := node.Death()
+	if death.Topic.Type != NodeDeath || death.Payload.Seq != nil || death.Payload.Metrics[0].Value != int64(44) {
+		t.Errorf("NDEATH = %+v, want bdSeq 44 and no seq", death.Payload)
+	}
+
+	births := node.Birth(now)
+	if len(births) != 1 || births[0].Topic.Type != NodeBirth || *births[0].Payload.Seq != 0 {
+		t.Fatalf("Birth = %+v, want a single NBIRTH with seq 0", births)
+	}
+	if births[0].Payload.Metrics[0].Value != int64(44) || births[0].Payload.Metrics[1].Name != RebirthMetric {
+		t.Errorf("NBIRTH metrics = %+v", births[0].Payload.Metrics)
+	}
+
+	metrics := []Metric{DoubleMetric("Engine/RPM", 800), StringMetric("Engine/State", "Running")}
+	message, ok := node.Data("vehicle-001", now, metrics)
+	if !ok || message.Topic.Type != DeviceBirth || message.Topic.Device != "vehicle-001" || *message.Payload.Seq != 1 {
+		t.Fatalf("first Data = %+v, want the DBIRTH with seq 1", message)
+	}
+	if m := message.Payload.Metrics[1]; m.Name != "Engine/State" || m.Alias != 2 || m.Value != "Running" {
+		t.Errorf("DBIRTH metric = %+v", m)
+	}
+
+	if _, ok := node.Data("vehicle-001", now, metrics); ok {
+		t.Error("Data without changes: expected no message")
+	}
+
+	metrics[0] = DoubleMetric("Engine/RPM", 900)
+	message, ok = node.Data("vehicle-001", now.Add(time.Second), metrics)
+	if !ok || message.Topic.Type != DeviceData || len(message.Payload.Metrics) != 1 || *message.Payload.Seq != 2 {
+		t.Fatalf("Data = %+v, want a DDATA with the RPM", message)
+	}
+	if m := message.Payload.Metrics[0]; m.Name != "" || m.Alias != 1 || m.Value != 900.0 {
+		t.Errorf("DDATA metric = %+v, want the value by alias", m)
+	}
+
+	// A rebirth restarts the sequence and repeats the devices with their last values
+	if !IsRebirthRequest(&Payload{Metrics: []Metric{BooleanMetric(RebirthMetric, true)}}) {
+		t.Error("IsRebirthRequest = false")
+	}
+	births = node.Birth(now.Add(2 * time.Second))
+	if len(births) != 2 || *births[1].Payload.Seq != 1 || births[1].Payload.Metrics[0].Value != 900.0 {
+		t.Errorf("rebirth = %+v", births)
+	}
+
+	for i := 0; i < 300; i++ {
+		metrics[0] = DoubleMetric("Engine/RPM", float64(i))
+		message, _ = node.Data("vehicle-001", now, metrics)
+	}
+	if *message.Payload.Seq != (2+299)%256 {
+		t.Errorf("seq = %d, want %d", *message.Payload.Seq, (2+299)%256)
+	}
+
+	if death := node.DeviceDeath("vehicle-001", now); death.Topic.Type != DeviceDeath || len(node.Devices()) != 0 {
+		t.Errorf("DeviceDeath = %+v, devices left %v", death, node.Devices())
+	}
+
+	// Every connection is a new session, the births carry the bdSeq of the will
+	for _, want := range []int64{45, 46} {
+		death := node.NextSession()
+		births := node.Birth(now)
+		if death.Payload.Metrics[0].Value != want || births[0].Payload.Metrics[0].Value != want || node.Death().Payload.Metrics[0].Value != want {
+			t.Errorf("next session: NDEATH %+v, NBIRTH %+v, want bdSeq %d", death.Payload.Metrics, births[0].Payload.Metrics, want)
+		}
+	}
+	for i := 0; i < 209; i++ {
+		node.NextSession()
+	}
+	if death := node.Death(); death.Payload.Metrics[0].Value != int64(255) {
+		t.Errorf("bdSeq = %v, want 255", death.Payload.Metrics[0].Value)
+	}
+	if death := node.NextSession(); death.Payload.Metrics[0].Value != int64(0) {
+		t.Errorf("bdSeq after 255 = %v, want 0", death.Payload.Metrics[0].Value)
+	}
+}
@@ -0,0 +1,60 @@
+package sparkplug
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Namespace is the first level of every Sparkplug B topic
+const Namespace = "spBv1.0"
+
+// MessageType is the third level of a topic
+type MessageType string
+
+const (
+	NodeBirth     MessageType = "NBIRTH"
+	NodeDeath     MessageType = "NDEATH"
+	NodeData      MessageType = "NDATA"
+	NodeCommand   MessageType = "NCMD"
+	DeviceBirth   MessageType = "DBIRTH"
+	DeviceDeath   MessageType = "DDEATH"
+	DeviceData    MessageType = "DDATA"
+	DeviceCommand MessageType = "DCMD"
+)
+
+// Topic is spBv1.0/{group}/{type}/{edge node}[/{device}]
+type Topic struct {
+	Group    string
+	Type     MessageType
+	EdgeNode string
+	Device   string // Empty for node messages
+}
+
+func (t Topic) String() string {
+	topic := fmt.Sprintf("%s/%s/%s/%s", Namespace, t.Group, t.Type, t.EdgeNode)
+	if t.Device != "" {
+		topic += "/" + t.Device
+	}
+	return topic
+}
+
+// ParseTopic splits a Sparkplug B topic in its levels
+func ParseTopic(topic string) (Topic, error) {
+	levels := strings.Split(topic, "/")
+	if len(levels) < 4 || len(levels) > 5 || levels[0] != Namespace {
+		return Topic{}, fmt.Errorf("invalid Sparkplug B topic %q, expected %s/group/type/node[/device]", topic, Namespace)
+	}
+	parsed := Topic{Group: levels[1], Type: MessageType(levels[2]), EdgeNode: levels[3]}
+	if len(levels) == 5 {
+		parsed.Device = levels[4]
+	}
+	return parsed, nil
+}
+
+// ValidateID checks that a group, edge node or device ID can be a topic level
+func ValidateID(id string) error {
+	if id == "" || strings.ContainsAny(id, "/+#") {
+		return fmt.Errorf("invalid Sparkplug B ID %q, it must be non empty without /, + or #", id)
+	}
+	return nil
+}
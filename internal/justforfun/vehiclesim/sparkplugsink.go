@@ -0,0 +1,182 @@
+package vehiclesim
+
+import (
+	"errors"
+	"fmt"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"go-playground/internal/justforfun/mqtt/publisher"
+	"go-playground/internal/justforfun/vehiclesim/sparkplug"
+	"log"
+	"sync"
+	"time"
+)
+
+// sparkplugTimeout bounds the wait for the broker on connect and publish
+const sparkplugTimeout = 5 * time.Second
+
+// SparkplugConfig is the MQTT broker and the Sparkplug B identity of the simulator,
+// every vehicle is a device of the edge node
+type SparkplugConfig struct {
+	Broker   string // e.g. "tcp://localhost:1883"
+	ClientID string
+	Group    string
+	EdgeNode string
+}
+
+// SparkplugSink publishes the snapshots as Sparkplug B device data: a DBIRTH per vehicle, then DDATA
+// with the metrics that changed. Every connection is a new session with the next bdSeq: the node births
+// again on every reconnection and on rebirth commands, the broker publishes its NDEATH if the simulator drops off.
+type SparkplugSink struct {
+	client *publisher.Publisher
+	node   *sparkplug.EdgeNode
+
+	mu     sync.Mutex // Publishes in sequence order
+	online bool       // NBIRTH published on the current connection
+}
+
+// NewSparkplugSink connects to the broker with the NDEATH as will and publishes the NBIRTH
+func NewSparkplugSink(config SparkplugConfig) (*SparkplugSink, error) {
+	// The birth/death sequence number tells the sessions apart, a late NDEATH of a previous
+	// run must not end the current one
+	node, err := sparkplug.NewEdgeNode(config.Group, config.EdgeNode, uint64(time.Now().Unix()))
+	if err != nil {
+		return nil, err
+	}
+
+	sink := &SparkplugSink{node: node}
+	opts := publisher.NewClientOptions(config.Broker, config.ClientID)
+	opts.SetCleanSession(true)
+	opts.SetOrderMatters(false) // The command handler publishes the births
+	opts.SetOnConnectHandler(sink.onConnect)
+	opts.SetConnectionLostHandler(func(client mqtt.Client, err error) {
+		log.Printf("Sparkplug connection lost: %v", err)
+		sink.mu.Lock()
+		sink.online = false
+		sink.mu.Unlock()
+	})
+	opts.SetReconnectingHandler(func(client mqtt.Client, opts *mqtt.ClientOptions) {
+		if err := sink.nextSession(opts); err != nil {
+			log.Printf("Error setting the Sparkplug will: %v", err)
+		}
+	})
+	if err := sink.nextSession(opts); err != nil {
+		return nil, err
+	}
+
+	sink.client = publisher.NewPublisher(opts, sparkplugTimeout)
+	if err := sink.client.Connect(); err != nil {
+		return nil, err
+	}
+	return sink, nil
+}
+
+// nextSession sets the NDEATH of a new birth/death sequence number as the will of the next connection
+func (s *SparkplugSink) nextSession(opts *mqtt.ClientOptions) error {
+	death := s.node.NextSession()
+	will, err := death.Payload.Marshal()
+	if err != nil {
+		return fmt.Errorf("error encoding %s: %v", death.Topic, err)
+	}
+	opts.SetBinaryWill(death.Topic.String(), will, 1, false)
+	return nil
+}
+
+// onConnect listens to the node commands and publishes the births
+func (s *SparkplugSink) onConnect(client mqtt.Client) {
+	commands := sparkplug.Topic{Group: s.node.Group, Type: sparkplug.NodeCommand, EdgeNode: s.node.ID}
+	if err := s.client.Subscribe(commands.String(), 1, s.onCommand); err != nil {
+		log.Printf("Error subscribing: %v", err)
+	}
+
+	if err := s.birth(); err != nil {
+		log.Printf("Error publishing Sparkplug births: %v", err)
+	}
+}
+
+// onCommand births again when a host application asks for it
+func (s *SparkplugSink) onCommand(client mqtt.Client, message mqtt.Message) {
+	payload, err := sparkplug.Unmarshal(message.Payload())
+	if err != nil {
+		log.Printf("Error reading Sparkplug command: %v", err)
+		return
+	}
+	if sparkplug.IsRebirthRequest(payload) {
+		if err := s.birth(); err != nil {
+			log.Printf("Error publishing Sparkplug births: %v", err)
+		}
+	}
+}
+
+func (s *SparkplugSink) birth() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, message := range s.node.Birth(time.Now()) {
+		if err := s.publish(message); err != nil {
+			return err
+		}
+	}
+	s.online = true
+	return nil
+}
+
+// Write publishes the DBIRTH or DDATA of the vehicle, snapshots are dropped while disconnected
+func (s *SparkplugSink) Write(snapshot Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.online {
+		return nil
+	}
+	message, ok := s.node.Data(snapshot.VehicleID, snapshot.Time, SparkplugMetrics(snapshot))
+	if !ok {
+		return nil
+	}
+	return s.publish(message)
+}
+
+// Close publishes the death of every vehicle and of the node, then disconnects
+func (s *SparkplugSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	if s.online {
+		for _, device := range s.node.Devices() {
+			errs = append(errs, s.publish(s.node.DeviceDeath(device, time.Now())))
+		}
+		errs = append(errs, s.publish(s.node.Death()))
+		s.online = false
+	}
+	s.client.Disconnect(250)
+	return errors.Join(errs...)
+}
+
+func (s *SparkplugSink) publish(message sparkplug.Message) error {
+	payload, err := message.Payload.Marshal()
+	if err != nil {
+		return fmt.Errorf("error encoding %s: %v", message.Topic, err)
+	}
+	return s.client.Publish(message.Topic.String(), 0, false, payload)
+}
+
+// sparkplugTypes are the Sparkplug B types of the field types
+var sparkplugTypes = map[FieldType]sparkplug.DataType{
+	FloatField:  sparkplug.Double,
+	IntField:    sparkplug.Int64,
+	BoolField:   sparkplug.Boolean,
+	StringField: sparkplug.String,
+}
+
+// SparkplugMetrics returns the fields of TelemetrySchema as metrics named measurement/field
+func SparkplugMetrics(snapshot Snapshot) []sparkplug.Metric {
+	schema := snapshot.schemaFields()
+	metrics := make([]sparkplug.Metric, len(schema))
+	for i, field := range schema {
+		metrics[i] = sparkplug.Metric{
+			Name:     field.Measurement + "/" + field.Name,
+			DataType: sparkplugTypes[field.Type],
+			Value:    field.Value(snapshot),
+			Unit:     field.Unit,
+		}
+	}
+	return metrics
+}
@@ -0,0 +1,52 @@
+package vehiclesim
+
+import (
+	"go-playground/internal/justforfun/vehiclesim/route"
+	"go-playground/internal/justforfun/vehiclesim/sparkplug"
+	"testing"
+	"time"
+)
+
+// TestSparkplugMetrics births a vehicle with every metric, then sends only the changed ones
+func TestSparkplugMetrics(t *testing.T) {
+	vehicle, err := NewVehicle("vehicle-001", DefaultVehicleSpec(), 7, route.Default(), defaultStart, 0, time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("NewVehicle: %v", err)
+	}
+	node, err := sparkplug.NewEdgeNode("vehiclesim", "simulator", 0)
+	if err != nil {
+		t.Fatalf("NewEdgeNode: %v", err)
+	}
+	node.Birth(time.Unix(0, 0))
+
+	driver := NewScriptedDriver(NormalProfile())
+	driver.Drive(vehicle, 0.1)
+	snapshot := vehicle.Step(0.1)
+	metrics := SparkplugMetrics(snapshot)
+	birth, _ := node.Data(snapshot.VehicleID, snapshot.Time, metrics)
+	if birth.Topic.String() != "spBv1.0/vehiclesim/DBIRTH/simulator/vehicle-001" || len(birth.Payload.Metrics) != len(metrics) {
+		t.Fatalf("DBIRTH on %s with %d metrics, want %d", birth.Topic, len(birth.Payload.Metrics), len(metrics))
+	}
+	names := map[string]bool{}
+	for _, metric := range birth.Payload.Metrics {
+		if names[metric.Name] {
+			t.Errorf("metric %s reported twice", metric.Name)
+		}
+		names[metric.Name] = true
+	}
+	if _, err := birth.Payload.Marshal(); err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		driver.Drive(vehicle, 0.1)
+		snapshot = vehicle.Step(0.1)
+	}
+	data, ok := node.Data(snapshot.VehicleID, snapshot.Time, SparkplugMetrics(snapshot))
+	if !ok || data.Topic.Type != sparkplug.DeviceData {
+		t.Fatalf("Data = %+v, want a DDATA", data)
+	}
+	if len(data.Payload.Metrics) == 0 || len(data.Payload.Metrics) == len(metrics) {
+		t.Errorf("DDATA with %d of %d metrics, want only the changed ones", len(data.Payload.Metrics), len(metrics))
+	}
+}
@@ -0,0 +1,21 @@
+package steering
+
+// Checkpoint is the state of the steering needed to resume a simulation
+type Checkpoint struct {
+	SteeringWheelAngle float64
+	SpeedMS            float64
+}
+
+// Checkpoint returns the current state of the steering
+func (s *Steering) Checkpoint() Checkpoint {
+	return Checkpoint{
+		SteeringWheelAngle: s.steeringWheelAngle,
+		SpeedMS:            s.speedMS,
+	}
+}
+
+// Restore sets the steering back to a saved state
+func (s *Steering) Restore(c Checkpoint) {
+	s.steeringWheelAngle = c.SteeringWheelAngle
+	s.speedMS = c.SpeedMS
+}
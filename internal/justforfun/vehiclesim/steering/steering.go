@@ -0,0 +1,97 @@
+package steering
+
+import "math"
+
+// Steering is a kinematic bicycle model of the vehicle in corners
+// From the steering input and the speed it derives the turn radius, yaw rate, lateral
+// acceleration and the speed difference between the inner and outer driven wheels.
+type Steering struct {
+	wheelbaseM    float64 // Distance between axles
+	trackWidthM   float64 // Distance between the driven wheels
+	steeringRatio float64 // Steering wheel degrees per road wheel degree
+	maxWheelAngle float64 // Max steering wheel angle in degrees (lock to lock / 2)
+
+	steeringWheelAngle float64 // Degrees, positive turns left
+	speedMS            float64
+}
+
+// NewSteering creates a steering with typical values for a sedan
+func NewSteering() *Steering {
+	return &Steering{
+		wheelbaseM:    2.85,
+		trackWidthM:   1.60,
+		steeringRatio: 15,
+		maxWheelAngle: 540,
+	}
+}
+
+// SetSteeringWheelAngle sets the steering wheel angle in degrees, positive turns left
+func (s *Steering) SetSteeringWheelAngle(degrees float64) {
+	s.steeringWheelAngle = math.Max(-s.maxWheelAngle, math.Min(s.maxWheelAngle, degrees))
+}
+
+// SteerForCurvature sets the steering wheel angle needed to follow a road with the given curvature (1/m)
+func (s *Steering) SteerForCurvature(curvature float64) {
+	roadWheelAngle := math.Atan(s.wheelbaseM * curvature)
+	s.SetSteeringWheelAngle(roadWheelAngle * 180 / math.Pi * s.steeringRatio)
+}
+
+// Update sets the vehicle speed for the corner calculations
+// Parameters:
+//
+//	speedMS: ground speed of the vehicle in m/s
+func (s *Steering) Update(speedMS float64) {
+	s.speedMS = speedMS
+}
+
+// roadWheelAngle returns the angle of the front wheels in radians
+func (s *Steering) roadWheelAngle() float64 {
+	return s.steeringWheelAngle / s.steeringRatio * math.Pi / 180
+}
+
+// GetCurvature returns 1/radius of the path of the rear axle in 1/m, positive turns left
+func (s *Steering) GetCurvature() float64 {
+	return math.Tan(s.roadWheelAngle()) / s.wheelbaseM
+}
+
+// GetTurnRadius returns the radius of the path of the rear axle in meters, 0 when driving straight
+func (s *Steering) GetTurnRadius() float64 {
+	curvature := s.GetCurvature()
+	if curvature == 0 {
+		return 0
+	}
+	return 1 / curvature
+}
+
+// GetYawRate returns the rotation speed of the vehicle in rad/s
+func (s *Steering) GetYawRate() float64 {
+	return s.speedMS * s.GetCurvature()
+}
+
+// GetLateralAcceleration returns the centripetal acceleration in m/s²
+func (s *Steering) GetLateralAcceleration() float64 {
+	return s.speedMS * s.GetYawRate()
+}
+
+// GetSlipRatio returns the relative speed difference between the driven wheels for the differential
+// The inner wheel runs on a radius track/2 shorter and the outer one track/2 longer
+func (s *Steering) GetSlipRatio() float64 {
+	return s.trackWidthM * s.GetCurvature()
+}
+
+// GetWheelGroundSpeeds returns the ground speed in m/s of the left and right driven wheels
+func (s *Steering) GetWheelGroundSpeeds() (float64, float64) {
+	halfSlip := s.GetSlipRatio() / 2
+	return s.speedMS * (1 - halfSlip), s.speedMS * (1 + halfSlip)
+}
+
+// GetData returns the cornering telemetry
+func (s *Steering) GetData() Telemetry {
+	return Telemetry{
+		SteeringWheelAngle:  s.steeringWheelAngle,
+		TurnRadiusM:         s.GetTurnRadius(),
+		YawRate:             s.GetYawRate(),
+		LateralAcceleration: s.GetLateralAcceleration(),
+		SlipRatio:           s.GetSlipRatio(),
+	}
+}
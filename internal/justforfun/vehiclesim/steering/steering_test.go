@@ -0,0 +1,61 @@
+package steering
+
+import (
+	"math"
+	"testing"
+)
+
+// TestSteeringCorner follows a 50 m left hand corner at 72 km/h and checks the bicycle model
+func TestSteeringCorner(t *testing.T) {
+	s := NewSteering()
+	s.SteerForCurvature(1.0 / 50)
+	s.Update(20)
+
+	if got := s.GetTurnRadius(); math.Abs(got-50) > 1e-9 {
+		t.Errorf("turn radius %.3f m, want 50 m", got)
+	}
+	// atan(2.85/50) is 3.26° at the road wheels, 48.9° at the steering wheel
+	if got, want := s.GetData().SteeringWheelAngle, math.Atan(2.85/50)*180/math.Pi*15; math.Abs(got-want) > 1e-9 {
+		t.Errorf("steering wheel at %.2f°, want %.2f°", got, want)
+	}
+	if got := s.GetYawRate(); math.Abs(got-0.4) > 1e-9 {
+		t.Errorf("yaw rate %.3f rad/s, want 0.4", got)
+	}
+	if got := s.GetLateralAcceleration(); math.Abs(got-8) > 1e-9 {
+		t.Errorf("lateral acceleration %.3f m/s², want 8", got)
+	}
+
+	// The inner (left) wheel runs on a 49.2 m radius, the outer one on 50.8 m
+	left, right := s.GetWheelGroundSpeeds()
+	if math.Abs(left-20*49.2/50) > 1e-9 || math.Abs(right-20*50.8/50) > 1e-9 {
+		t.Errorf("wheel speeds %.3f/%.3f m/s, want %.3f/%.3f", left, right, 20*49.2/50, 20*50.8/50)
+	}
+
+	// Turning right mirrors everything
+	s.SteerForCurvature(-1.0 / 50)
+	if left, right := s.GetWheelGroundSpeeds(); right >= left || s.GetYawRate() >= 0 || s.GetTurnRadius() >= 0 {
+		t.Errorf("right hand corner: wheels %.3f/%.3f m/s, yaw rate %.3f, radius %.1f", left, right, s.GetYawRate(), s.GetTurnRadius())
+	}
+}
+
+// TestSteeringStraightAndLock checks straight ahead driving and the steering lock
+func TestSteeringStraightAndLock(t *testing.T) {
+	s := NewSteering()
+	s.Update(30)
+	if left, right := s.GetWheelGroundSpeeds(); s.GetTurnRadius() != 0 || s.GetYawRate() != 0 || left != 30 || right != 30 {
+		t.Errorf("straight ahead: radius %.1f m, yaw rate %.3f, wheels %.1f/%.1f m/s", s.GetTurnRadius(), s.GetYawRate(), left, right)
+	}
+
+	s.SetSteeringWheelAngle(900)
+	if got := s.GetData().SteeringWheelAngle; got != 540 {
+		t.Errorf("steering wheel at %.0f°, want the 540° lock", got)
+	}
+	s.SteerForCurvature(1)
+	if got := s.GetData().SteeringWheelAngle; got != 540 {
+		t.Errorf("1 m radius: steering wheel at %.0f°, want the 540° lock", got)
+	}
+	// At the lock the road wheels turn 36°, the car turns on 3.92 m
+	if got, want := s.GetTurnRadius(), 2.85/math.Tan(36*math.Pi/180); math.Abs(got-want) > 1e-9 {
+		t.Errorf("turn radius at the lock %.3f m, want %.3f m", got, want)
+	}
+}
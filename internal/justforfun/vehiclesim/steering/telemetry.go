@@ -0,0 +1,21 @@
+package steering
+
+import "fmt"
+
+type Telemetry struct {
+	SteeringWheelAngle  float64 // Degrees, positive turns left
+	TurnRadiusM         float64 // 0 when driving straight
+	YawRate             float64 // rad/s
+	LateralAcceleration float64 // m/s²
+	SlipRatio           float64 // Speed difference between driven wheels sent to the differential
+}
+
+// String implements the String interface for human-readable formatting
+func (d Telemetry) String() string {
+	return fmt.Sprintf("Steering [Angle: %.0f°, Radius: %.0f m, YawRate: %.3f rad/s, LateralAccel: %.2f m/s², SlipRatio: %.4f]\n",
+		d.SteeringWheelAngle,
+		d.TurnRadiusM,
+		d.YawRate,
+		d.LateralAcceleration,
+		d.SlipRatio)
+}
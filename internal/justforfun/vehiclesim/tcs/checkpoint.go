@@ -0,0 +1,37 @@
+package tcs
+
+// Checkpoint is the state of the traction control needed to resume a simulation
+// Enabled comes from the vehicle spec
+type Checkpoint struct {
+	ThrottleLimit float64
+	TorqueLimit   float64
+	BrakeTorqueL  float64
+	BrakeTorqueR  float64
+	SlipL         float64
+	SlipR         float64
+	Active        bool
+}
+
+// Checkpoint returns the current state of the traction control
+func (tc *TractionControl) Checkpoint() Checkpoint {
+	return Checkpoint{
+		ThrottleLimit: tc.throttleLimit,
+		TorqueLimit:   tc.torqueLimit,
+		BrakeTorqueL:  tc.brakeTorqueL,
+		BrakeTorqueR:  tc.brakeTorqueR,
+		SlipL:         tc.slipL,
+		SlipR:         tc.slipR,
+		Active:        tc.active,
+	}
+}
+
+// Restore sets the traction control back to a saved state
+func (tc *TractionControl) Restore(c Checkpoint) {
+	tc.throttleLimit = c.ThrottleLimit
+	tc.torqueLimit = c.TorqueLimit
+	tc.brakeTorqueL = c.BrakeTorqueL
+	tc.brakeTorqueR = c.BrakeTorqueR
+	tc.slipL = c.SlipL
+	tc.slipR = c.SlipR
+	tc.active = c.Active
+}
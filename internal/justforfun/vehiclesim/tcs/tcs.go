@@ -0,0 +1,129 @@
+package tcs
+
+import "math"
+
+// TractionControl watches the slip of the driven wheels and intervenes when it exceeds the target
+// It cuts the engine torque at once, closes the throttle over time and brakes the wheel that spins
+// more than the other one
+type TractionControl struct {
+	Enabled            bool
+	TargetSlip         float64 // Slip ratio above which the system intervenes
+	Aggressiveness     float64 // 0.0 (gentle) to 1.0 (aggressive) scales every intervention
+	MinThrottle        float64 // Lowest throttle override the system may command
+	RecoveryRate       float64 // Throttle override recovered per second once slip is under control
+	MinTorque          float64 // Lowest share of the engine torque the system may leave
+	TorqueRecoveryRate float64 // Share of the engine torque given back per second once slip is under control
+	MaxBrakeTorque     float64 // Max brake torque per wheel in Nm
+
+	throttleLimit float64
+	torqueLimit   float64
+	brakeTorqueL  float64
+	brakeTorqueR  float64
+	slipL         float64
+	slipR         float64
+	active        bool
+}
+
+// NewTractionControl creates an enabled traction control with a road calibration
+func NewTractionControl() *TractionControl {
+	return &TractionControl{
+		Enabled:            true,
+		TargetSlip:         0.15,
+		Aggressiveness:     0.5,
+		MinThrottle:        0.1,
+		RecoveryRate:       0.5,
+		MinTorque:          0.2,
+		TorqueRecoveryRate: 2,
+		MaxBrakeTorque:     1500,
+		throttleLimit:      1,
+		torqueLimit:        1,
+	}
+}
+
+// Update evaluates the wheel slip and computes the interventions for the next step
+// Parameters:
+//
+//	slipL: slip ratio of the left driven wheel
+//	slipR: slip ratio of the right driven wheel
+//	deltaTime: time elapsed in seconds
+func (tc *TractionControl) Update(slipL float64, slipR float64, deltaTime float64) {
+	tc.slipL = slipL
+	tc.slipR = slipR
+
+	if !tc.Enabled {
+		tc.throttleLimit = 1
+		tc.torqueLimit = 1
+		tc.brakeTorqueL = 0
+		tc.brakeTorqueR = 0
+		tc.active = false
+		return
+	}
+
+	excessL := math.Max(0, slipL-tc.TargetSlip)
+	excessR := math.Max(0, slipR-tc.TargetSlip)
+	excess := math.Max(excessL, excessR)
+
+	if excess > 0 {
+		// The engine torque is cut at once in proportion to the excess of slip, whichever wheel spins,
+		// and the throttle closes over time, both harder when more aggressive
+		torqueGain := 2 + 6*tc.Aggressiveness
+		tc.torqueLimit = math.Min(tc.torqueLimit, 1-excess*torqueGain)
+		cutRate := 5 + 25*tc.Aggressiveness
+		tc.throttleLimit -= excess * cutRate * deltaTime
+	} else {
+		tc.torqueLimit += tc.TorqueRecoveryRate * deltaTime
+		tc.throttleLimit += tc.RecoveryRate * deltaTime
+	}
+	tc.torqueLimit = math.Max(tc.MinTorque, math.Min(1, tc.torqueLimit))
+	tc.throttleLimit = math.Max(tc.MinThrottle, math.Min(1, tc.throttleLimit))
+
+	// Brake only the wheel that spins more than the other one, like an electronic locking differential
+	tc.brakeTorqueL = tc.brakeTorque(excessL - excessR)
+	tc.brakeTorqueR = tc.brakeTorque(excessR - excessL)
+
+	tc.active = tc.torqueLimit < 1 || tc.throttleLimit < 1 || tc.brakeTorqueL > 0 || tc.brakeTorqueR > 0
+}
+
+// brakeTorque returns the brake torque for a wheel that spins excess more than the other one
+func (tc *TractionControl) brakeTorque(excess float64) float64 {
+	if excess <= 0 {
+		return 0
+	}
+	brakeGain := 2000 + 8000*tc.Aggressiveness // Nm per unit of slip
+	return math.Min(tc.MaxBrakeTorque, excess*brakeGain)
+}
+
+// GetThrottleLimit returns the throttle override to apply to the engine
+func (tc *TractionControl) GetThrottleLimit() float64 {
+	return tc.throttleLimit
+}
+
+// GetTorqueLimit returns the share of its torque the engine may deliver
+func (tc *TractionControl) GetTorqueLimit() float64 {
+	return tc.torqueLimit
+}
+
+// GetBrakeTorque returns the brake torque to apply on the left and right wheels in Nm
+func (tc *TractionControl) GetBrakeTorque() (float64, float64) {
+	return tc.brakeTorqueL, tc.brakeTorqueR
+}
+
+// IsActive reports whether the system is intervening
+func (tc *TractionControl) IsActive() bool {
+	return tc.active
+}
+
+// GetData returns the traction control telemetry
+func (tc *TractionControl) GetData() Telemetry {
+	return Telemetry{
+		Enabled:       tc.Enabled,
+		Active:        tc.active,
+		TargetSlip:    tc.TargetSlip,
+		SlipL:         tc.slipL,
+		SlipR:         tc.slipR,
+		ThrottleLimit: tc.throttleLimit,
+		TorqueLimit:   tc.torqueLimit,
+		BrakeTorqueL:  tc.brakeTorqueL,
+		BrakeTorqueR:  tc.brakeTorqueR,
+	}
+}
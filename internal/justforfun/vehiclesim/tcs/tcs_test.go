@@ -0,0 +1,73 @@
+package tcs
+
+import (
+	"math"
+	"testing"
+)
+
+// TestTractionControlSlip checks the system only intervenes once a wheel slips more than the target
+func TestTractionControlSlip(t *testing.T) {
+	tc := NewTractionControl()
+	for _, slip := range []float64{0, 0.1, tc.TargetSlip, -0.3} {
+		tc.Update(slip, slip, 0.1)
+		brakeL, brakeR := tc.GetBrakeTorque()
+		if tc.IsActive() || tc.GetTorqueLimit() != 1 || tc.GetThrottleLimit() != 1 || brakeL != 0 || brakeR != 0 {
+			t.Errorf("slip %.2f: active %v, torque %.2f, throttle %.2f, brakes %.0f/%.0f Nm, want no intervention",
+				slip, tc.IsActive(), tc.GetTorqueLimit(), tc.GetThrottleLimit(), brakeL, brakeR)
+		}
+	}
+
+	tc.Update(0.3, 0.05, 0.1)
+	if !tc.IsActive() {
+		t.Fatal("left wheel spinning at 0.30: not active")
+	}
+	if brakeL, brakeR := tc.GetBrakeTorque(); brakeL <= 0 || brakeR != 0 {
+		t.Errorf("left wheel spinning: brakes %.0f/%.0f Nm, want only the left one", brakeL, brakeR)
+	}
+
+	tc.Enabled = false
+	tc.Update(0.5, 0.5, 0.1)
+	if tc.IsActive() || tc.GetTorqueLimit() != 1 || tc.GetThrottleLimit() != 1 {
+		t.Errorf("disabled: active %v, torque %.2f, throttle %.2f, want no intervention", tc.IsActive(), tc.GetTorqueLimit(), tc.GetThrottleLimit())
+	}
+}
+
+// TestTractionControlTorqueCut checks the engine torque is cut at once when both wheels spin alike,
+// harder with more slip, and given back once the slip is under control
+func TestTractionControlTorqueCut(t *testing.T) {
+	previous := 1.0
+	for _, slip := range []float64{0.2, 0.25, 0.3} {
+		tc := NewTractionControl()
+		tc.Update(slip, slip, 0.1)
+		brakeL, brakeR := tc.GetBrakeTorque()
+		if limit := tc.GetTorqueLimit(); limit >= previous || limit < tc.MinTorque {
+			t.Errorf("both wheels at %.2f slip: torque limit %.2f, want below %.2f", slip, limit, previous)
+		}
+		if brakeL != 0 || brakeR != 0 {
+			t.Errorf("both wheels at %.2f slip: brakes %.0f/%.0f Nm, want the torque cut alone", slip, brakeL, brakeR)
+		}
+		previous = tc.GetTorqueLimit()
+	}
+
+	tc := NewTractionControl()
+	tc.Update(1, 1, 0.1)
+	if tc.GetTorqueLimit() != tc.MinTorque {
+		t.Errorf("wheels spinning freely: torque limit %.2f, want the minimum %.2f", tc.GetTorqueLimit(), tc.MinTorque)
+	}
+
+	// The torque comes back before the throttle
+	var torqueBack, throttleBack float64
+	for elapsed := 0.1; tc.IsActive() && elapsed < 10; elapsed += 0.1 {
+		tc.Update(0, 0, 0.1)
+		if torqueBack == 0 && tc.GetTorqueLimit() == 1 {
+			torqueBack = elapsed
+		}
+		if throttleBack == 0 && tc.GetThrottleLimit() == 1 {
+			throttleBack = elapsed
+		}
+	}
+	if tc.IsActive() || torqueBack == 0 || torqueBack > throttleBack || math.Abs(torqueBack-(1-tc.MinTorque)/tc.TorqueRecoveryRate) > 0.15 {
+		t.Errorf("full torque back after %.1f s and full throttle after %.1f s, want %.1f s and later",
+			torqueBack, throttleBack, (1-tc.MinTorque)/tc.TorqueRecoveryRate)
+	}
+}
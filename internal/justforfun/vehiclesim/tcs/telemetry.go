@@ -0,0 +1,27 @@
+package tcs
+
+import "fmt"
+
+type Telemetry struct {
+	Enabled       bool
+	Active        bool // True while the system is intervening
+	TargetSlip    float64
+	SlipL         float64
+	SlipR         float64
+	ThrottleLimit float64 // Throttle override sent to the engine (0.0 to 1.0)
+	TorqueLimit   float64 // Share of its torque the engine may deliver (0.0 to 1.0)
+	BrakeTorqueL  float64 // Nm
+	BrakeTorqueR  float64 // Nm
+}
+
+// String implements the String interface for human-readable formatting
+func (d Telemetry) String() string {
+	return fmt.Sprintf("TCS [Active: %t, SlipL: %.2f, SlipR: %.2f, ThrottleLimit: %.1f %%, TorqueLimit: %.1f %%, BrakeL: %.0f Nm, BrakeR: %.0f Nm]\n",
+		d.Active,
+		d.SlipL,
+		d.SlipR,
+		d.ThrottleLimit*100,
+		d.TorqueLimit*100,
+		d.BrakeTorqueL,
+		d.BrakeTorqueR)
+}
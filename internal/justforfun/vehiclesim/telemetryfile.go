@@ -0,0 +1,254 @@
+package vehiclesim
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Formats of the telemetry files
+const (
+	TelemetryJSONL = "jsonl" // One EncodeTelemetryJSON record per line
+	TelemetryCSV   = "csv"   // vehicle_id, timestamp and the channels of TelemetrySchema as columns
+)
+
+// TelemetryFileFormat returns the format of a telemetry file from its extension, JSONL unless it is .csv
+func TelemetryFileFormat(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return TelemetryCSV
+	}
+	return TelemetryJSONL
+}
+
+// TelemetryFileSink records the snapshots in a file that ReplayTelemetry can send to the sinks again
+type TelemetryFileSink struct {
+	mu     sync.Mutex
+	output io.Writer
+	writer *bufio.Writer
+	csv    *csv.Writer // nil for JSONL
+}
+
+// NewTelemetryFileSink writes the snapshots to w in the given format
+func NewTelemetryFileSink(w io.Writer, format string) (*TelemetryFileSink, error) {
+	sink := &TelemetryFileSink{output: w, writer: bufio.NewWriter(w)}
+	switch format {
+	case TelemetryJSONL:
+	case TelemetryCSV:
+		sink.csv = csv.NewWriter(sink.writer)
+		header := []string{"vehicle_id", "timestamp"}
+		for _, field := range TelemetrySchema {
+			header = append(header, field.Channel)
+		}
+		if err := sink.csv.Write(header); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown telemetry file format %q, expected %s or %s", format, TelemetryJSONL, TelemetryCSV)
+	}
+	return sink, nil
+}
+
+// Write appends the record of the snapshot
+func (s *TelemetryFileSink) Write(snapshot Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.csv == nil {
+		record, err := EncodeTelemetryJSON(snapshot)
+		if err != nil {
+			return err
+		}
+		_, err = s.writer.Write(append(record, '\n'))
+		return err
+	}
+
+	row := []string{snapshot.VehicleID, strconv.FormatInt(snapshot.Time.UnixMilli(), 10)}
+	for _, field := range TelemetrySchema {
+		row = append(row, formatFieldValue(field.Value(snapshot)))
+	}
+	return s.csv.Write(row)
+}
+
+// Close writes the buffered records and closes the output when it is a file or any other io.Closer
+func (s *TelemetryFileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.csv != nil {
+		s.csv.Flush()
+		if err := s.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if err := s.writer.Flush(); err != nil {
+		return err
+	}
+	if closer, ok := s.output.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func formatFieldValue(value any) string {
+	switch value := value.(type) {
+	case float64:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case bool:
+		return strconv.FormatBool(value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// TelemetryReader reads back the snapshots of a telemetry file. Only the values of TelemetrySchema are
+// recorded: shifts are lost and the snapshot carries the fields found in the file, so that the sinks
+// writing a subset of the schema leave out what an older file lacks.
+type TelemetryReader struct {
+	lines *bufio.Scanner // JSONL
+	csv   *csv.Reader
+	line  int
+
+	columns []*TelemetryField // Field of every CSV column, nil for unknown channels
+	fields  []TelemetryField  // Fields of the CSV columns in schema order
+}
+
+// NewTelemetryReader reads a telemetry file of the given format
+func NewTelemetryReader(r io.Reader, format string) (*TelemetryReader, error) {
+	reader := &TelemetryReader{}
+	switch format {
+	case TelemetryJSONL:
+		reader.lines = bufio.NewScanner(r)
+		reader.lines.Buffer(make([]byte, 64*1024), 1024*1024)
+	case TelemetryCSV:
+		reader.csv = csv.NewReader(r)
+		header, err := reader.csv.Read()
+		if err != nil {
+			return nil, fmt.Errorf("error reading CSV header: %v", err)
+		}
+		if len(header) < 2 || header[0] != "vehicle_id" || header[1] != "timestamp" {
+			return nil, fmt.Errorf("invalid CSV header, expected vehicle_id,timestamp,<channels>")
+		}
+		reader.line = 1
+		present := make(map[string]bool)
+		for _, name := range header[2:] {
+			reader.columns = append(reader.columns, schemaField(name))
+			present[name] = true
+		}
+		reader.fields = []TelemetryField{}
+		for _, field := range TelemetrySchema {
+			if present[field.Channel] {
+				reader.fields = append(reader.fields, field)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown telemetry file format %q, expected %s or %s", format, TelemetryJSONL, TelemetryCSV)
+	}
+	return reader, nil
+}
+
+// schemaField returns the field of TelemetrySchema with the channel name, nil when there is none
+func schemaField(channel string) *TelemetryField {
+	for i := range TelemetrySchema {
+		if TelemetrySchema[i].Channel == channel {
+			return &TelemetrySchema[i]
+		}
+	}
+	return nil
+}
+
+// Read returns the next snapshot, io.EOF after the last one
+func (r *TelemetryReader) Read() (Snapshot, error) {
+	if r.csv != nil {
+		return r.readCSV()
+	}
+	for r.lines.Scan() {
+		r.line++
+		if line := bytes.TrimSpace(r.lines.Bytes()); len(line) > 0 {
+			snapshot, err := r.decodeJSON(line)
+			if err != nil {
+				return snapshot, fmt.Errorf("line %d: %v", r.line, err)
+			}
+			return snapshot, nil
+		}
+	}
+	if err := r.lines.Err(); err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{}, io.EOF
+}
+
+func (r *TelemetryReader) decodeJSON(line []byte) (Snapshot, error) {
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+	var record map[string]any
+	if err := decoder.Decode(&record); err != nil {
+		return Snapshot{}, err
+	}
+
+	var snapshot Snapshot
+	id, _ := record["vehicle_id"].(string)
+	timestamp, ok := record["timestamp"].(json.Number)
+	if id == "" || !ok {
+		return snapshot, fmt.Errorf("record without vehicle_id or timestamp")
+	}
+	ms, err := timestamp.Int64()
+	if err != nil {
+		return snapshot, fmt.Errorf("invalid timestamp %s: %v", timestamp, err)
+	}
+	snapshot.VehicleID, snapshot.Time = id, time.UnixMilli(ms)
+
+	snapshot.fields = []TelemetryField{}
+	for _, field := range TelemetrySchema {
+		value, ok := record[field.Channel]
+		if !ok {
+			continue
+		}
+		if number, ok := value.(json.Number); ok {
+			if value, err = field.Parse(number.String()); err != nil {
+				return snapshot, fmt.Errorf("invalid %s: %v", field.Channel, err)
+			}
+		}
+		if err := field.SetValue(&snapshot, value); err != nil {
+			return snapshot, err
+		}
+		snapshot.fields = append(snapshot.fields, field)
+	}
+	return snapshot, nil
+}
+
+func (r *TelemetryReader) readCSV() (Snapshot, error) {
+	var snapshot Snapshot
+	row, err := r.csv.Read()
+	if err != nil {
+		return snapshot, err
+	}
+	r.line++
+	ms, err := strconv.ParseInt(row[1], 10, 64)
+	if err != nil {
+		return snapshot, fmt.Errorf("line %d: invalid timestamp %q: %v", r.line, row[1], err)
+	}
+	snapshot.VehicleID, snapshot.Time = row[0], time.UnixMilli(ms)
+
+	for i, field := range r.columns {
+		if field == nil {
+			continue
+		}
+		value, err := field.Parse(row[i+2])
+		if err != nil {
+			return snapshot, fmt.Errorf("line %d: invalid %s: %v", r.line, field.Channel, err)
+		}
+		if err := field.SetValue(&snapshot, value); err != nil {
+			return snapshot, fmt.Errorf("line %d: %v", r.line, err)
+		}
+	}
+	snapshot.fields = r.fields
+	return snapshot, nil
+}
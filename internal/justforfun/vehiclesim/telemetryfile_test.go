@@ -0,0 +1,104 @@
+package vehiclesim
+
+import (
+	"bytes"
+	"errors"
+	"go-playground/internal/justforfun/vehiclesim/route"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordTelemetry writes a few seconds of a seeded run in the format and returns the snapshots
+func recordTelemetry(t *testing.T, format string, output io.Writer) []Snapshot {
+	vehicle, err := NewVehicle("vehicle-001", DefaultVehicleSpec(), 7, route.Default(), defaultStart, 0, time.Unix(1700000000, 0))
+	if err != nil {
+		t.Fatalf("NewVehicle: %v", err)
+	}
+	sink, err := NewTelemetryFileSink(output, format)
+	if err != nil {
+		t.Fatalf("NewTelemetryFileSink: %v", err)
+	}
+	driver := NewScriptedDriver(NormalProfile())
+	var snapshots []Snapshot
+	for i := 0; i < 50; i++ {
+		driver.Drive(vehicle, 0.1)
+		snapshot := vehicle.Step(0.1)
+		if err := sink.Write(snapshot); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return snapshots
+}
+
+// TestTelemetryFile reads back every value of the schema from the recorded CSV and JSONL files
+func TestTelemetryFile(t *testing.T) {
+	for _, format := range []string{TelemetryCSV, TelemetryJSONL} {
+		t.Run(format, func(t *testing.T) {
+			var file bytes.Buffer
+			want := recordTelemetry(t, format, &file)
+			reader, err := NewTelemetryReader(&file, format)
+			if err != nil {
+				t.Fatalf("NewTelemetryReader: %v", err)
+			}
+			for i := range want {
+				got, err := reader.Read()
+				if err != nil {
+					t.Fatalf("Read %d: %v", i, err)
+				}
+				if got.VehicleID != want[i].VehicleID || !got.Time.Equal(want[i].Time.Truncate(time.Millisecond)) {
+					t.Fatalf("snapshot %d of %s at %s, want %s at %s", i, got.VehicleID, got.Time, want[i].VehicleID, want[i].Time)
+				}
+				if len(got.schemaFields()) != len(TelemetrySchema) {
+					t.Fatalf("snapshot %d has %d fields, want %d", i, len(got.schemaFields()), len(TelemetrySchema))
+				}
+				for _, field := range TelemetrySchema {
+					gotValue, wantValue := field.Value(got), field.Value(want[i])
+					if gotFloat, ok := gotValue.(float64); ok {
+						if !near(gotFloat, wantValue.(float64), 1e-12) {
+							t.Errorf("snapshot %d: %s = %v, want %v", i, field.Channel, gotValue, wantValue)
+						}
+					} else if gotValue != wantValue {
+						t.Errorf("snapshot %d: %s = %v, want %v", i, field.Channel, gotValue, wantValue)
+					}
+				}
+			}
+			if _, err := reader.Read(); !errors.Is(err, io.EOF) {
+				t.Errorf("Read after the last snapshot: %v, want io.EOF", err)
+			}
+		})
+	}
+}
+
+// TestTelemetryFileSubset keeps the channels found in a file written with fewer columns
+func TestTelemetryFileSubset(t *testing.T) {
+	file := "vehicle_id,timestamp,rpm,ground_speed_kmh,unknown\nvehicle-002,1700000000500,3000,72,1\n"
+	reader, err := NewTelemetryReader(strings.NewReader(file), TelemetryCSV)
+	if err != nil {
+		t.Fatalf("NewTelemetryReader: %v", err)
+	}
+	snapshot, err := reader.Read()
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if snapshot.Engine.RPM != 3000 || !near(snapshot.Body.SpeedMS, 20, 1e-12) || snapshot.Time.UnixMilli() != 1700000000500 {
+		t.Errorf("got %.0f rpm and %.1f m/s at %d, want 3000 rpm and 20 m/s at 1700000000500",
+			snapshot.Engine.RPM, snapshot.Body.SpeedMS, snapshot.Time.UnixMilli())
+	}
+	if fields := snapshot.schemaFields(); len(fields) != 2 {
+		t.Errorf("got %d fields, want rpm and ground_speed_kmh", len(fields))
+	}
+
+	if _, err := NewTelemetryReader(strings.NewReader("time,rpm\n"), TelemetryCSV); err == nil {
+		t.Error("NewTelemetryReader without vehicle_id and timestamp: expected an error")
+	}
+	reader, _ = NewTelemetryReader(strings.NewReader(`{"vehicle_id":"vehicle-001","timestamp":1,"rpm":"fast"}`), TelemetryJSONL)
+	if _, err := reader.Read(); err == nil {
+		t.Error("Read of a string rpm: expected an error")
+	}
+}
@@ -0,0 +1,92 @@
+package vehiclesim
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// avroField is a field of an Avro record schema
+type avroField struct {
+	Name string `json:"name"`
+	Type any    `json:"type"`
+	Doc  string `json:"doc,omitempty"`
+}
+
+// avroTypes are the Avro types of the field types
+var avroTypes = map[FieldType]string{
+	FloatField:  "double",
+	IntField:    "long",
+	BoolField:   "boolean",
+	StringField: "string",
+}
+
+// TelemetryAvroSchema returns the Avro schema of the records encoded by EncodeTelemetryAvro:
+// the vehicle ID, the timestamp and the fields of TelemetrySchema by channel, in that order
+func TelemetryAvroSchema() string {
+	fields := []avroField{
+		{Name: "vehicle_id", Type: "string"},
+		{Name: "timestamp", Type: map[string]string{"type": "long", "logicalType": "timestamp-millis"}, Doc: "Simulated time of the step"},
+	}
+	for _, field := range TelemetrySchema {
+		doc := field.Description
+		if field.Unit != "" {
+			doc += " (" + field.Unit + ")"
+		}
+		fields = append(fields, avroField{Name: field.Channel, Type: avroTypes[field.Type], Doc: doc})
+	}
+
+	schema, _ := json.Marshal(map[string]any{
+		"type":      "record",
+		"name":      "VehicleTelemetry",
+		"namespace": "vehiclesim",
+		"doc":       "Telemetry schema " + TelemetrySchemaVersion,
+		"fields":    fields,
+	})
+	return string(schema)
+}
+
+// EncodeTelemetryJSON encodes the snapshot as a flat JSON object described by TelemetryJSONSchema
+func EncodeTelemetryJSON(snapshot Snapshot) ([]byte, error) {
+	record := make(map[string]any, len(TelemetrySchema)+2)
+	record["vehicle_id"] = snapshot.VehicleID
+	record["timestamp"] = snapshot.Time.UnixMilli()
+	for _, field := range TelemetrySchema {
+		value := field.Value(snapshot)
+		if number, ok := value.(float64); ok && (math.IsNaN(number) || math.IsInf(number, 0)) {
+			return nil, fmt.Errorf("error encoding %s of %s: %v is not a JSON number", field.Channel, snapshot.VehicleID, number)
+		}
+		record[field.Channel] = value
+	}
+	return json.Marshal(record)
+}
+
+// EncodeTelemetryAvro encodes the snapshot in the Avro binary encoding of TelemetryAvroSchema
+func EncodeTelemetryAvro(snapshot Snapshot) []byte {
+	data := make([]byte, 0, 16+len(snapshot.VehicleID)+8*len(TelemetrySchema))
+	data = appendAvroString(data, snapshot.VehicleID)
+	data = binary.AppendVarint(data, snapshot.Time.UnixMilli())
+	for _, field := range TelemetrySchema {
+		switch value := field.Value(snapshot).(type) {
+		case float64:
+			data = binary.LittleEndian.AppendUint64(data, math.Float64bits(value))
+		case int64:
+			data = binary.AppendVarint(data, value)
+		case bool:
+			if value {
+				data = append(data, 1)
+			} else {
+				data = append(data, 0)
+			}
+		case string:
+			data = appendAvroString(data, value)
+		}
+	}
+	return data
+}
+
+// appendAvroString appends the length, a zig-zag varint like every Avro long, and the bytes of the string
+func appendAvroString(data []byte, value string) []byte {
+	return append(binary.AppendVarint(data, int64(len(value))), value...)
+}
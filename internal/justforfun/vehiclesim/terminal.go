@@ -0,0 +1,28 @@
+package vehiclesim
+
+import (
+	"fmt"
+	"golang.org/x/term"
+	"os"
+)
+
+// StdinIsTerminal reports whether stdin is a terminal the dashboard can read keys from
+func StdinIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// MakeTerminalRaw puts the terminal on stdin in raw mode, every key press arrives at once without echo
+// Raw mode also turns off the signal keys and the output processing: Ctrl+C arrives as a key and lines
+// have to end in "\r\n". The returned function restores the previous terminal settings.
+func MakeTerminalRaw() (func(), error) {
+	fd := int(os.Stdin.Fd())
+	saved, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, fmt.Errorf("error setting terminal raw mode, is stdin a terminal? %v", err)
+	}
+	return func() {
+		if err := term.Restore(fd, saved); err != nil {
+			fmt.Fprintf(os.Stderr, "Error restoring terminal settings: %v\n", err)
+		}
+	}, nil
+}
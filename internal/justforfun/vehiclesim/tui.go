@@ -0,0 +1,360 @@
+package vehiclesim
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	dashboardRefresh = 100 * time.Millisecond // Redraw period of the dashboard
+	chartWidth       = 60                     // Samples shown by the scrolling charts
+	gaugeWidth       = 30
+	gaugeMaxKMH      = 300.0
+	throttleStep     = 0.1
+	brakeStep        = 0.25
+	clutchStep       = 0.25
+	steeringStep     = 10.0 // Steering wheel degrees per key press
+)
+
+// sparkLevels draws the scrolling charts, from the lowest to the highest value
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// dashboardHelp lists the keys of the dashboard
+var dashboardHelp = []string{
+	"w/↑ s/↓ throttle  b/n brake  space stop  c/v clutch  e/q gear  0-7 select gear  a/← d/→ steer  f follow road",
+	"m autopilot  p pause  . step  +/- time scale  Esc/Ctrl+C quit",
+}
+
+// Dashboard is a keyboard driven terminal UI of a live simulation
+// Keys move the controls of the car, taking it from the autopilot. Terminals do not report released keys,
+// so pedals stay where the last key left them.
+type Dashboard struct {
+	live     *LiveSimulation
+	controls *ControlledDriver
+	maxRPM   float64
+	maxGear  int
+
+	// Controls held by the keyboard, read back from the car when it is taken from the autopilot
+	throttle float64
+	brake    float64
+	clutch   float64
+	gear     int
+	steering float64
+
+	speeds  []float64
+	rpms    []float64
+	lastLog time.Time
+
+	mu      sync.Mutex // Guards message, written by the log too
+	message string
+}
+
+// NewDashboard creates the dashboard of a live simulation, maxRPM is the end of the RPM gauge
+func NewDashboard(live *LiveSimulation, controls *ControlledDriver, maxRPM float64) *Dashboard {
+	return &Dashboard{
+		live:     live,
+		controls: controls,
+		maxRPM:   maxRPM,
+		maxGear:  live.vehicle.Gearbox.GetMaxGear(),
+	}
+}
+
+// Run draws the dashboard on out and reads keys from in until ctx is done or Esc is pressed
+// in must already deliver single key presses, see MakeTerminalRaw
+func (d *Dashboard) Run(ctx context.Context, in io.Reader, out io.Writer) error {
+	keys := make(chan string)
+	readErr := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := in.Read(buf)
+			if err != nil {
+				readErr <- err
+				return
+			}
+			for _, key := range parseKeys(buf[:n]) {
+				select {
+				case keys <- key:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(dashboardRefresh)
+	defer ticker.Stop()
+
+	// Hide the cursor while drawing, show it back on exit
+	fmt.Fprint(out, "\x1b[?25l\x1b[2J")
+	defer fmt.Fprint(out, "\x1b[?25h\r\n")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-readErr:
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("error reading keys: %v", err)
+		case key := <-keys:
+			if !d.HandleKey(key) {
+				return nil
+			}
+		case <-ticker.C:
+		}
+
+		snapshot, ok := d.live.Latest()
+		if !ok {
+			continue
+		}
+		d.record(snapshot)
+		fmt.Fprint(out, "\x1b[H", d.Render(snapshot, d.live.Status(), d.controls.IsManual()))
+	}
+}
+
+// HandleKey applies a key press, it returns false when the key quits the dashboard
+func (d *Dashboard) HandleKey(key string) bool {
+	switch key {
+	case "esc", "ctrl+c":
+		return false
+	case "w", "up":
+		d.take()
+		d.throttle = clamp01(d.throttle + throttleStep)
+		d.command(Command{Input: InputThrottle, Value: d.throttle})
+	case "s", "down":
+		d.take()
+		d.throttle = clamp01(d.throttle - throttleStep)
+		d.command(Command{Input: InputThrottle, Value: d.throttle})
+	case "b":
+		d.take()
+		d.brake = clamp01(d.brake + brakeStep)
+		d.command(Command{Input: InputBrake, Value: d.brake})
+	case "n":
+		d.take()
+		d.brake = clamp01(d.brake - brakeStep)
+		d.command(Command{Input: InputBrake, Value: d.brake})
+	case " ":
+		d.take()
+		d.throttle, d.brake = 0, 1
+		d.command(Command{Input: InputThrottle, Value: 0}, Command{Input: InputBrake, Value: 1})
+	case "c":
+		d.take()
+		d.clutch = clamp01(d.clutch - clutchStep)
+		d.command(Command{Input: InputClutch, Value: d.clutch})
+	case "v":
+		d.take()
+		d.clutch = clamp01(d.clutch + clutchStep)
+		d.command(Command{Input: InputClutch, Value: d.clutch})
+	case "e":
+		d.take()
+		d.selectGear(d.gear + 1)
+	case "q":
+		d.take()
+		d.selectGear(d.gear - 1)
+	case "0", "1", "2", "3", "4", "5", "6", "7":
+		d.take()
+		d.selectGear(int(key[0] - '0'))
+	case "a", "left":
+		d.take()
+		d.steering += steeringStep
+		d.command(Command{Input: InputSteering, Value: d.steering})
+	case "d", "right":
+		d.take()
+		d.steering -= steeringStep
+		d.command(Command{Input: InputSteering, Value: d.steering})
+	case "f":
+		d.take()
+		d.steering = 0
+		d.command(Command{Input: InputFollowRoad})
+	case "m":
+		d.controls.SetManual(false)
+		d.setMessage("Autopilot driving")
+	case "p":
+		if d.live.Status().Paused {
+			d.live.Resume()
+			d.setMessage("Resumed")
+		} else {
+			d.live.Pause()
+			d.setMessage("Paused")
+		}
+	case ".":
+		if _, err := d.live.Step(1); err != nil {
+			d.setMessage(err.Error())
+		}
+	case "+", "-":
+		scale := d.live.Status().TimeScale * 2
+		if key == "-" {
+			scale = d.live.Status().TimeScale / 2
+		}
+		if err := d.live.SetTimeScale(scale); err != nil {
+			d.setMessage(err.Error())
+		} else {
+			d.setMessage(fmt.Sprintf("Time scale x%g", scale))
+		}
+	}
+	return true
+}
+
+// take reads the controls back from the car when the autopilot is driving, so keys move them from where they are
+func (d *Dashboard) take() {
+	if d.controls.IsManual() {
+		return
+	}
+	snapshot, _ := d.live.Latest()
+	d.throttle = snapshot.Engine.AcceleratorPosition
+	d.brake = 0
+	d.clutch = snapshot.Gearbox.ClutchPosition
+	d.gear = snapshot.Gearbox.CurrentGear
+	d.steering = snapshot.Steering.SteeringWheelAngle
+	d.setMessage("Driving by hand, m hands the car back to the autopilot")
+}
+
+func (d *Dashboard) setMessage(message string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.message = message
+}
+
+// Write implements io.Writer, the last line written is shown under the dashboard
+func (d *Dashboard) Write(p []byte) (int, error) {
+	lines := strings.Split(strings.TrimRight(string(p), "\n"), "\n")
+	d.setMessage(lines[len(lines)-1])
+	return len(p), nil
+}
+
+func (d *Dashboard) selectGear(gear int) {
+	if gear < 0 || gear > d.maxGear {
+		return
+	}
+	d.gear = gear
+	d.command(Command{Input: InputGear, Value: float64(gear)})
+}
+
+func (d *Dashboard) command(commands ...Command) {
+	d.controls.Command(commands...)
+}
+
+// record adds a new snapshot to the charts, once per simulated step
+func (d *Dashboard) record(snapshot Snapshot) {
+	if snapshot.Time.Equal(d.lastLog) {
+		return
+	}
+	d.lastLog = snapshot.Time
+	d.speeds = appendSample(d.speeds, snapshot.Wheels.VehicleSpeedKMH)
+	d.rpms = appendSample(d.rpms, snapshot.Engine.RPM)
+}
+
+func appendSample(samples []float64, value float64) []float64 {
+	samples = append(samples, value)
+	if len(samples) > chartWidth {
+		samples = samples[len(samples)-chartWidth:]
+	}
+	return samples
+}
+
+// Render draws one frame of the dashboard, every line is cleared to its end so shorter frames leave no leftovers
+// Lines end in "\r\n", the raw terminal does not return the cursor to the start of the line
+func (d *Dashboard) Render(snapshot Snapshot, status LiveStatus, manual bool) string {
+	driver := "autopilot"
+	if manual {
+		driver = "manual"
+	}
+	state := "running"
+	if status.Paused {
+		state = "paused"
+	}
+	gear := "N"
+	if snapshot.Gearbox.CurrentGear > 0 {
+		gear = fmt.Sprintf("%d", snapshot.Gearbox.CurrentGear)
+	}
+
+	lines := []string{
+		fmt.Sprintf("%s  t=%.1f s  %s x%g  driver: %s", snapshot.VehicleID, status.ElapsedS, state, status.TimeScale, driver),
+		"",
+		fmt.Sprintf("Speed    %s %5.0f km/h", gauge(snapshot.Wheels.VehicleSpeedKMH/gaugeMaxKMH), snapshot.Wheels.VehicleSpeedKMH),
+		fmt.Sprintf("RPM      %s %5.0f", gauge(snapshot.Engine.RPM/d.maxRPM), snapshot.Engine.RPM),
+		fmt.Sprintf("Gear     %s", gear),
+		fmt.Sprintf("Throttle %s %5.0f %%", gauge(snapshot.Engine.AcceleratorPosition), snapshot.Engine.AcceleratorPosition*100),
+		fmt.Sprintf("Brake    %s %5.0f Nm", gauge((snapshot.Wheels.BrakeTorqueL+snapshot.Wheels.BrakeTorqueR)/(2*driverMaxBrakeTorque)),
+			snapshot.Wheels.BrakeTorqueL+snapshot.Wheels.BrakeTorqueR),
+		fmt.Sprintf("Clutch   %s %5.0f %%", gauge(snapshot.Gearbox.ClutchPosition), snapshot.Gearbox.ClutchPosition*100),
+		fmt.Sprintf("Steering %+5.0f°   Oil %5.1f °C   Coolant %5.1f °C   Fuel %5.1f L/h   Torque %5.0f Nm   Slip %+.2f/%+.2f",
+			snapshot.Steering.SteeringWheelAngle, snapshot.Engine.OilTemp, snapshot.Engine.CoolantTemp, snapshot.Engine.FuelRateLH,
+			snapshot.Engine.Torque, snapshot.Wheels.SlipL, snapshot.Wheels.SlipR),
+		fmt.Sprintf("Tires    L %5.1f °C %3.0f kPa %.2f mm   R %5.1f °C %3.0f kPa %.2f mm",
+			snapshot.Wheels.TireL.TempC, snapshot.Wheels.TireL.PressureKPa, snapshot.Wheels.TireL.TreadDepthMM,
+			snapshot.Wheels.TireR.TempC, snapshot.Wheels.TireR.PressureKPa, snapshot.Wheels.TireR.TreadDepthMM),
+		fmt.Sprintf("Route    %.0f m, grade %+.1f %%, TCS active: %t, cruise: %s",
+			snapshot.Route.DistanceM, 100*math.Tan(snapshot.Route.Grade), snapshot.TractionControl.Active, snapshot.CruiseControl.State),
+		"",
+		fmt.Sprintf("km/h %s", sparkline(d.speeds, gaugeMaxKMH)),
+		fmt.Sprintf("RPM  %s", sparkline(d.rpms, d.maxRPM)),
+		"",
+	}
+	lines = append(lines, dashboardHelp...)
+	d.mu.Lock()
+	lines = append(lines, d.message)
+	d.mu.Unlock()
+
+	var frame strings.Builder
+	for _, line := range lines {
+		frame.WriteString(line)
+		frame.WriteString("\x1b[K\r\n")
+	}
+	// Clears whatever was printed below the previous frame
+	frame.WriteString("\x1b[J")
+	return frame.String()
+}
+
+// gauge draws a horizontal bar of fraction, clamped to [0, 1]
+func gauge(fraction float64) string {
+	filled := int(math.Round(clamp01(fraction) * gaugeWidth))
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", gaugeWidth-filled) + "]"
+}
+
+// sparkline draws the samples as a one line chart scaled to max
+func sparkline(samples []float64, max float64) string {
+	var line strings.Builder
+	for _, sample := range samples {
+		level := int(clamp01(sample/max) * float64(len(sparkLevels)-1))
+		line.WriteRune(sparkLevels[level])
+	}
+	return line.String()
+}
+
+// parseKeys splits the bytes read from a raw terminal into key names
+// Arrows are "up", "down", "left" and "right", a lone escape is "esc", Ctrl+C is "ctrl+c",
+// any other byte is itself
+func parseKeys(buf []byte) []string {
+	arrows := map[byte]string{'A': "up", 'B': "down", 'C': "right", 'D': "left"}
+
+	var keys []string
+	for i := 0; i < len(buf); i++ {
+		switch {
+		case buf[i] == 0x03:
+			keys = append(keys, "ctrl+c")
+		case buf[i] != 0x1b:
+			keys = append(keys, string(buf[i]))
+		case i+2 < len(buf) && buf[i+1] == '[':
+			if arrow, ok := arrows[buf[i+2]]; ok {
+				keys = append(keys, arrow)
+			}
+			i += 2
+		default:
+			keys = append(keys, "esc")
+		}
+	}
+	return keys
+}
+
+func clamp01(value float64) float64 {
+	return math.Max(0, math.Min(1, value))
+}
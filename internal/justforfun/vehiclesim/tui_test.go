@@ -0,0 +1,78 @@
+package vehiclesim
+
+import (
+	"go-playground/internal/justforfun/vehiclesim/route"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseKeys(t *testing.T) {
+	got := parseKeys([]byte("w\x1b[A\x1b[Dq \x03\x1b"))
+	want := []string{"w", "up", "left", "q", " ", "ctrl+c", "esc"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKeys = %q, want %q", got, want)
+	}
+}
+
+// TestDashboardKeys takes the car from the autopilot with the keyboard and checks the car follows the keys
+func TestDashboardKeys(t *testing.T) {
+	vehicle, err := NewVehicle("vehicle-001", DefaultVehicleSpec(), 7, route.Default(), defaultStart, 0, time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("NewVehicle: %v", err)
+	}
+	controls := NewControlledDriver(NewScriptedDriver(NormalProfile()))
+	live := NewLiveSimulation(vehicle, controls, 0.1, nil)
+	live.Pause()
+	if _, err := live.Step(300); err != nil {
+		t.Fatalf("Step: %v", err)
+	}
+	before, _ := live.Latest()
+
+	dashboard := NewDashboard(live, controls, vehicle.Spec.LimitRPM)
+	for _, key := range []string{"s", "s", "b", "b", "0", "left"} {
+		if !dashboard.HandleKey(key) {
+			t.Fatalf("key %q quit the dashboard", key)
+		}
+	}
+	snapshot, err := live.Step(1)
+	if err != nil {
+		t.Fatalf("Step: %v", err)
+	}
+
+	if !controls.IsManual() {
+		t.Error("keys did not take the car from the autopilot")
+	}
+	wantThrottle := clamp01(before.Engine.AcceleratorPosition - 2*throttleStep)
+	if snapshot.Engine.AcceleratorPosition != wantThrottle {
+		t.Errorf("throttle = %.2f, want %.2f", snapshot.Engine.AcceleratorPosition, wantThrottle)
+	}
+	if vehicle.GetBrakePedal() != 2*brakeStep {
+		t.Errorf("brake pedal = %.2f, want %.2f", vehicle.GetBrakePedal(), 2*brakeStep)
+	}
+	if snapshot.Gearbox.CurrentGear != 0 {
+		t.Errorf("gear = %d, want neutral", snapshot.Gearbox.CurrentGear)
+	}
+	if !vehicle.IsSteeringManually() || snapshot.Steering.SteeringWheelAngle != before.Steering.SteeringWheelAngle+steeringStep {
+		t.Errorf("steering = %.0f, want %.0f by hand", snapshot.Steering.SteeringWheelAngle, before.Steering.SteeringWheelAngle+steeringStep)
+	}
+
+	dashboard.record(snapshot)
+	frame := dashboard.Render(snapshot, live.Status(), controls.IsManual())
+	for _, want := range []string{"driver: manual", "paused", "Gear     N", "Coolant", "Driving by hand"} {
+		if !strings.Contains(frame, want) {
+			t.Errorf("frame misses %q:\n%s", want, frame)
+		}
+	}
+
+	dashboard.HandleKey("m")
+	if controls.IsManual() {
+		t.Error("m did not hand the car back to the autopilot")
+	}
+	for _, key := range []string{"esc", "ctrl+c"} {
+		if dashboard.HandleKey(key) {
+			t.Errorf("%s did not quit the dashboard", key)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package units
+
+import "math"
+
+// AngularVelocity is a rotational speed, stored in rad/s
+type AngularVelocity float64
+
+// Units of angular velocity
+var (
+	RadPerSec = Unit[AngularVelocity]{Symbol: "rad/s", scale: 1}
+	RPM       = Unit[AngularVelocity]{Symbol: "rpm", scale: 2 * math.Pi / 60}
+
+	AngularVelocityUnits = []Unit[AngularVelocity]{RadPerSec, RPM}
+)
+
+// Geared returns the speed of a shaft driving w through a reduction of the given ratio,
+// e.g. the engine speed of wheels turning at w through the gearbox and differential
+func Geared(w AngularVelocity, ratio float64) AngularVelocity {
+	return AngularVelocity(float64(w) * ratio)
+}
+
+func (w AngularVelocity) RadPerSec() float64 { return RadPerSec.In(w) }
+func (w AngularVelocity) RPM() float64       { return RPM.In(w) }
+func (w AngularVelocity) String() string     { return RPM.Format(w, 0) }
+
+// MarshalJSON encodes the angular velocity in rpm
+func (w AngularVelocity) MarshalJSON() ([]byte, error) { return marshal(w, RPM) }
+
+// UnmarshalJSON reads a Measure in any unit of angular velocity, or a number in rpm
+func (w *AngularVelocity) UnmarshalJSON(data []byte) error {
+	return unmarshal(data, w, RPM, AngularVelocityUnits)
+}
+
+// Torque is stored in N·m
+type Torque float64
+
+// Units of torque
+var (
+	NewtonMeters = Unit[Torque]{Symbol: "Nm", scale: 1}
+	PoundFeet    = Unit[Torque]{Symbol: "lb·ft", scale: 1.3558179483314004}
+
+	TorqueUnits = []Unit[Torque]{NewtonMeters, PoundFeet}
+)
+
+func (t Torque) NewtonMeters() float64 { return NewtonMeters.In(t) }
+func (t Torque) PoundFeet() float64    { return PoundFeet.In(t) }
+func (t Torque) String() string        { return NewtonMeters.Format(t, 1) }
+
+// MarshalJSON encodes the torque in Nm
+func (t Torque) MarshalJSON() ([]byte, error) { return marshal(t, NewtonMeters) }
+
+// UnmarshalJSON reads a Measure in any unit of torque, or a number in Nm
+func (t *Torque) UnmarshalJSON(data []byte) error {
+	return unmarshal(data, t, NewtonMeters, TorqueUnits)
+}
+
+// Power is stored in W
+type Power float64
+
+// Units of power, the horsepower is the mechanical one (550 ft·lbf/s)
+var (
+	Watts      = Unit[Power]{Symbol: "W", scale: 1}
+	Kilowatts  = Unit[Power]{Symbol: "kW", scale: 1000}
+	Horsepower = Unit[Power]{Symbol: "hp", scale: 745.69987158227022}
+
+	PowerUnits = []Unit[Power]{Watts, Kilowatts, Horsepower}
+)
+
+// PowerOf returns the power of a shaft turning at w with torque t
+func PowerOf(t Torque, w AngularVelocity) Power {
+	return Power(float64(t) * float64(w))
+}
+
+func (p Power) Watts() float64      { return Watts.In(p) }
+func (p Power) Kilowatts() float64  { return Kilowatts.In(p) }
+func (p Power) Horsepower() float64 { return Horsepower.In(p) }
+func (p Power) String() string      { return Kilowatts.Format(p, 1) }
+
+// MarshalJSON encodes the power in kW
+func (p Power) MarshalJSON() ([]byte, error) { return marshal(p, Kilowatts) }
+
+// UnmarshalJSON reads a Measure in any unit of power, or a number in kW
+func (p *Power) UnmarshalJSON(data []byte) error {
+	return unmarshal(data, p, Kilowatts, PowerUnits)
+}
+
+// Speed is a linear speed, stored in m/s
+type Speed float64
+
+// Units of speed
+var (
+	MetersPerSecond   = Unit[Speed]{Symbol: "m/s", scale: 1}
+	KilometersPerHour = Unit[Speed]{Symbol: "km/h", scale: 1 / 3.6}
+	MilesPerHour      = Unit[Speed]{Symbol: "mph", scale: 0.44704}
+
+	SpeedUnits = []Unit[Speed]{MetersPerSecond, KilometersPerHour, MilesPerHour}
+)
+
+// SurfaceSpeed returns the speed of the surface of a wheel of the given radius turning at w
+func SurfaceSpeed(w AngularVelocity, radius Length) Speed {
+	return Speed(float64(w) * float64(radius))
+}
+
+// RollingSpeed returns the angular velocity of a wheel of the given radius rolling at s
+func RollingSpeed(s Speed, radius Length) AngularVelocity {
+	return AngularVelocity(float64(s) / float64(radius))
+}
+
+func (s Speed) MetersPerSecond() float64   { return MetersPerSecond.In(s) }
+func (s Speed) KilometersPerHour() float64 { return KilometersPerHour.In(s) }
+func (s Speed) MilesPerHour() float64      { return MilesPerHour.In(s) }
+func (s Speed) String() string             { return KilometersPerHour.Format(s, 1) }
+
+// MarshalJSON encodes the speed in km/h
+func (s Speed) MarshalJSON() ([]byte, error) { return marshal(s, KilometersPerHour) }
+
+// UnmarshalJSON reads a Measure in any unit of speed, or a number in km/h
+func (s *Speed) UnmarshalJSON(data []byte) error {
+	return unmarshal(data, s, KilometersPerHour, SpeedUnits)
+}
+
+// Temperature is stored in °C
+type Temperature float64
+
+// Units of temperature
+var (
+	Celsius    = Unit[Temperature]{Symbol: "°C", scale: 1}
+	Kelvin     = Unit[Temperature]{Symbol: "K", scale: 1, offset: -273.15}
+	Fahrenheit = Unit[Temperature]{Symbol: "°F", scale: 5.0 / 9, offset: -32 * 5.0 / 9}
+
+	TemperatureUnits = []Unit[Temperature]{Celsius, Kelvin, Fahrenheit}
+)
+
+func (t Temperature) Celsius() float64    { return Celsius.In(t) }
+func (t Temperature) Kelvin() float64     { return Kelvin.In(t) }
+func (t Temperature) Fahrenheit() float64 { return Fahrenheit.In(t) }
+func (t Temperature) String() string      { return Celsius.Format(t, 1) }
+
+// MarshalJSON encodes the temperature in °C
+func (t Temperature) MarshalJSON() ([]byte, error) { return marshal(t, Celsius) }
+
+// UnmarshalJSON reads a Measure in any unit of temperature, or a number in °C
+func (t *Temperature) UnmarshalJSON(data []byte) error {
+	return unmarshal(data, t, Celsius, TemperatureUnits)
+}
+
+// Length is stored in m
+type Length float64
+
+// Units of length
+var (
+	Meters      = Unit[Length]{Symbol: "m", scale: 1}
+	Millimeters = Unit[Length]{Symbol: "mm", scale: 0.001}
+	Inches      = Unit[Length]{Symbol: "in", scale: 0.0254}
+
+	LengthUnits = []Unit[Length]{Meters, Millimeters, Inches}
+)
+
+func (l Length) Meters() float64      { return Meters.In(l) }
+func (l Length) Millimeters() float64 { return Millimeters.In(l) }
+func (l Length) Inches() float64      { return Inches.In(l) }
+func (l Length) String() string       { return Meters.Format(l, 3) }
+
+// MarshalJSON encodes the length in m
+func (l Length) MarshalJSON() ([]byte, error) { return marshal(l, Meters) }
+
+// UnmarshalJSON reads a Measure in any unit of length, or a number in m
+func (l *Length) UnmarshalJSON(data []byte) error {
+	return unmarshal(data, l, Meters, LengthUnits)
+}
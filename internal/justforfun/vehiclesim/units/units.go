@@ -0,0 +1,87 @@
+package units
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Unit is a unit of the quantity Q, quantities are stored in the base unit of their kind
+type Unit[Q ~float64] struct {
+	Symbol string
+	scale  float64 // Base units in one unit
+	offset float64 // Base value at the zero of the unit, only temperatures have one
+}
+
+// Of returns the quantity of value in the unit
+func (u Unit[Q]) Of(value float64) Q {
+	return Q(value*u.scale + u.offset)
+}
+
+// In returns the quantity expressed in the unit
+func (u Unit[Q]) In(q Q) float64 {
+	return (float64(q) - u.offset) / u.scale
+}
+
+// Format returns the quantity in the unit with the given number of decimals, e.g. "3000 rpm"
+func (u Unit[Q]) Format(q Q, decimals int) string {
+	return strconv.FormatFloat(u.In(q), 'f', decimals, 64) + " " + u.Symbol
+}
+
+// Measure returns the quantity in the unit, to marshal it in a unit other than the default of its kind
+func (u Unit[Q]) Measure(q Q) Measure {
+	return Measure{Value: u.In(q), Unit: u.Symbol}
+}
+
+// Measure is a value and the symbol of its unit, the JSON form of the quantities
+type Measure struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"`
+}
+
+// Parse returns the quantity of a measure in one of the given units
+func Parse[Q ~float64](m Measure, units []Unit[Q]) (Q, error) {
+	for _, u := range units {
+		if u.Symbol == m.Unit {
+			return u.Of(m.Value), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown unit %q, expected one of %v", m.Unit, symbols(units))
+}
+
+// marshal encodes the quantity as a Measure in the unit
+func marshal[Q ~float64](q Q, u Unit[Q]) ([]byte, error) {
+	return json.Marshal(u.Measure(q))
+}
+
+// unmarshal reads a Measure in any of the units, or a bare number in the default unit
+func unmarshal[Q ~float64](data []byte, q *Q, def Unit[Q], units []Unit[Q]) error {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var value float64
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		*q = def.Of(value)
+		return nil
+	}
+
+	var m Measure
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	value, err := Parse(m, units)
+	if err != nil {
+		return err
+	}
+	*q = value
+	return nil
+}
+
+func symbols[Q ~float64](units []Unit[Q]) []string {
+	symbols := make([]string, len(units))
+	for i, u := range units {
+		symbols[i] = u.Symbol
+	}
+	return symbols
+}
@@ -0,0 +1,90 @@
+package units
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestConversions(t *testing.T) {
+	tests := []struct {
+		name      string
+		got, want float64
+	}{
+		{"3000 rpm in rad/s", RPM.Of(3000).RadPerSec(), 100 * math.Pi},
+		{"1 rad/s in rpm", RadPerSec.Of(1).RPM(), 60 / (2 * math.Pi)},
+		{"900 rpm through 3.5:1 in rpm", Geared(RPM.Of(900), 3.5).RPM(), 3150},
+		{"100 lb·ft in Nm", PoundFeet.Of(100).NewtonMeters(), 135.582},
+		{"100 kW in hp", Kilowatts.Of(100).Horsepower(), 134.102},
+		{"300 Nm at 5000 rpm in kW", PowerOf(NewtonMeters.Of(300), RPM.Of(5000)).Kilowatts(), 157.080},
+		{"10 m/s in km/h", MetersPerSecond.Of(10).KilometersPerHour(), 36},
+		{"100 km/h in mph", KilometersPerHour.Of(100).MilesPerHour(), 62.137},
+		{"90 °C in K", Celsius.Of(90).Kelvin(), 363.15},
+		{"212 °F in °C", Fahrenheit.Of(212).Celsius(), 100},
+		{"-40 °C in °F", Celsius.Of(-40).Fahrenheit(), -40},
+		{"16 in in mm", Inches.Of(16).Millimeters(), 406.4},
+		{"0.3 m at 1000 rpm in km/h", SurfaceSpeed(RPM.Of(1000), Meters.Of(0.3)).KilometersPerHour(), 113.097},
+		{"100 km/h on 0.3 m in rpm", RollingSpeed(KilometersPerHour.Of(100), Meters.Of(0.3)).RPM(), 884.194},
+	}
+	for _, tt := range tests {
+		if math.Abs(tt.got-tt.want) > 1e-3 {
+			t.Errorf("%s = %.6f, want %.3f", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		got, want string
+	}{
+		{RPM.Of(3000).String(), "3000 rpm"},
+		{NewtonMeters.Of(250).String(), "250.0 Nm"},
+		{Watts.Of(150000).String(), "150.0 kW"},
+		{MetersPerSecond.Of(10).String(), "36.0 km/h"},
+		{Celsius.Of(90).String(), "90.0 °C"},
+		{Millimeters.Of(316).String(), "0.316 m"},
+		{Horsepower.Format(Kilowatts.Of(100), 0), "134 hp"},
+		{MilesPerHour.Format(KilometersPerHour.Of(100), 2), "62.14 mph"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("got %q, want %q", tt.got, tt.want)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	type reading struct {
+		EngineSpeed AngularVelocity `json:"engine_speed"`
+		Power       Power           `json:"power"`
+		Speed       Measure         `json:"speed"`
+	}
+	data, err := json.Marshal(reading{
+		EngineSpeed: RPM.Of(3000),
+		Power:       Kilowatts.Of(100),
+		Speed:       MilesPerHour.Measure(MilesPerHour.Of(60)),
+	})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want := `{"engine_speed":{"value":3000,"unit":"rpm"},"power":{"value":100,"unit":"kW"},"speed":{"value":60,"unit":"mph"}}`
+	if string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+
+	var speeds struct {
+		A, B Speed
+		C    Temperature
+	}
+	if err := json.Unmarshal([]byte(`{"A":{"value":60,"unit":"mph"},"B":100,"C":{"value":300,"unit":"K"}}`), &speeds); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if math.Abs(speeds.A.MetersPerSecond()-26.8224) > 1e-9 || speeds.B.KilometersPerHour() != 100 || math.Abs(speeds.C.Celsius()-26.85) > 1e-9 {
+		t.Errorf("Unmarshal = %v, %v, %v, want 60 mph, 100 km/h and 300 K", speeds.A, speeds.B, speeds.C)
+	}
+
+	var torque Torque
+	if err := json.Unmarshal([]byte(`{"value":100,"unit":"kW"}`), &torque); err == nil {
+		t.Errorf("Unmarshal of a power into a torque = %v, want an error", torque)
+	}
+}
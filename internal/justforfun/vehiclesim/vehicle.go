@@ -0,0 +1,319 @@
+package vehiclesim
+
+import (
+	"fmt"
+	"go-playground/internal/justforfun/vehiclesim/body"
+	"go-playground/internal/justforfun/vehiclesim/cruise"
+	"go-playground/internal/justforfun/vehiclesim/differential"
+	"go-playground/internal/justforfun/vehiclesim/engine"
+	"go-playground/internal/justforfun/vehiclesim/gearbox"
+	"go-playground/internal/justforfun/vehiclesim/gps"
+	"go-playground/internal/justforfun/vehiclesim/route"
+	"go-playground/internal/justforfun/vehiclesim/steering"
+	"go-playground/internal/justforfun/vehiclesim/tcs"
+	"go-playground/internal/justforfun/vehiclesim/units"
+	"go-playground/internal/justforfun/vehiclesim/wheels"
+	"log"
+	"math"
+	"time"
+)
+
+// driverMaxBrakeTorque is the brake torque per driven wheel with the pedal fully pressed, in Nm
+const driverMaxBrakeTorque = 1500.0
+
+// VehicleSpec describes the hardware of one simulated vehicle
+type VehicleSpec struct {
+	TireSpec          string  // e.g. "245/40R19"
+	TirePressureKPa   float64 // Cold inflation pressure from wheels.MinPressureKPa to MaxPressureKPa, 0 = NominalPressureKPa
+	DifferentialRatio float64
+	MassKg            float64
+	RevLimiter        engine.RevLimiterStrategy
+	LimitRPM          float64
+	TractionControl   bool
+}
+
+// DefaultVehicleSpec returns the rear driven sedan used by the single vehicle simulation
+func DefaultVehicleSpec() VehicleSpec {
+	return VehicleSpec{
+		TireSpec:          "245/40R19",
+		TirePressureKPa:   wheels.NominalPressureKPa,
+		DifferentialRatio: differential.TypeRDiffRatio,
+		MassKg:            1550,
+		RevLimiter:        engine.HardCut,
+		LimitRPM:          8200,
+		TractionControl:   true,
+	}
+}
+
+// Vehicle groups every component of one simulated car and advances them together
+type Vehicle struct {
+	ID   string
+	Spec VehicleSpec
+
+	Engine          *engine.Engine
+	Gearbox         *gearbox.ManualGearbox
+	Differential    *differential.Differential
+	Wheels          *wheels.WheelPair
+	Body            *body.Body
+	Steering        *steering.Steering
+	TractionControl *tcs.TractionControl
+	CruiseControl   *cruise.CruiseControl
+	Route           *route.Route
+	Tracker         *gps.Tracker
+	ShiftMonitor    *gearbox.ShiftMonitor
+
+	startTime time.Time
+	elapsed   float64 // Simulated seconds since startTime
+
+	brakePedal     float64 // 0.0 = released, 1.0 = fully pressed
+	manualSteering bool    // The driver steers instead of following the road
+	gear           int     // Gear of the previous step, a shift cancels the cruise control
+
+	tireRatingWarned bool // The speed rating of the tires has been exceeded once already
+}
+
+// Snapshot is the telemetry of one vehicle after a simulation step
+type Snapshot struct {
+	VehicleID string
+	Time      time.Time // Simulated time of the step
+
+	Engine          engine.Telemetry
+	Gearbox         gearbox.Telemetry
+	Differential    differential.Telemetry
+	Wheels          wheels.Telemetry
+	Body            body.Telemetry
+	TractionControl tcs.Telemetry
+	CruiseControl   cruise.Telemetry
+	Route           route.Telemetry
+	Steering        steering.Telemetry
+	GPS             gps.Telemetry
+	Shifts          []gearbox.ShiftEvent // Shifts completed in this step
+
+	fields []TelemetryField // Subset of TelemetrySchema of a replayed snapshot, nil for all of it
+}
+
+// schemaFields returns the fields of TelemetrySchema the snapshot carries
+func (s Snapshot) schemaFields() []TelemetryField {
+	if s.fields != nil {
+		return s.fields
+	}
+	return TelemetrySchema
+}
+
+// NewVehicle builds a vehicle from its spec
+// seed makes the engine fluctuations reproducible, startTime is the simulated time of the first step
+func NewVehicle(id string, spec VehicleSpec, seed int64, theRoute *route.Route, start gps.Coordinate, headingDeg float64, startTime time.Time) (*Vehicle, error) {
+	wheelPair, err := wheels.NewWheelPair(spec.TireSpec)
+	if err != nil {
+		return nil, fmt.Errorf("error creating wheels of vehicle %s: %v", id, err)
+	}
+	if spec.TirePressureKPa != 0 {
+		if err := wheelPair.SetColdPressure(spec.TirePressureKPa); err != nil {
+			return nil, fmt.Errorf("error creating wheels of vehicle %s: %v", id, err)
+		}
+	}
+
+	manualGB, ok := gearbox.NewManualGearbox().(*gearbox.ManualGearbox)
+	if !ok {
+		return nil, fmt.Errorf("vehicle %s needs a manual gearbox", id)
+	}
+
+	theEngine := engine.NewEngine()
+	theEngine.SetSeed(seed)
+	theEngine.SetRevLimiter(engine.NewRevLimiter(spec.RevLimiter, spec.LimitRPM))
+
+	theBody := body.NewBody()
+	theBody.SetMass(spec.MassKg)
+
+	tractionControl := tcs.NewTractionControl()
+	tractionControl.Enabled = spec.TractionControl
+
+	return &Vehicle{
+		ID:              id,
+		Spec:            spec,
+		Engine:          theEngine,
+		Gearbox:         manualGB,
+		Differential:    differential.NewBasicDifferential(spec.DifferentialRatio),
+		Wheels:          wheelPair,
+		Body:            theBody,
+		Steering:        steering.NewSteering(),
+		TractionControl: tractionControl,
+		CruiseControl:   cruise.NewCruiseControl(),
+		Route:           theRoute,
+		Tracker:         gps.NewTracker(start, headingDeg),
+		ShiftMonitor:    gearbox.NewShiftMonitor(),
+		startTime:       startTime,
+	}, nil
+}
+
+// SyncRPM returns the engine RPM that matches the current ground speed in the given gear
+func (v *Vehicle) SyncRPM(gear int) float64 {
+	return syncEngineRPM(v.Wheels, v.Differential, v.Gearbox, gear)
+}
+
+// drivelineSpeed returns the clutch disc speed at which the tires transmit the drive torque of the last step
+// Returns false in neutral or when the torque exceeds the grip
+func (v *Vehicle) drivelineSpeed() (units.AngularVelocity, bool) {
+	gear := v.Gearbox.GetCurrentGear()
+	wheelSpeed, ok := v.Wheels.GetDrivelineSpeed(v.Body.DrivenWheelLoad())
+	if gear == 0 || !ok {
+		return 0, false
+	}
+	return units.Geared(wheelSpeed, v.Differential.GetGearRatio()*v.Gearbox.GetGearRatio(gear)), true
+}
+
+// SetBrakePedal presses the brake pedal (0.0 to 1.0)
+func (v *Vehicle) SetBrakePedal(position float64) {
+	v.brakePedal = math.Max(0, math.Min(1, position))
+}
+
+// GetBrakePedal returns the brake pedal position
+func (v *Vehicle) GetBrakePedal() float64 {
+	return v.brakePedal
+}
+
+// Steer turns the steering wheel to the given angle in degrees and stops following the road
+func (v *Vehicle) Steer(angleDeg float64) {
+	v.manualSteering = true
+	v.Steering.SetSteeringWheelAngle(angleDeg)
+}
+
+// FollowRoad hands the steering back to the road following of every step
+func (v *Vehicle) FollowRoad() {
+	v.manualSteering = false
+}
+
+// IsSteeringManually reports whether the driver is steering instead of following the road
+func (v *Vehicle) IsSteeringManually() bool {
+	return v.manualSteering
+}
+
+// Elapsed returns the simulated seconds since the first step
+func (v *Vehicle) Elapsed() float64 {
+	return v.elapsed
+}
+
+// Step advances every component of the vehicle by deltaTime seconds and returns its telemetry
+func (v *Vehicle) Step(deltaTime float64) Snapshot {
+	v.elapsed += deltaTime
+
+	// Obtener posición del clutch de la transmisión para actualizar el motor
+	clutchPos := v.Gearbox.ClutchPosition
+
+	// The road under the car sets the grade for the body and the grip for the tires
+	roadSegment := v.Route.At(v.Wheels.GetDistance())
+	v.Body.SetGrade(roadSegment.Grade)
+	v.Wheels.SetSurfaceMu(roadSegment.Mu)
+
+	// The driver follows the road unless steering by hand
+	// The bicycle model gives the speed difference between the driven wheels
+	if !v.manualSteering {
+		v.Steering.SteerForCurvature(roadSegment.Curvature)
+	}
+	v.Steering.Update(v.Body.GetSpeed())
+	leftGroundMS, rightGroundMS := v.Steering.GetWheelGroundSpeeds()
+	v.Wheels.SetGroundSpeeds(units.MetersPerSecond.Of(leftGroundMS), units.MetersPerSecond.Of(rightGroundMS))
+
+	// Traction control cuts the torque and limits the throttle based on the slip of the previous step
+	v.Engine.SetThrottleOverride(v.TractionControl.GetThrottleLimit())
+	v.Engine.SetTorqueLimit(v.TractionControl.GetTorqueLimit())
+	// Launch control cuts the torque on the slip the wheels have now
+	v.Engine.SetWheelSlip(math.Max(v.Wheels.Left.GetSlip(), v.Wheels.Right.GetSlip()))
+
+	// Pressing the clutch or the brake pedal, or changing gear, cancels the cruise control
+	gear := v.Gearbox.GetCurrentGear()
+	if v.CruiseControl.GetState() == cruise.Engaged && (clutchPos < 1 || v.brakePedal > 0 || gear != v.gear) {
+		v.CruiseControl.Cancel()
+	}
+	v.gear = gear
+	v.Engine.SetCruiseThrottle(v.CruiseControl.GetThrottle())
+
+	// Through the clutch the wheels set the engine speed, unless the tires are spinning
+	drivelineSpeed, locked := v.drivelineSpeed()
+	v.Engine.SetDrivelineSpeed(drivelineSpeed, locked)
+
+	// Actualizar motor con posición del clutch (afecta ralentización)
+	v.Engine.Update(clutchPos, deltaTime)
+	engineSpeed := v.Engine.GetSpeed()
+	engineTorque := v.Engine.GetTorque()
+	clutchTorque := v.Engine.GetClutchTorque()
+
+	// While the tires grip, the gearbox turns at the speed of the wheels and the clutch absorbs
+	// any difference with the engine. Spinning tires follow the engine instead.
+	inputSpeed := engineSpeed
+	if locked {
+		inputSpeed = drivelineSpeed
+	}
+
+	// Actualizar transmisión con datos del motor como parámetros
+	v.Gearbox.Update(inputSpeed, clutchTorque, deltaTime)
+
+	// Actualizar diferencial
+	v.Differential.Update(v.Gearbox.GetOutputShaft(), v.Gearbox.GetOutputTorque(), v.Steering.GetSlipRatio())
+	differentialData := v.Differential.GetData()
+
+	v.Wheels.Update(v.Differential.GetWheelSpeeds())
+	v.Wheels.SetDriveTorque(units.NewtonMeters.Of(differentialData.TorqueL), units.NewtonMeters.Of(differentialData.TorqueR))
+	tcsBrakeL, tcsBrakeR := v.TractionControl.GetBrakeTorque()
+	cruiseBrake := v.CruiseControl.GetBrakeTorque()
+	pedalBrake := v.brakePedal * driverMaxBrakeTorque
+	v.Wheels.SetBrakeTorque(units.NewtonMeters.Of(tcsBrakeL+cruiseBrake+pedalBrake), units.NewtonMeters.Of(tcsBrakeR+cruiseBrake+pedalBrake))
+
+	// The tires push the body, the slip comes from wheel speed vs ground speed
+	wheelsData := v.Wheels.GetData()
+	if wheelsData.OverSpeedRating && !v.tireRatingWarned {
+		v.tireRatingWarned = true
+		log.Printf("Warning: %s reached %.1f km/h, above the %s speed rating (%.0f km/h) of its %s tires",
+			v.ID, wheelsData.VehicleSpeedKMH, wheelsData.TireInfo.SpeedRating, wheelsData.TireInfo.MaxSpeedKMH, v.Spec.TireSpec)
+	}
+	v.Body.SetTireRollingFactor(v.Wheels.GetRollingResistanceFactor())
+	v.Body.Update(v.Wheels.GetTractiveForce(v.Body.DrivenWheelLoad()), deltaTime)
+	bodyData := v.Body.GetData()
+	v.Wheels.UpdateDistance(deltaTime)
+
+	// Rolling and sliding heat and wear the tires, changing their radius and grip for the next step
+	v.Wheels.UpdateTires(v.Body.DrivenWheelLoad(), deltaTime)
+
+	snapshot := Snapshot{
+		VehicleID:    v.ID,
+		Time:         v.startTime.Add(time.Duration(v.elapsed * float64(time.Second))),
+		Engine:       v.Engine.GetData(),
+		Gearbox:      gearbox.GetManualGearboxData(v.Gearbox),
+		Differential: differentialData,
+		Wheels:       wheelsData,
+		Body:         bodyData,
+		Route:        roadSegment.GetData(),
+		Steering:     v.Steering.GetData(),
+	}
+
+	v.Tracker.Update(gps.Sample{
+		Time:       snapshot.Time,
+		SpeedMS:    bodyData.SpeedMS,
+		YawRate:    snapshot.Steering.YawRate,
+		ElevationM: snapshot.Route.ElevationM,
+		RPM:        engineSpeed.RPM(),
+		Gear:       snapshot.Gearbox.CurrentGear,
+	}, deltaTime)
+	snapshot.GPS = v.Tracker.GetData()
+
+	v.TractionControl.Update(wheelsData.SlipL, wheelsData.SlipR, deltaTime)
+	snapshot.TractionControl = v.TractionControl.GetData()
+
+	v.CruiseControl.Update(wheelsData.VehicleSpeedKMH, deltaTime)
+	snapshot.CruiseControl = v.CruiseControl.GetData()
+
+	shiftSample := gearbox.ShiftSample{
+		Gear:           snapshot.Gearbox.CurrentGear,
+		ClutchPosition: snapshot.Gearbox.ClutchPosition,
+		EngineRPM:      engineSpeed.RPM(),
+		EngineTorque:   engineTorque.NewtonMeters(),
+		OutputTorque:   snapshot.Gearbox.OutputShaftTorque,
+		SyncRPM:        v.SyncRPM(snapshot.Gearbox.CurrentGear),
+		Acceleration:   bodyData.AccelerationMS,
+	}
+	if shiftEvent, done := v.ShiftMonitor.Update(shiftSample, deltaTime); done {
+		snapshot.Shifts = append(snapshot.Shifts, shiftEvent)
+	}
+
+	return snapshot
+}
@@ -0,0 +1,86 @@
+package vehiclesim
+
+import (
+	"go-playground/internal/justforfun/vehiclesim/engine"
+	"go-playground/internal/justforfun/vehiclesim/route"
+	"math"
+	"testing"
+	"time"
+)
+
+// TestDrivelineRatio checks the engaged clutch holds the engine at the wheel speed times the gear and the
+// final drive ratio, with the final drive applied once, by the differential
+func TestDrivelineRatio(t *testing.T) {
+	vehicle, err := NewVehicle("vehicle-001", DefaultVehicleSpec(), 1, route.Straight(testTrackLengthM), defaultStart, 0, time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("NewVehicle: %v", err)
+	}
+	driver := NewPerformanceDriver(ZeroTo100(), vehicle.Spec)
+	for i := 0; i < 60; i++ {
+		driver.Drive(vehicle, 0.1)
+		vehicle.Step(0.1)
+	}
+
+	// Steady part throttle in the current gear
+	vehicle.Gearbox.SetClutch(1)
+	vehicle.Engine.SetAcceleratorPos(0.5)
+	var snapshot Snapshot
+	for i := 0; i < 30; i++ {
+		snapshot = vehicle.Step(0.1)
+	}
+
+	gear := vehicle.Gearbox.GetCurrentGear()
+	ratio := vehicle.Gearbox.GetGearRatio(gear) * vehicle.Spec.DifferentialRatio
+	wheelRPM := (snapshot.Wheels.WheelSpeedL + snapshot.Wheels.WheelSpeedR) / 2
+	if got := snapshot.Engine.RPM / wheelRPM; gear == 0 || math.Abs(got/ratio-1) > 0.03 {
+		t.Errorf("gear %d: engine at %.0f rpm, wheels at %.0f rpm, ratio %.3f, want %.3f", gear, snapshot.Engine.RPM, wheelRPM, got, ratio)
+	}
+	if got := snapshot.Differential.TorqueL + snapshot.Differential.TorqueR; math.Abs(got-snapshot.Gearbox.OutputShaftTorque*vehicle.Spec.DifferentialRatio) > 1e-6 {
+		t.Errorf("wheels get %.1f Nm from %.1f Nm at the gearbox output, want × %.2f", got, snapshot.Gearbox.OutputShaftTorque, vehicle.Spec.DifferentialRatio)
+	}
+}
+
+// TestVehicleTirePressure rejects a spec with a tire pressure out of range
+func TestVehicleTirePressure(t *testing.T) {
+	spec := DefaultVehicleSpec()
+	for _, kpa := range []float64{-100, 5, 1000} {
+		spec.TirePressureKPa = kpa
+		if _, err := NewVehicle("vehicle-001", spec, 1, route.Default(), defaultStart, 0, time.Unix(0, 0)); err == nil {
+			t.Errorf("tire pressure %.0f kPa accepted", kpa)
+		}
+	}
+}
+
+// TestLaunchControlWheelspin launches on a wet straight without traction control: launch control has to
+// read the wheel slip and spin the tires less than the open-loop torque ramp alone
+func TestLaunchControlWheelspin(t *testing.T) {
+	spin := func(slipTorqueCut float64) float64 {
+		spec := DefaultVehicleSpec()
+		spec.TractionControl = false
+		wet := route.Straight(testTrackLengthM)
+		for i := range wet.Points {
+			wet.Points[i].Mu = 0.6
+		}
+		vehicle, err := NewVehicle("vehicle-001", spec, 1, wet, defaultStart, 0, time.Unix(0, 0))
+		if err != nil {
+			t.Fatalf("NewVehicle: %v", err)
+		}
+		vehicle.Engine.GetLaunchControl().SlipTorqueCut = slipTorqueCut
+		driver := NewPerformanceDriver(ZeroTo100(), spec)
+
+		total := 0.0
+		for i := 0; i < 40; i++ {
+			driver.Drive(vehicle, 0.1)
+			snapshot := vehicle.Step(0.1)
+			if snapshot.Engine.EngineState == "launch_control" {
+				total += math.Max(snapshot.Wheels.SlipL, snapshot.Wheels.SlipR) * 0.1
+			}
+		}
+		return total
+	}
+
+	ramp, closedLoop := spin(0), spin(engine.NewLaunchControl(4000).SlipTorqueCut)
+	if closedLoop >= 0.8*ramp {
+		t.Errorf("slip integral %.3f s with the slip cut, want well below the %.3f s of the torque ramp alone", closedLoop, ramp)
+	}
+}
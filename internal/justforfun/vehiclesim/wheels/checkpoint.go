@@ -0,0 +1,67 @@
+package wheels
+
+import "go-playground/internal/justforfun/vehiclesim/units"
+
+// WheelCheckpoint is the state of one wheel needed to resume a simulation
+type WheelCheckpoint struct {
+	SpeedRPM      float64
+	SurfaceMu     float64
+	GroundSpeedMS float64
+	DriveTorque   float64 // Nm
+	BrakeTorque   float64 // Nm
+
+	ColdPressureKPa float64
+	TireTempC       float64
+	TreadWornMM     float64
+}
+
+// Checkpoint is the state of the driven wheels needed to resume a simulation
+// The tire size comes from the vehicle spec
+type Checkpoint struct {
+	Left      WheelCheckpoint
+	Right     WheelCheckpoint
+	DistanceM float64
+}
+
+// Checkpoint returns the current state of the wheel
+func (w *Wheel) Checkpoint() WheelCheckpoint {
+	return WheelCheckpoint{
+		SpeedRPM:      w.speed.RPM(),
+		SurfaceMu:     w.surfaceMu,
+		GroundSpeedMS: w.groundSpeed.MetersPerSecond(),
+		DriveTorque:   w.driveTorque.NewtonMeters(),
+		BrakeTorque:   w.brakeTorque.NewtonMeters(),
+
+		ColdPressureKPa: w.coldPressureKPa,
+		TireTempC:       w.tireTempC,
+		TreadWornMM:     w.treadWornMM,
+	}
+}
+
+// Restore sets the wheel back to a saved state
+func (w *Wheel) Restore(c WheelCheckpoint) {
+	w.speed = units.RPM.Of(c.SpeedRPM)
+	w.surfaceMu = c.SurfaceMu
+	w.groundSpeed = units.MetersPerSecond.Of(c.GroundSpeedMS)
+	w.driveTorque = units.NewtonMeters.Of(c.DriveTorque)
+	w.brakeTorque = units.NewtonMeters.Of(c.BrakeTorque)
+	w.coldPressureKPa = c.ColdPressureKPa
+	w.tireTempC = c.TireTempC
+	w.treadWornMM = c.TreadWornMM
+}
+
+// Checkpoint returns the current state of both wheels
+func (wp *WheelPair) Checkpoint() Checkpoint {
+	return Checkpoint{
+		Left:      wp.Left.Checkpoint(),
+		Right:     wp.Right.Checkpoint(),
+		DistanceM: wp.distanceM,
+	}
+}
+
+// Restore sets both wheels back to a saved state
+func (wp *WheelPair) Restore(c Checkpoint) {
+	wp.Left.Restore(c.Left)
+	wp.Right.Restore(c.Right)
+	wp.distanceM = c.DistanceM
+}
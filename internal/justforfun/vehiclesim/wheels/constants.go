@@ -1,12 +0,0 @@
-package wheels
-
-import "math"
-
-const (
-	// Unit conversions
-	RPMToRadPerSec = 2 * math.Pi / 60
-	MSToKMH        = 3.6
-	MSToMPH        = 2.237
-	InchToMM       = 25.4
-	MMToM          = 0.001
-)
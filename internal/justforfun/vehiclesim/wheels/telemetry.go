@@ -4,16 +4,35 @@ import "fmt"
 
 // Telemetry provides complete wheel telemetry data
 type Telemetry struct {
-	WheelSpeedL  float64
-	WheelSpeedR  float64
-	VehicleSpeed Speed
-	TireInfo     TireInfo
+	WheelSpeedL     float64
+	WheelSpeedR     float64
+	VehicleSpeedKMH float64 // Speed from the driven wheels
+	SlipL           float64 // Longitudinal slip ratio of the left wheel
+	SlipR           float64 // Longitudinal slip ratio of the right wheel
+	BrakeTorqueL    float64
+	BrakeTorqueR    float64
+	BrakeTorque     float64 // Both wheels
+	DistanceM       float64 // Distance traveled over the ground in meters
+	TireInfo        TireInfo
+	TireL           TireState // Pressure, temperature and wear of the left tire
+	TireR           TireState // Pressure, temperature and wear of the right tire
+
+	OverSpeedRating bool // The wheels turn faster than the speed rating of the tires
 }
 
 func (d Telemetry) String() string {
-	return fmt.Sprintf("Wheels [WheelSpeedL: %.2f RPM, WheelSpeedR: %.2f RPM, VehicleSpeed: %.2f KMH]\n",
+	return fmt.Sprintf("Wheels [WheelSpeedL: %.2f RPM, WheelSpeedR: %.2f RPM, VehicleSpeed: %.2f KMH, SlipL: %.2f, SlipR: %.2f, Distance: %.0f m, TireTemp: %.1f/%.1f °C, TirePressure: %.0f/%.0f kPa, Tread: %.2f/%.2f mm]\n",
 		d.WheelSpeedL,
 		d.WheelSpeedR,
-		d.VehicleSpeed.KMH)
+		d.VehicleSpeedKMH,
+		d.SlipL,
+		d.SlipR,
+		d.DistanceM,
+		d.TireL.TempC,
+		d.TireR.TempC,
+		d.TireL.PressureKPa,
+		d.TireR.PressureKPa,
+		d.TireL.TreadDepthMM,
+		d.TireR.TreadDepthMM)
 
 }
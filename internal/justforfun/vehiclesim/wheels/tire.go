@@ -2,44 +2,221 @@ package wheels
 
 import (
 	"fmt"
+	"go-playground/internal/justforfun/vehiclesim/units"
+	"math"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
-// TireSize represents tire dimensions
+// TireSize represents tire dimensions and, when the sidewall code has one, its service description
 type TireSize struct {
+	Service        string  // Service type prefix: "P" passenger, "LT" light truck, "T" temporary, "ST" trailer, "" metric
+	Construction   string  // "R" radial, "ZR" radial rated above 240 km/h, "D" diagonal, "B" belted
 	Width          float64 // Width in millimeters
 	AspectRatio    float64 // Aspect ratio (height/width)
 	WheelDiameter  float64 // Rim diameter in inches
 	SideWallHeight float64 // Sidewall height in millimeters
 	TotalRadius    float64 // Total radius in meters
+
+	LoadIndex     int     // 0 when not given
+	DualLoadIndex int     // Load index of each tire in dual fitment (LT tires), 0 when not given
+	LoadCapacity  float64 // Maximum load per tire in kg for LoadIndex, 0 when unknown
+	SpeedRating   string  // Speed symbol, e.g. "W", "(Y)" when the rating is a minimum
+	MaxSpeedKMH   float64 // Maximum speed for SpeedRating, 0 when unlimited or unknown
+	Reinforced    bool    // Extra load tire, marked XL or RF after the service description
+}
+
+var (
+	// Metric and P-metric sizes: "245/40R19", "P225/45ZR17 94W", "LT265/70R17 121/118S", "285/30ZR19 (98Y)"
+	metricTirePattern = regexp.MustCompile(`^(P|LT|T|ST)?(\d{3})/(\d{2,3})(ZR|R|D|B|-)(\d{2}(?:\.5)?)(LT)?(?:\s+(.+))?$`)
+	// Flotation sizes in inches, overall diameter x width: "33x12.50R15 108Q", "35x12.50R17LT 121Q"
+	flotationTirePattern = regexp.MustCompile(`^(LT)?(\d{2}(?:\.\d{1,2})?)[xX](\d{1,2}(?:\.\d{1,2})?)(ZR|R|D|B|-)(\d{2}(?:\.5)?)(LT)?(?:\s+(.+))?$`)
+	// Service description: load index, dual fitment load index and speed symbol, in parentheses above the listed speed,
+	// then the extra load marking
+	serviceDescriptionPattern = regexp.MustCompile(`^(\()?(\d{2,3})(?:/(\d{2,3}))?([A-Z])(\))?(?:\s+(XL|RF))?$`)
+)
+
+// speedRatingsKMH maps speed symbols to the maximum speed of the tire
+// Z only says above 240 km/h, like the ZR construction, so it has no upper limit
+var speedRatingsKMH = map[string]float64{
+	"L": 120, "M": 130, "N": 140, "P": 150, "Q": 160, "R": 170, "S": 180, "T": 190,
+	"U": 200, "H": 210, "V": 240, "Z": 0, "W": 270, "Y": 300,
+}
+
+// loadIndexKg maps load indexes from 60 to 150 to the maximum load per tire in kg
+var loadIndexKg = map[int]float64{
+	60: 250, 61: 257, 62: 265, 63: 272, 64: 280, 65: 290, 66: 300, 67: 307, 68: 315, 69: 325,
+	70: 335, 71: 345, 72: 355, 73: 365, 74: 375, 75: 387, 76: 400, 77: 412, 78: 425, 79: 437,
+	80: 450, 81: 462, 82: 475, 83: 487, 84: 500, 85: 515, 86: 530, 87: 545, 88: 560, 89: 580,
+	90: 600, 91: 615, 92: 630, 93: 650, 94: 670, 95: 690, 96: 710, 97: 730, 98: 750, 99: 775,
+	100: 800, 101: 825, 102: 850, 103: 875, 104: 900, 105: 925, 106: 950, 107: 975, 108: 1000, 109: 1030,
+	110: 1060, 111: 1090, 112: 1120, 113: 1150, 114: 1180, 115: 1215, 116: 1250, 117: 1285, 118: 1320, 119: 1360,
+	120: 1400, 121: 1450, 122: 1500, 123: 1550, 124: 1600, 125: 1650, 126: 1700, 127: 1750, 128: 1800, 129: 1850,
+	130: 1900, 131: 1950, 132: 2000, 133: 2060, 134: 2120, 135: 2180, 136: 2240, 137: 2300, 138: 2360, 139: 2430,
+	140: 2500, 141: 2575, 142: 2650, 143: 2725, 144: 2800, 145: 2900, 146: 3000, 147: 3075, 148: 3150, 149: 3250,
+	150: 3350,
 }
 
-// ParseTireSize parses a tire specification (e.g., "245/40R19")
-// Format: [Width]/[AspectRatio]R[WheelDiameter]
+// ParseTireSize parses a tire sidewall code
+// Formats: [Service][Width]/[AspectRatio][Construction][WheelDiameter] [Service description], e.g. "P225/45ZR17 94W",
+// and flotation sizes [Diameter]x[Width][Construction][WheelDiameter][LT] [Service description], e.g. "33x12.50R15 108Q".
+// Rims can be half inch sizes such as 16.5, the service description is optional.
 func ParseTireSize(spec string) (*TireSize, error) {
-	pattern := regexp.MustCompile(`^(\d+)/(\d+)R(\d+)$`)
-	matches := pattern.FindStringSubmatch(spec)
+	spec = strings.TrimSpace(spec)
 
-	if matches == nil {
-		return nil, fmt.Errorf("invalid tire format: %s", spec)
+	if matches := metricTirePattern.FindStringSubmatch(spec); matches != nil {
+		return parseMetricTireSize(spec, matches)
 	}
+	if matches := flotationTirePattern.FindStringSubmatch(spec); matches != nil {
+		return parseFlotationTireSize(spec, matches)
+	}
+	return nil, fmt.Errorf("invalid tire format: %s", spec)
+}
 
-	// Convert string values to numbers
-	width, _ := strconv.ParseFloat(matches[1], 64)
-	aspectRatio, _ := strconv.ParseFloat(matches[2], 64)
-	diameter, _ := strconv.ParseFloat(matches[3], 64)
-
-	// Calculate dimensions
-	sideWallHeight := width * (aspectRatio / 100)
-	wheelDiameterMM := diameter * InchToMM
-	totalDiameterMM := wheelDiameterMM + (2 * sideWallHeight)
+func parseMetricTireSize(spec string, matches []string) (*TireSize, error) {
+	width, err := parseTireNumber(spec, "width", matches[2])
+	if err != nil {
+		return nil, err
+	}
+	aspectRatio, err := parseTireNumber(spec, "aspect ratio", matches[3])
+	if err != nil {
+		return nil, err
+	}
+	diameter, err := parseTireNumber(spec, "rim diameter", matches[5])
+	if err != nil {
+		return nil, err
+	}
 
-	return &TireSize{
+	size := &TireSize{
+		Service:        tireService(matches[1], matches[6]),
+		Construction:   tireConstruction(matches[4]),
 		Width:          width,
 		AspectRatio:    aspectRatio,
 		WheelDiameter:  diameter,
+		SideWallHeight: width * (aspectRatio / 100),
+	}
+	size.TotalRadius = tireRadius(diameter, size.SideWallHeight)
+	return size, size.parseServiceDescription(spec, matches[7])
+}
+
+func parseFlotationTireSize(spec string, matches []string) (*TireSize, error) {
+	overallDiameter, err := parseTireNumber(spec, "overall diameter", matches[2])
+	if err != nil {
+		return nil, err
+	}
+	widthIn, err := parseTireNumber(spec, "width", matches[3])
+	if err != nil {
+		return nil, err
+	}
+	diameter, err := parseTireNumber(spec, "rim diameter", matches[5])
+	if err != nil {
+		return nil, err
+	}
+	if overallDiameter <= diameter {
+		return nil, fmt.Errorf("invalid tire %s: overall diameter %g in is not above the rim %g in", spec, overallDiameter, diameter)
+	}
+
+	width := units.Inches.Of(widthIn).Millimeters()
+	sideWallHeight := units.Inches.Of((overallDiameter - diameter) / 2).Millimeters()
+	size := &TireSize{
+		Service:        tireService(matches[1], matches[6]),
+		Construction:   tireConstruction(matches[4]),
+		Width:          width,
+		AspectRatio:    sideWallHeight / width * 100,
+		WheelDiameter:  diameter,
 		SideWallHeight: sideWallHeight,
-		TotalRadius:    (totalDiameterMM / 2) * MMToM, // Convert to meters
-	}, nil
+		TotalRadius:    tireRadius(diameter, sideWallHeight),
+	}
+	return size, size.parseServiceDescription(spec, matches[7])
+}
+
+// parseServiceDescription reads the load index and speed symbol, e.g. "94W", "121/118S", "(98Y)" or "98Y XL"
+// Without one the speed is not limited, a ZR tire is only known to be rated above 240 km/h
+func (t *TireSize) parseServiceDescription(spec string, description string) error {
+	if description == "" {
+		return nil
+	}
+
+	matches := serviceDescriptionPattern.FindStringSubmatch(description)
+	if matches == nil || (matches[1] == "") != (matches[5] == "") {
+		return fmt.Errorf("invalid service description %q of tire %s, expected load index and speed symbol like 94W", description, spec)
+	}
+
+	loadIndex, err := strconv.Atoi(matches[2])
+	if err != nil {
+		return fmt.Errorf("invalid load index of tire %s: %v", spec, err)
+	}
+	t.LoadIndex = loadIndex
+	t.LoadCapacity = loadIndexKg[loadIndex]
+	t.Reinforced = matches[6] != ""
+	if matches[3] != "" {
+		if t.DualLoadIndex, err = strconv.Atoi(matches[3]); err != nil {
+			return fmt.Errorf("invalid dual load index of tire %s: %v", spec, err)
+		}
+	}
+
+	maxSpeed, ok := speedRatingsKMH[matches[4]]
+	if !ok {
+		return fmt.Errorf("unknown speed symbol %s of tire %s", matches[4], spec)
+	}
+	t.SpeedRating = matches[4]
+	t.MaxSpeedKMH = maxSpeed
+	if matches[1] != "" {
+		// A service description in parentheses only gives the minimum, the tire is rated above that speed
+		t.SpeedRating = "(" + matches[4] + ")"
+		t.MaxSpeedKMH = 0
+	}
+	return nil
+}
+
+func parseTireNumber(spec string, name string, value string) (float64, error) {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s of tire %s: %v", name, spec, err)
+	}
+	if number <= 0 {
+		return 0, fmt.Errorf("invalid %s of tire %s: %s", name, spec, value)
+	}
+	return number, nil
+}
+
+// tireService returns the service type from the prefix, or from the LT suffix of flotation and some metric sizes
+func tireService(prefix string, suffix string) string {
+	if prefix != "" {
+		return prefix
+	}
+	return suffix
+}
+
+// tireConstruction names the construction code, "-" is the diagonal tire of older sizes
+func tireConstruction(code string) string {
+	if code == "-" {
+		return "D"
+	}
+	return code
+}
+
+// tireRadius returns the radius in meters of a tire mounted on a rim of the given inches
+func tireRadius(rimDiameterIn float64, sideWallHeightMM float64) float64 {
+	totalDiameter := units.Inches.Of(rimDiameterIn) + 2*units.Millimeters.Of(sideWallHeightMM)
+	return (totalDiameter / 2).Meters()
+}
+
+// Simplified Pacejka "magic formula" coefficients for the longitudinal force
+const (
+	tireStiffnessB = 12.0 // Stiffness factor
+	tireShapeC     = 1.5  // Shape factor, peak grip around 15% slip
+)
+
+// TireForceFactor returns the fraction of the peak friction force available at the given slip ratio
+func TireForceFactor(slip float64) float64 {
+	return math.Sin(tireShapeC * math.Atan(tireStiffnessB*slip))
+}
+
+// SlipForForceFactor is the inverse of TireForceFactor up to the peak: the slip ratio at which
+// the tire delivers the given fraction (-1.0 to 1.0) of the peak friction force
+func SlipForForceFactor(factor float64) float64 {
+	factor = math.Max(-1, math.Min(1, factor))
+	return math.Tan(math.Asin(factor)/tireShapeC) / tireStiffnessB
 }
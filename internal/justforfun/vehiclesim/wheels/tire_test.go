@@ -0,0 +1,180 @@
+package wheels
+
+import (
+	"go-playground/internal/justforfun/vehiclesim/units"
+	"math"
+	"testing"
+)
+
+func TestParseTireSize(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    TireSize // Without TotalRadius, sizes rounded to 0.01
+		radiusM float64
+	}{
+		{"245/40R19", TireSize{Construction: "R", Width: 245, AspectRatio: 40, WheelDiameter: 19, SideWallHeight: 98}, 0.3393},
+		{"P225/45ZR17 94W", TireSize{Service: "P", Construction: "ZR", Width: 225, AspectRatio: 45, WheelDiameter: 17,
+			SideWallHeight: 101.25, LoadIndex: 94, LoadCapacity: 670, SpeedRating: "W", MaxSpeedKMH: 270}, 0.3172},
+		{"LT265/70R17 121/118S", TireSize{Service: "LT", Construction: "R", Width: 265, AspectRatio: 70, WheelDiameter: 17,
+			SideWallHeight: 185.5, LoadIndex: 121, DualLoadIndex: 118, LoadCapacity: 1450, SpeedRating: "S", MaxSpeedKMH: 180}, 0.4014},
+		{"285/30ZR19 (98Y)", TireSize{Construction: "ZR", Width: 285, AspectRatio: 30, WheelDiameter: 19,
+			SideWallHeight: 85.5, LoadIndex: 98, LoadCapacity: 750, SpeedRating: "(Y)"}, 0.3268},
+		{"255/35ZR18", TireSize{Construction: "ZR", Width: 255, AspectRatio: 35, WheelDiameter: 18,
+			SideWallHeight: 89.25}, 0.3179},
+		{"255/35R18 94Z", TireSize{Construction: "R", Width: 255, AspectRatio: 35, WheelDiameter: 18,
+			SideWallHeight: 89.25, LoadIndex: 94, LoadCapacity: 670, SpeedRating: "Z"}, 0.3179},
+		{"215/85R16.5", TireSize{Construction: "R", Width: 215, AspectRatio: 85, WheelDiameter: 16.5, SideWallHeight: 182.75}, 0.3923},
+		{"33x12.50R15 108Q", TireSize{Construction: "R", Width: 317.5, AspectRatio: 72, WheelDiameter: 15, SideWallHeight: 228.6,
+			LoadIndex: 108, LoadCapacity: 1000, SpeedRating: "Q", MaxSpeedKMH: 160}, 0.4191},
+		{"35x12.50R17LT 121Q", TireSize{Service: "LT", Construction: "R", Width: 317.5, AspectRatio: 72, WheelDiameter: 17,
+			SideWallHeight: 228.6, LoadIndex: 121, LoadCapacity: 1450, SpeedRating: "Q", MaxSpeedKMH: 160}, 0.4445},
+		{"225/40R18 92Y XL", TireSize{Construction: "R", Width: 225, AspectRatio: 40, WheelDiameter: 18, SideWallHeight: 90,
+			LoadIndex: 92, LoadCapacity: 630, SpeedRating: "Y", MaxSpeedKMH: 300, Reinforced: true}, 0.3186},
+		{"T125/70D17", TireSize{Service: "T", Construction: "D", Width: 125, AspectRatio: 70, WheelDiameter: 17, SideWallHeight: 87.5}, 0.3034},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTireSize(tt.spec)
+		if err != nil {
+			t.Errorf("ParseTireSize(%q): %v", tt.spec, err)
+			continue
+		}
+		if math.Abs(got.TotalRadius-tt.radiusM) > 1e-4 {
+			t.Errorf("ParseTireSize(%q) radius = %.4f m, want %.4f m", tt.spec, got.TotalRadius, tt.radiusM)
+		}
+		got.TotalRadius = 0
+		got.AspectRatio = math.Round(got.AspectRatio*100) / 100
+		got.SideWallHeight = math.Round(got.SideWallHeight*100) / 100
+		if *got != tt.want {
+			t.Errorf("ParseTireSize(%q) =\n%+v, want\n%+v", tt.spec, *got, tt.want)
+		}
+	}
+}
+
+func TestParseTireSizeErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"245/40",
+		"245/40R19 98",      // Missing speed symbol
+		"245/40R19 98A",     // Unknown speed symbol
+		"245/40R19 (98Y",    // Unbalanced parentheses
+		"245/40R19 98W SUV", // Only the service description and XL or RF may follow the size
+		"15x12.50R15",       // Overall diameter not above the rim
+		"000/40R19",
+	} {
+		if size, err := ParseTireSize(spec); err == nil {
+			t.Errorf("ParseTireSize(%q) = %+v, expected an error", spec, *size)
+		}
+	}
+}
+
+func TestOverSpeedRating(t *testing.T) {
+	pair, err := NewWheelPair("205/55R16 91Q")
+	if err != nil {
+		t.Fatalf("NewWheelPair: %v", err)
+	}
+	radius := pair.Left.GetTireInfo().TotalRadiusM
+	speedAt := func(kmh float64) units.AngularVelocity {
+		return units.RollingSpeed(units.KilometersPerHour.Of(kmh), units.Meters.Of(radius))
+	}
+
+	pair.Update(speedAt(155), speedAt(155))
+	if pair.GetData().OverSpeedRating {
+		t.Error("155 km/h flagged above the 160 km/h Q rating")
+	}
+	pair.Update(speedAt(165), speedAt(165))
+	if data := pair.GetData(); !data.OverSpeedRating || data.TireInfo.LoadCapacityKg != 615 {
+		t.Errorf("165 km/h on a 91Q tire: got over speed %t, load capacity %.0f kg", data.OverSpeedRating, data.TireInfo.LoadCapacityKg)
+	}
+
+	// Z and ZR tires are rated above 240 km/h without an upper limit
+	for _, spec := range []string{"255/35R18 94Z", "255/35ZR18"} {
+		pair, err := NewWheelPair(spec)
+		if err != nil {
+			t.Fatalf("NewWheelPair: %v", err)
+		}
+		radius := pair.Left.GetTireInfo().TotalRadiusM
+		speed := units.RollingSpeed(units.KilometersPerHour.Of(320), units.Meters.Of(radius))
+		pair.Update(speed, speed)
+		if pair.GetData().OverSpeedRating {
+			t.Errorf("320 km/h flagged above the rating of a %s tire", spec)
+		}
+	}
+}
+
+// TestTireState spins a wheel and checks the tire heats up, wears and cools down again,
+// and that a soft tire rolls smaller and harder
+func TestTireState(t *testing.T) {
+	wheel, err := NewWheel("245/40R19")
+	if err != nil {
+		t.Fatalf("NewWheel: %v", err)
+	}
+	fresh := wheel.GetTireState()
+	if fresh.PressureKPa != NominalPressureKPa || fresh.RollingRadiusM != wheel.GetTireInfo().TotalRadiusM || fresh.GripFactor != 1 {
+		t.Fatalf("new tire = %+v, want nominal pressure, full radius and grip", fresh)
+	}
+
+	// Wheelspin at 10 m/s over the ground
+	const load = 4000.0
+	wheel.SetGroundSpeed(units.MetersPerSecond.Of(10))
+	wheel.SetSpeed(units.RollingSpeed(units.MetersPerSecond.Of(20), units.Meters.Of(fresh.RollingRadiusM)))
+	wheel.SetDriveTorque(units.NewtonMeters.Of(3000))
+	for i := 0; i < 100; i++ {
+		wheel.UpdateTire(load, 0.1)
+	}
+	hot := wheel.GetTireState()
+	if hot.TempC <= fresh.TempC+10 || hot.PressureKPa <= fresh.PressureKPa || hot.TreadDepthMM >= fresh.TreadDepthMM {
+		t.Errorf("after 10 s of wheelspin: %+v, want a hotter, harder and worn tire", hot)
+	}
+	if hot.GripFactor >= 1 || wheel.GetGripForce(load) >= load {
+		t.Errorf("hot tire grip factor %.3f, want below 1", hot.GripFactor)
+	}
+
+	// Parked, the tire cools back to ambient but keeps its wear
+	wheel.SetGroundSpeed(0)
+	wheel.SetSpeed(0)
+	wheel.SetDriveTorque(0)
+	for i := 0; i < 72000; i++ {
+		wheel.UpdateTire(load, 0.1)
+	}
+	if cooled := wheel.GetTireState(); math.Abs(cooled.TempC-AmbientTempC) > 0.1 || cooled.TreadDepthMM != hot.TreadDepthMM {
+		t.Errorf("after two hours parked: %+v, want ambient temperature and %.3f mm of tread", cooled, hot.TreadDepthMM)
+	}
+
+	soft, err := NewWheel("245/40R19")
+	if err != nil {
+		t.Fatalf("NewWheel: %v", err)
+	}
+	if err := soft.SetColdPressure(NominalPressureKPa / 2); err != nil {
+		t.Fatalf("SetColdPressure: %v", err)
+	}
+	if soft.GetRollingRadius() >= fresh.RollingRadiusM || soft.GetRollingResistanceFactor() <= 1 || soft.GetGripFactor() >= 1 {
+		t.Errorf("half inflated tire: radius %.4f m, rolling resistance x%.2f, grip x%.2f",
+			soft.GetRollingRadius(), soft.GetRollingResistanceFactor(), soft.GetGripFactor())
+	}
+}
+
+// TestTirePressureRange rejects pressures a tire cannot be driven on and keeps a flat tire on its sidewall
+func TestTirePressureRange(t *testing.T) {
+	wheel, err := NewWheel("245/40R19")
+	if err != nil {
+		t.Fatalf("NewWheel: %v", err)
+	}
+	for _, kpa := range []float64{0, 7, MaxPressureKPa + 1} {
+		if err := wheel.SetColdPressure(kpa); err == nil {
+			t.Errorf("SetColdPressure(%.0f) accepted", kpa)
+		}
+		if pressure := wheel.GetPressure(); pressure != NominalPressureKPa {
+			t.Errorf("after SetColdPressure(%.0f): %.0f kPa, want the nominal pressure kept", kpa, pressure)
+		}
+	}
+
+	// A flat tire deflects at most 30% of its 98 mm sidewall
+	minRadius := wheel.GetTireInfo().TotalRadiusM - 0.3*0.098
+	for _, kpa := range []float64{5, 0.5, 0} {
+		wheel.coldPressureKPa = kpa
+		if radius := wheel.GetRollingRadius(); math.Abs(radius-minRadius) > 1e-9 {
+			t.Errorf("%.1f kPa: rolling radius %.4f m, want the %.4f m of the sidewall deflected", kpa, radius, minRadius)
+		}
+	}
+}
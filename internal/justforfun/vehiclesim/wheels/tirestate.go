@@ -0,0 +1,153 @@
+package wheels
+
+import (
+	"fmt"
+	"go-playground/internal/justforfun/vehiclesim/units"
+	"math"
+)
+
+const (
+	NominalPressureKPa = 240.0 // Cold inflation pressure the TotalRadius of a tire size is given for
+	AmbientTempC       = 20.0  // Air and road temperature
+	NewTreadDepthMM    = 8.0   // Tread depth of a new tire
+	MinTreadDepthMM    = 1.6   // Legal limit, the tread wear indicators are flush with the tread
+	MinPressureKPa     = 80.0  // Lowest cold pressure a tire can be driven on
+	MaxPressureKPa     = 600.0 // Highest cold pressure, light truck tires at their max load
+
+	atmosphericKPa = 101.325
+
+	tireHeatCapacity   = 8000.0 // Carcass heat capacity in J/K
+	tireCoolingStill   = 10.0   // Heat loss in W/K with the wheel stopped
+	tireCoolingPerMS   = 1.5    // Additional heat loss in W/K per m/s of speed
+	tireRollingLossCrr = 0.012  // Share of the normal load lost as heat while rolling
+
+	wearPerMeterMM   = 1.6e-7 // Tread lost per meter of normal rolling, ~40 000 km per tire
+	wearPerSlipJMM   = 5e-7   // Tread lost per Joule of slip energy, weighted by the slip ratio
+	wearHotTempC     = 90.0   // Above this carcass temperature the tread wears faster
+	gripHotTempC     = 90.0   // Above this carcass temperature the rubber loses grip
+	gripColdTempC    = AmbientTempC
+	gripLossPerHotC  = 0.004
+	gripLossPerColdC = 0.003
+	minGripFactor    = 0.6
+
+	pressureRadiusFactor  = 0.03  // Rolling radius lost at half the nominal pressure, as a share of the radius
+	maxSidewallDeflection = 0.3   // Share of the sidewall height a soft tire can deflect, then the rim sits on it
+	pressureGripFactor    = 0.5   // Grip lost per squared relative pressure deviation
+	wornTireWetGripLoss   = 0.25  // Grip lost by a worn tire on a surface without grip
+	warmRollingGain       = 0.004 // Rolling resistance lost per °C of carcass above ambient
+	wornRollingGain       = 0.1   // Rolling resistance lost by a worn tire
+)
+
+// TireState is the pressure, temperature and wear of one tire
+type TireState struct {
+	PressureKPa    float64 // Gauge inflation pressure, rises with the temperature
+	TempC          float64 // Carcass temperature
+	TreadDepthMM   float64
+	Wear           float64 // 0.0 = new, 1.0 = worn down to MinTreadDepthMM
+	RollingRadiusM float64 // Effective rolling radius
+	GripFactor     float64 // Share of the peak grip of a new tire at its best
+}
+
+// SetColdPressure sets the inflation pressure in kPa at ambient temperature
+// Returns an error outside MinPressureKPa to MaxPressureKPa, the pressure is then left unchanged
+func (w *Wheel) SetColdPressure(kpa float64) error {
+	if kpa < MinPressureKPa || kpa > MaxPressureKPa {
+		return fmt.Errorf("tire pressure %.0f kPa out of range, expected %.0f to %.0f kPa", kpa, MinPressureKPa, MaxPressureKPa)
+	}
+	w.coldPressureKPa = kpa
+	return nil
+}
+
+// GetPressure returns the gauge inflation pressure in kPa at the current carcass temperature
+// The air in the tire follows the carcass temperature at constant volume
+func (w *Wheel) GetPressure() float64 {
+	absolute := (w.coldPressureKPa + atmosphericKPa) * units.Celsius.Of(w.tireTempC).Kelvin() / units.Celsius.Of(AmbientTempC).Kelvin()
+	return absolute - atmosphericKPa
+}
+
+// GetTreadDepth returns the remaining tread depth in mm
+func (w *Wheel) GetTreadDepth() float64 {
+	return NewTreadDepthMM - w.treadWornMM
+}
+
+// getWear returns the tread lost as a share of the usable tread
+func (w *Wheel) getWear() float64 {
+	return w.treadWornMM / (NewTreadDepthMM - MinTreadDepthMM)
+}
+
+// GetRollingRadius returns the effective rolling radius in meters
+// A worn tread shrinks the tire and a soft tire deflects more under load, at most a share of its sidewall
+func (w *Wheel) GetRollingRadius() float64 {
+	deflection := maxSidewallDeflection * units.Millimeters.Of(w.tireSize.SideWallHeight).Meters()
+	if pressure := w.GetPressure(); pressure > 0 {
+		deflection = math.Min(deflection, pressureRadiusFactor*w.tireSize.TotalRadius*(NominalPressureKPa/pressure-1))
+	}
+	return w.tireSize.TotalRadius - units.Millimeters.Of(w.treadWornMM).Meters() - deflection
+}
+
+// rollingRadius returns the effective rolling radius as a Length
+func (w *Wheel) rollingRadius() units.Length {
+	return units.Meters.Of(w.GetRollingRadius())
+}
+
+// GetGripFactor returns the peak grip of the tire relative to a new tire at nominal pressure
+// and working temperature. A worn tread only loses grip on low friction surfaces, where it
+// cannot clear the water.
+func (w *Wheel) GetGripFactor() float64 {
+	factor := 1.0
+	if w.tireTempC > gripHotTempC {
+		factor -= gripLossPerHotC * (w.tireTempC - gripHotTempC)
+	} else if w.tireTempC < gripColdTempC {
+		factor -= gripLossPerColdC * (gripColdTempC - w.tireTempC)
+	}
+
+	deviation := w.GetPressure()/NominalPressureKPa - 1
+	factor -= pressureGripFactor * deviation * deviation
+
+	factor -= wornTireWetGripLoss * w.getWear() * math.Max(0, 1-w.surfaceMu)
+	return math.Max(minGripFactor, factor)
+}
+
+// GetRollingResistanceFactor returns the rolling resistance of the tire relative to a new tire
+// at nominal pressure and ambient temperature. Soft, cold and new tires roll harder.
+func (w *Wheel) GetRollingResistanceFactor() float64 {
+	pressure := math.Pow(NominalPressureKPa/math.Max(w.GetPressure(), 1), 0.4)
+	warm := math.Max(0.8, 1-warmRollingGain*(w.tireTempC-AmbientTempC))
+	worn := 1 - wornRollingGain*math.Min(1, w.getWear())
+	return pressure * warm * worn
+}
+
+// UpdateTire heats, cools and wears the tire over one step
+// Rolling and slip heat the carcass, the air flow cools it faster with speed.
+// The tread wears slowly with distance and fast while sliding, more so when hot.
+// Parameters:
+//
+//	normalLoad: normal load on the wheel in Newtons
+//	deltaTime: time elapsed in seconds
+func (w *Wheel) UpdateTire(normalLoad, deltaTime float64) {
+	speed := math.Abs(w.groundSpeed.MetersPerSecond())
+	slipSpeed := math.Abs((w.GetLinearSpeed() - w.groundSpeed).MetersPerSecond())
+	slipPower := math.Abs(w.GetLongitudinalForce(normalLoad)) * slipSpeed
+
+	heat := tireRollingLossCrr*normalLoad*speed + slipPower
+	cooling := (tireCoolingStill + tireCoolingPerMS*speed) * (w.tireTempC - AmbientTempC)
+	w.tireTempC += (heat - cooling) / tireHeatCapacity * deltaTime
+
+	wear := wearPerMeterMM*speed*deltaTime + wearPerSlipJMM*slipPower*math.Abs(w.GetSlip())*deltaTime
+	if w.tireTempC > wearHotTempC {
+		wear *= 1 + (w.tireTempC-wearHotTempC)/50
+	}
+	w.treadWornMM = math.Min(NewTreadDepthMM, w.treadWornMM+wear)
+}
+
+// GetTireState returns the pressure, temperature and wear of the tire
+func (w *Wheel) GetTireState() TireState {
+	return TireState{
+		PressureKPa:    w.GetPressure(),
+		TempC:          w.tireTempC,
+		TreadDepthMM:   w.GetTreadDepth(),
+		Wear:           w.getWear(),
+		RollingRadiusM: w.GetRollingRadius(),
+		GripFactor:     w.GetGripFactor(),
+	}
+}
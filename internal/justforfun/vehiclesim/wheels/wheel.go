@@ -1,6 +1,9 @@
 package wheels
 
-import "math"
+import (
+	"go-playground/internal/justforfun/vehiclesim/units"
+	"math"
+)
 
 // TireInfo contains detailed tire information
 type TireInfo struct {
@@ -10,12 +13,30 @@ type TireInfo struct {
 	SideWallHeightMM float64
 	TotalRadiusM     float64
 	CircumferenceM   float64
+	Service          string  // "P", "LT", "T", "ST" or "" for metric tires
+	LoadIndex        int     // 0 when the spec has no service description
+	LoadCapacityKg   float64 // Maximum load per tire, 0 when unknown
+	SpeedRating      string  // Speed symbol, e.g. "W"
+	MaxSpeedKMH      float64 // Maximum speed of the speed rating, 0 when unlimited or unknown
 }
 
+// minSlipSpeedMS avoids huge slip ratios when the vehicle is almost stopped
+const minSlipSpeedMS = 0.5
+
 // Wheel represents a wheel with its tire
 type Wheel struct {
 	tireSize *TireSize
-	speedRPM float64
+	speed    units.AngularVelocity
+
+	peakMu      float64      // Peak friction coefficient of the tire
+	surfaceMu   float64      // Friction coefficient of the road surface, 1.0 = dry asphalt
+	groundSpeed units.Speed  // Speed of the vehicle body over the ground
+	driveTorque units.Torque // Torque from the differential
+	brakeTorque units.Torque // Torque from the brake
+
+	coldPressureKPa float64 // Inflation pressure at ambient temperature
+	tireTempC       float64 // Carcass temperature
+	treadWornMM     float64 // Tread lost since new
 }
 
 // NewWheel creates a new Wheel instance with specific tire size
@@ -26,8 +47,12 @@ func NewWheel(tireSpec string) (*Wheel, error) {
 	}
 
 	return &Wheel{
-		tireSize: tireSize,
-		speedRPM: 0,
+		tireSize:  tireSize,
+		peakMu:    1.0,
+		surfaceMu: 1.0,
+
+		coldPressureKPa: NominalPressureKPa,
+		tireTempC:       AmbientTempC,
 	}, nil
 }
 
@@ -40,21 +65,116 @@ func (w *Wheel) GetTireInfo() TireInfo {
 		SideWallHeightMM: w.tireSize.SideWallHeight,
 		TotalRadiusM:     w.tireSize.TotalRadius,
 		CircumferenceM:   2 * math.Pi * w.tireSize.TotalRadius,
+		Service:          w.tireSize.Service,
+		LoadIndex:        w.tireSize.LoadIndex,
+		LoadCapacityKg:   w.tireSize.LoadCapacity,
+		SpeedRating:      w.tireSize.SpeedRating,
+		MaxSpeedKMH:      w.tireSize.MaxSpeedKMH,
 	}
 }
 
-// SetSpeedRPM sets wheel rotation speed in RPM
-func (w *Wheel) SetSpeedRPM(rpm float64) {
-	w.speedRPM = rpm
+// SetSpeed sets wheel rotation speed
+func (w *Wheel) SetSpeed(speed units.AngularVelocity) {
+	w.speed = speed
 }
 
-// GetSpeedRPM gets current wheel rotation speed in RPM
-func (w *Wheel) GetSpeedRPM() float64 {
-	return w.speedRPM
+// GetSpeed gets current wheel rotation speed
+func (w *Wheel) GetSpeed() units.AngularVelocity {
+	return w.speed
+}
+
+// GetLinearSpeed calculates the speed of the tread
+func (w *Wheel) GetLinearSpeed() units.Speed {
+	return units.SurfaceSpeed(w.speed, w.rollingRadius())
+}
+
+// SetGroundSpeed sets the speed of the vehicle body over the ground
+func (w *Wheel) SetGroundSpeed(speed units.Speed) {
+	w.groundSpeed = speed
+}
+
+// SetSurfaceMu sets the friction coefficient of the road under the wheel
+func (w *Wheel) SetSurfaceMu(mu float64) {
+	w.surfaceMu = math.Max(0, mu)
+}
+
+// SetDriveTorque sets the torque delivered by the differential
+func (w *Wheel) SetDriveTorque(torque units.Torque) {
+	w.driveTorque = torque
+}
+
+// SetBrakeTorque sets the brake torque, negative torques release the brake
+func (w *Wheel) SetBrakeTorque(torque units.Torque) {
+	w.brakeTorque = max(0, torque)
+}
+
+// GetBrakeTorque returns the brake torque
+func (w *Wheel) GetBrakeTorque() units.Torque {
+	return w.brakeTorque
+}
+
+// GetRollingSpeed returns the speed the wheel would turn at without slip at the current ground speed
+func (w *Wheel) GetRollingSpeed() units.AngularVelocity {
+	return units.RollingSpeed(w.groundSpeed, w.rollingRadius())
+}
+
+// GetSlip returns the longitudinal slip ratio between -1.0 and 1.0
+// Positive values mean wheelspin, negative values mean the wheel is locking
+func (w *Wheel) GetSlip() float64 {
+	wheelSpeed := w.GetLinearSpeed().MetersPerSecond()
+	groundSpeed := w.groundSpeed.MetersPerSecond()
+	reference := math.Max(math.Max(math.Abs(wheelSpeed), math.Abs(groundSpeed)), minSlipSpeedMS)
+	return (wheelSpeed - groundSpeed) / reference
+}
+
+// GetGripForce returns the peak longitudinal force in Newtons the tire can transmit
+func (w *Wheel) GetGripForce(normalLoad float64) float64 {
+	return w.peakMu * w.GetGripFactor() * w.surfaceMu * normalLoad
+}
+
+// GetDriveSpeed returns the wheel speed at which the tire transmits the drive minus brake torque
+// at the current ground speed. Returns false when the torque exceeds the grip, the wheel
+// then spins up or locks.
+func (w *Wheel) GetDriveSpeed(normalLoad float64) (units.AngularVelocity, bool) {
+	grip := w.GetGripForce(normalLoad)
+	if grip <= 0 {
+		return 0, false
+	}
+
+	forceFactor := w.wheelForce(w.driveTorque-w.brakeTorque) / grip
+	if math.Abs(forceFactor) >= 1 {
+		return 0, false
+	}
+	slip := SlipForForceFactor(forceFactor)
+
+	// Inverse of GetSlip: the reference is the faster of wheel and ground, at least minSlipSpeedMS
+	groundSpeed := w.groundSpeed.MetersPerSecond()
+	var wheelSpeed float64
+	switch {
+	case slip >= 0 && groundSpeed/(1-slip) >= minSlipSpeedMS:
+		wheelSpeed = groundSpeed / (1 - slip)
+	case slip < 0 && groundSpeed >= minSlipSpeedMS:
+		wheelSpeed = groundSpeed * (1 + slip)
+	default:
+		wheelSpeed = math.Max(0, groundSpeed+slip*minSlipSpeedMS)
+	}
+	return units.RollingSpeed(units.MetersPerSecond.Of(wheelSpeed), w.rollingRadius()), true
+}
+
+// GetLongitudinalForce calculates the force in Newtons the tire transmits to the ground
+// The tire generates force from the slip, bounded by what drive and brake torques can deliver:
+// a spinning wheel cannot push more than the drive torque, and a wheel slower than the ground
+// cannot hold back more than the brake plus the driveline coupling.
+func (w *Wheel) GetLongitudinalForce(normalLoad float64) float64 {
+	tireForce := w.GetGripForce(normalLoad) * TireForceFactor(w.GetSlip())
+
+	maxDrive := w.wheelForce(max(0, w.driveTorque-w.brakeTorque))
+	maxHold := w.wheelForce(w.brakeTorque + max(0, w.driveTorque))
+
+	return math.Max(-maxHold, math.Min(maxDrive, tireForce))
 }
 
-// GetLinearSpeedMS calculates linear speed in meters per second
-func (w *Wheel) GetLinearSpeedMS() float64 {
-	angularVelocity := w.speedRPM * RPMToRadPerSec
-	return angularVelocity * w.tireSize.TotalRadius
+// wheelForce returns the force in Newtons a torque on the wheel applies at the contact patch
+func (w *Wheel) wheelForce(torque units.Torque) float64 {
+	return torque.NewtonMeters() / w.GetRollingRadius()
 }
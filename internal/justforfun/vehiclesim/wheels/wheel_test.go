@@ -0,0 +1,58 @@
+package wheels
+
+import (
+	"go-playground/internal/justforfun/vehiclesim/units"
+	"math"
+	"testing"
+)
+
+// TestWheelDriveSpeed checks the drive speed is the wheel speed at which the tire delivers exactly the drive
+// minus brake torque, and that torques beyond the grip leave the wheel free to spin or lock
+func TestWheelDriveSpeed(t *testing.T) {
+	const load = 4000.0
+	tests := []struct {
+		groundMS    float64
+		driveTorque float64
+		brakeTorque float64
+	}{
+		{20, 400, 0},  // Accelerating
+		{20, 0, 600},  // Braking
+		{0.5, 400, 0}, // Pulling away, slip against the minimum reference speed
+	}
+	for _, tt := range tests {
+		wheel, err := NewWheel("245/40R19")
+		if err != nil {
+			t.Fatalf("NewWheel: %v", err)
+		}
+		wheel.SetGroundSpeed(units.MetersPerSecond.Of(tt.groundMS))
+		wheel.SetDriveTorque(units.NewtonMeters.Of(tt.driveTorque))
+		wheel.SetBrakeTorque(units.NewtonMeters.Of(tt.brakeTorque))
+
+		speed, ok := wheel.GetDriveSpeed(load)
+		if !ok {
+			t.Fatalf("%.0f Nm drive, %.0f Nm brake at %.1f m/s: over the grip", tt.driveTorque, tt.brakeTorque, tt.groundMS)
+		}
+		rpm := speed.RPM()
+		if direction := rpm - wheel.GetRollingSpeed().RPM(); direction*(tt.driveTorque-tt.brakeTorque) <= 0 {
+			t.Errorf("%.0f Nm drive, %.0f Nm brake at %.1f m/s: %.1f rpm against %.1f rpm over the ground",
+				tt.driveTorque, tt.brakeTorque, tt.groundMS, rpm, wheel.GetRollingSpeed().RPM())
+		}
+
+		wheel.SetSpeed(speed)
+		got := wheel.GetLongitudinalForce(load) * wheel.GetRollingRadius()
+		if want := tt.driveTorque - tt.brakeTorque; math.Abs(got-want) > 1e-6 {
+			t.Errorf("%.0f Nm drive, %.0f Nm brake at %.1f m/s: tire delivers %.3f Nm at %.1f rpm, want %.3f Nm",
+				tt.driveTorque, tt.brakeTorque, tt.groundMS, got, rpm, want)
+		}
+	}
+
+	wheel, err := NewWheel("245/40R19")
+	if err != nil {
+		t.Fatalf("NewWheel: %v", err)
+	}
+	wheel.SetGroundSpeed(units.MetersPerSecond.Of(20))
+	wheel.SetDriveTorque(units.NewtonMeters.Of(1.01 * wheel.GetGripForce(load) * wheel.GetRollingRadius()))
+	if speed, ok := wheel.GetDriveSpeed(load); ok {
+		t.Errorf("drive torque over the grip: held at %s, want the wheel spinning", speed)
+	}
+}
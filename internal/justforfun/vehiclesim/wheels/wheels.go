@@ -1,9 +1,13 @@
 package wheels
 
+import "go-playground/internal/justforfun/vehiclesim/units"
+
 // WheelPair represents a pair of wheels (left and right)
 type WheelPair struct {
 	Left  *Wheel
 	Right *Wheel
+
+	distanceM float64 // Distance traveled over the ground in meters
 }
 
 // NewWheelPair creates a new pair of wheels with specified tire size
@@ -24,40 +28,126 @@ func NewWheelPair(tireSpec string) (*WheelPair, error) {
 	}, nil
 }
 
-// Speed represents velocity in different units
-type Speed struct {
-	MS       float64 // Meters per second
-	KMH      float64 // Kilometers per hour
-	MPH      float64 // Miles per hour
-	WheelRPM float64 // Wheel RPM
+// GetVehicleSpeed calculates vehicle speed based on wheel speeds
+func (wp *WheelPair) GetVehicleSpeed() units.Speed {
+	return (wp.Left.GetLinearSpeed() + wp.Right.GetLinearSpeed()) / 2
 }
 
-// GetVehicleSpeed calculates vehicle speed based on wheel speeds
-func (wp *WheelPair) GetVehicleSpeed() Speed {
-	leftSpeed := wp.Left.GetLinearSpeedMS()
-	rightSpeed := wp.Right.GetLinearSpeedMS()
-	avgSpeedMS := (leftSpeed + rightSpeed) / 2
-
-	return Speed{
-		MS:       avgSpeedMS,
-		KMH:      avgSpeedMS * MSToKMH,
-		MPH:      avgSpeedMS * MSToMPH,
-		WheelRPM: (wp.Left.GetSpeedRPM() + wp.Right.GetSpeedRPM()) / 2,
+// Update updates wheel speeds
+func (wp *WheelPair) Update(left, right units.AngularVelocity) {
+	wp.Left.SetSpeed(left)
+	wp.Right.SetSpeed(right)
+}
+
+// SetGroundSpeed sets the speed of the vehicle body over the ground
+func (wp *WheelPair) SetGroundSpeed(speed units.Speed) {
+	wp.Left.SetGroundSpeed(speed)
+	wp.Right.SetGroundSpeed(speed)
+}
+
+// SetGroundSpeeds sets a different ground speed for each wheel, as happens in corners
+func (wp *WheelPair) SetGroundSpeeds(left, right units.Speed) {
+	wp.Left.SetGroundSpeed(left)
+	wp.Right.SetGroundSpeed(right)
+}
+
+// SetSurfaceMu sets the friction coefficient of the road under both wheels
+func (wp *WheelPair) SetSurfaceMu(mu float64) {
+	wp.Left.SetSurfaceMu(mu)
+	wp.Right.SetSurfaceMu(mu)
+}
+
+// SetColdPressure sets the inflation pressure of both tires in kPa at ambient temperature
+func (wp *WheelPair) SetColdPressure(kpa float64) error {
+	if err := wp.Left.SetColdPressure(kpa); err != nil {
+		return err
 	}
+	return wp.Right.SetColdPressure(kpa)
 }
 
-// Update updates wheel speeds
-func (wp *WheelPair) Update(leftRPM, rightRPM float64) {
-	wp.Left.SetSpeedRPM(leftRPM)
-	wp.Right.SetSpeedRPM(rightRPM)
+// UpdateTires heats, cools and wears both tires
+// Parameters:
+//
+//	wheelLoad: normal load on each wheel in Newtons
+//	deltaTime: time elapsed in seconds
+func (wp *WheelPair) UpdateTires(wheelLoad, deltaTime float64) {
+	wp.Left.UpdateTire(wheelLoad, deltaTime)
+	wp.Right.UpdateTire(wheelLoad, deltaTime)
+}
+
+// GetRollingResistanceFactor returns the average rolling resistance of both tires
+// relative to new tires at nominal pressure and ambient temperature
+func (wp *WheelPair) GetRollingResistanceFactor() float64 {
+	return (wp.Left.GetRollingResistanceFactor() + wp.Right.GetRollingResistanceFactor()) / 2
+}
+
+// UpdateDistance integrates the distance traveled over the ground
+// Parameters:
+//
+//	deltaTime: time elapsed in seconds
+func (wp *WheelPair) UpdateDistance(deltaTime float64) {
+	groundSpeed := (wp.Left.groundSpeed + wp.Right.groundSpeed) / 2
+	wp.distanceM += groundSpeed.MetersPerSecond() * deltaTime
+}
+
+// GetDistance returns the distance traveled over the ground in meters
+func (wp *WheelPair) GetDistance() float64 {
+	return wp.distanceM
+}
+
+// SetDriveTorque sets the torque delivered by the differential to each wheel
+func (wp *WheelPair) SetDriveTorque(leftTorque, rightTorque units.Torque) {
+	wp.Left.SetDriveTorque(leftTorque)
+	wp.Right.SetDriveTorque(rightTorque)
+}
+
+// SetBrakeTorque sets the brake torque of each wheel
+func (wp *WheelPair) SetBrakeTorque(leftTorque, rightTorque units.Torque) {
+	wp.Left.SetBrakeTorque(leftTorque)
+	wp.Right.SetBrakeTorque(rightTorque)
+}
+
+// GetRollingSpeed returns the wheel speed that matches the ground speed without slip
+func (wp *WheelPair) GetRollingSpeed() units.AngularVelocity {
+	return (wp.Left.GetRollingSpeed() + wp.Right.GetRollingSpeed()) / 2
+}
+
+// GetDrivelineSpeed returns the average wheel speed at which both tires transmit their torque
+// to the ground at the current ground speed. Returns false when a tire exceeds its grip.
+// Parameters:
+//
+//	wheelLoad: normal load on each wheel in Newtons
+func (wp *WheelPair) GetDrivelineSpeed(wheelLoad float64) (units.AngularVelocity, bool) {
+	left, leftOK := wp.Left.GetDriveSpeed(wheelLoad)
+	right, rightOK := wp.Right.GetDriveSpeed(wheelLoad)
+	return (left + right) / 2, leftOK && rightOK
+}
+
+// GetTractiveForce calculates the total longitudinal force of the pair in Newtons
+// Parameters:
+//
+//	wheelLoad: normal load on each wheel in Newtons
+func (wp *WheelPair) GetTractiveForce(wheelLoad float64) float64 {
+	return wp.Left.GetLongitudinalForce(wheelLoad) + wp.Right.GetLongitudinalForce(wheelLoad)
 }
 
 // GetData returns complete telemetry data
 func (wp *WheelPair) GetData() Telemetry {
+	speedKMH := wp.GetVehicleSpeed().KilometersPerHour()
+	tireInfo := wp.Left.GetTireInfo() // Assuming same size on both wheels
 	return Telemetry{
-		WheelSpeedL:  wp.Left.GetSpeedRPM(),
-		WheelSpeedR:  wp.Right.GetSpeedRPM(),
-		VehicleSpeed: wp.GetVehicleSpeed(),
-		TireInfo:     wp.Left.GetTireInfo(), // Assuming same size on both wheels
+		WheelSpeedL:     wp.Left.GetSpeed().RPM(),
+		WheelSpeedR:     wp.Right.GetSpeed().RPM(),
+		VehicleSpeedKMH: speedKMH,
+		SlipL:           wp.Left.GetSlip(),
+		SlipR:           wp.Right.GetSlip(),
+		BrakeTorqueL:    wp.Left.GetBrakeTorque().NewtonMeters(),
+		BrakeTorqueR:    wp.Right.GetBrakeTorque().NewtonMeters(),
+		BrakeTorque:     (wp.Left.GetBrakeTorque() + wp.Right.GetBrakeTorque()).NewtonMeters(),
+		DistanceM:       wp.distanceM,
+		TireInfo:        tireInfo,
+		TireL:           wp.Left.GetTireState(),
+		TireR:           wp.Right.GetTireState(),
+		OverSpeedRating: tireInfo.MaxSpeedKMH > 0 && speedKMH > tireInfo.MaxSpeedKMH,
 	}
 }